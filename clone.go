@@ -99,7 +99,35 @@ func cloneStateMachine(sm AbstractStateMachine) AbstractStateMachine {
 
 	eventHandlersField.Set(reflect.ValueOf(newHandlers))
 
-	return smc.Addr().Interface().(AbstractStateMachine)
+	cloned := smc.Addr().Interface().(AbstractStateMachine)
+	innerSM := getInnerStateMachine(cloned)
+	innerSM.regions = cloneRegions(innerSM.regions)
+
+	return cloned
+}
+
+func cloneRegions(regions []region) []region {
+	if regions == nil {
+		return nil
+	}
+	rc := make([]region, len(regions))
+	for i, r := range regions {
+		rc[i] = region{name: r.name, state: cloneState(r.state)}
+	}
+	return rc
+}
+
+// cloneValue copies an arbitrary value, deep copying map and slice contents
+// and the map and slice fields of structs.
+func cloneValue(value any) any {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return value
+	}
+	vc := reflect.New(v.Type()).Elem()
+	vc.Set(deepCopyValue(v))
+	deepCopyStructFields(vc)
+	return vc.Interface()
 }
 
 // WARNING: cloneEvent deep copies map and slice fields, but nested pointers are still shared.
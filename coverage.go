@@ -0,0 +1,320 @@
+package goat
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Coverage reports which parts of each state machine spec the exploration
+// exercised. Specs are identified by the type name of their state machines.
+type Coverage struct {
+	Specs []SpecCoverage
+}
+
+// SpecCoverage is the coverage of the state machines of one type.
+type SpecCoverage struct {
+	Name string
+	// States lists the states defined by the spec, main states first and
+	// then the states of each region, in definition order.
+	States []StateCoverage
+	// Handlers lists the handlers registered with OnEntry, OnEvent and the
+	// other On functions, grouped by state in the order of States.
+	Handlers []HandlerCoverage
+	// UnhandledEvents lists, sorted, the names of events the machines
+	// received but never processed with a handler in any world.
+	UnhandledEvents []string
+}
+
+// StateCoverage reports whether any explored world reached a state.
+type StateCoverage struct {
+	// Region is the orthogonal region the state belongs to, or empty for the
+	// main states.
+	Region  string
+	State   string
+	Reached bool
+}
+
+// HandlerCoverage reports whether a handler ran in any explored world.
+type HandlerCoverage struct {
+	Region string
+	State  string
+	// Handler names the kind of handler, such as "OnEntry" or
+	// "OnEvent(eRequest)".
+	Handler string
+	// Alternative is the position of the handler among the handlers the
+	// state has for the same event, which the model checker explores as
+	// non-deterministic alternatives. It is 0 for the first. As for
+	// Step.Handler, the built-in handlers that change state on Goto and halt
+	// on Halt come before those registered with OnTransition and OnHalt, so
+	// that these start at 1.
+	Alternative int
+	Fired       bool
+}
+
+// DeadHandlers returns the handlers that never fired, across all specs.
+func (c *Coverage) DeadHandlers() []HandlerCoverage {
+	var dead []HandlerCoverage
+	for _, spec := range c.Specs {
+		for _, h := range spec.Handlers {
+			if !h.Fired {
+				dead = append(dead, h)
+			}
+		}
+	}
+	return dead
+}
+
+// String returns a human-readable coverage report.
+func (c *Coverage) String() string {
+	var sb strings.Builder
+	sb.WriteString("Coverage:\n")
+	for _, spec := range c.Specs {
+		fmt.Fprintf(&sb, "  %s:\n", spec.Name)
+		sb.WriteString("    States:\n")
+		for _, s := range spec.States {
+			mark := "reached"
+			if !s.Reached {
+				mark = "unreached"
+			}
+			fmt.Fprintf(&sb, "      [%s] %s\n", mark, regionLabel(s.Region, s.State))
+		}
+		if len(spec.Handlers) > 0 {
+			sb.WriteString("    Handlers:\n")
+			for _, h := range spec.Handlers {
+				mark := "fired"
+				if !h.Fired {
+					mark = "never fired"
+				}
+				fmt.Fprintf(&sb, "      [%s] %s in %s\n", mark, h.label(), regionLabel(h.Region, h.State))
+			}
+		}
+		if len(spec.UnhandledEvents) > 0 {
+			fmt.Fprintf(&sb, "    Unhandled events: %s\n", strings.Join(spec.UnhandledEvents, ", "))
+		}
+	}
+	return sb.String()
+}
+
+func (h HandlerCoverage) label() string {
+	if h.Alternative == 0 {
+		return h.Handler
+	}
+	return fmt.Sprintf("%s (handler %d)", h.Handler, h.Alternative)
+}
+
+type stateKey struct {
+	region string
+	state  string
+}
+
+// handlerKey identifies a handler of a machine by the region whose event it
+// processed, the state it is registered on and its index among the handlers
+// of that state.
+type handlerKey struct {
+	machine string
+	region  string
+	state   string
+	index   int
+}
+
+// specUsage accumulates what the exploration exercised for one type.
+type specUsage struct {
+	// machines holds every machine of the type, by ID, as first seen. Their
+	// handlers may differ when they come from different specs.
+	machines map[string]*StateMachine
+	reached  map[stateKey]bool
+	fired    map[handlerKey]bool
+	received map[string]bool
+	handled  map[string]bool
+}
+
+// recordCoverage records the states the machines of env are in, the
+// events they received and the handlers that ran in the steps gss.
+func (x *exploration) recordCoverage(env environment, gss []globalStep) {
+	for _, smID := range sortedMachineIDs(env) {
+		sm := env.machines[smID]
+		innerSM := getInnerStateMachine(sm)
+		u := x.usage(getStateMachineName(sm))
+		if _, ok := u.machines[smID]; !ok {
+			u.machines[smID] = innerSM
+		}
+		for _, region := range regionNames(sm) {
+			u.reached[stateKey{region: region, state: getStateDetails(innerSM.stateIn(region))}] = true
+			queue := env.queue[queueID(smID, region)]
+			if !innerSM.halted && len(queue) > 0 && countableEvent(queue[0]) {
+				u.received[getEventName(queue[0])] = true
+			}
+		}
+	}
+
+	for _, gs := range gss {
+		sm := env.machines[gs.smID]
+		if gs.handlerState == "" || getInnerStateMachine(sm).halted {
+			continue
+		}
+		u := x.usage(getStateMachineName(sm))
+		u.fired[handlerKey{machine: gs.smID, region: gs.region, state: gs.handlerState, index: gs.handlerIndex}] = true
+		if event := env.queue[queueID(gs.smID, gs.region)][0]; countableEvent(event) {
+			u.handled[getEventName(event)] = true
+		}
+	}
+}
+
+// countableEvent reports whether event is sent by handlers, rather than
+// queued by Goto and Halt.
+func countableEvent(event AbstractEvent) bool {
+	return !isLifecycleEvent(event) && !sameEvent(event, &haltEvent{})
+}
+
+func (x *exploration) usage(name string) *specUsage {
+	u, ok := x.usages[name]
+	if !ok {
+		u = &specUsage{
+			machines: make(map[string]*StateMachine),
+			reached:  make(map[stateKey]bool),
+			fired:    make(map[handlerKey]bool),
+			received: make(map[string]bool),
+			handled:  make(map[string]bool),
+		}
+		x.usages[name] = u
+	}
+	return u
+}
+
+// coverage reports the states reached, the handlers that ran and the events
+// no handler processed, as recorded by Solve.
+func (m *model) coverage() *Coverage {
+	names := make([]string, 0, len(m.explored.usages))
+	for name := range m.explored.usages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c := &Coverage{}
+	for _, name := range names {
+		c.Specs = append(c.Specs, m.explored.usages[name].specCoverage(name))
+	}
+	return c
+}
+
+func (u *specUsage) specCoverage(name string) SpecCoverage {
+	sc := SpecCoverage{Name: name}
+
+	smIDs := make([]string, 0, len(u.machines))
+	for smID := range u.machines {
+		smIDs = append(smIDs, smID)
+	}
+	sort.Strings(smIDs)
+
+	// The states of all the machines, in definition order.
+	type regionState struct {
+		region string
+		state  AbstractState
+	}
+	var states []regionState
+	seen := make(map[stateKey]bool)
+	for _, smID := range smIDs {
+		def := u.machines[smID].definition
+		if def == nil {
+			continue
+		}
+		regions := []string{""}
+		for _, r := range def.regions {
+			regions = append(regions, r.name)
+		}
+		for _, region := range regions {
+			regionStates, _ := def.regionDefinition(region)
+			for _, state := range regionStates {
+				key := stateKey{region: region, state: getStateDetails(state)}
+				if !seen[key] {
+					seen[key] = true
+					states = append(states, regionState{region: region, state: state})
+				}
+			}
+		}
+	}
+
+	for _, rs := range states {
+		details := getStateDetails(rs.state)
+		sc.States = append(sc.States, StateCoverage{
+			Region:  rs.region,
+			State:   details,
+			Reached: u.reached[stateKey{region: rs.region, state: details}],
+		})
+
+		// A handler fired when it fired in any machine of the type.
+		var handlers []HandlerCoverage
+		for _, smID := range smIDs {
+			for _, hc := range u.handlerCoverage(smID, rs.region, rs.state) {
+				i := slices.IndexFunc(handlers, func(h HandlerCoverage) bool {
+					return h.Handler == hc.Handler && h.Alternative == hc.Alternative
+				})
+				if i < 0 {
+					handlers = append(handlers, hc)
+				} else {
+					handlers[i].Fired = handlers[i].Fired || hc.Fired
+				}
+			}
+		}
+		sc.Handlers = append(sc.Handlers, handlers...)
+	}
+
+	for eventName := range u.received {
+		if !u.handled[eventName] {
+			sc.UnhandledEvents = append(sc.UnhandledEvents, eventName)
+		}
+	}
+	sort.Strings(sc.UnhandledEvents)
+	return sc
+}
+
+func (u *specUsage) handlerCoverage(smID, region string, state AbstractState) []HandlerCoverage {
+	var his []handlerInfo
+	for s, infos := range u.machines[smID].EventHandlers {
+		if sameState(s, state) {
+			his = infos
+			break
+		}
+	}
+
+	details := getStateDetails(state)
+	var hcs []HandlerCoverage
+	for i, hi := range his {
+		switch hi.handler.(type) {
+		case *defaultOnTransitionHandler, *defaultOnHaltHandler:
+			continue
+		}
+		alternative := 0
+		for _, prev := range his[:i] {
+			if sameEvent(prev.event, hi.event) {
+				alternative++
+			}
+		}
+		hcs = append(hcs, HandlerCoverage{
+			Region:      region,
+			State:       details,
+			Handler:     handlerKind(hi.event),
+			Alternative: alternative,
+			Fired:       u.fired[handlerKey{machine: smID, region: region, state: details, index: i}],
+		})
+	}
+	return hcs
+}
+
+// handlerKind names the On function that registers handlers for event.
+func handlerKind(event AbstractEvent) string {
+	switch event.(type) {
+	case *entryEvent:
+		return "OnEntry"
+	case *exitEvent:
+		return "OnExit"
+	case *transitionEvent:
+		return "OnTransition"
+	case *haltEvent:
+		return "OnHalt"
+	default:
+		return "OnEvent(" + getEventName(event) + ")"
+	}
+}
@@ -0,0 +1,251 @@
+package goat
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newCoverageTestOptions(t *testing.T) []Option {
+	t.Helper()
+	idle := newTestState("idle")
+	done := newTestState("done")
+	unused := newTestState("unused")
+
+	receiverSpec := NewStateMachineSpec(&testStateMachine{})
+	receiverSpec.DefineStates(idle, done, unused).SetInitialState(idle)
+	OnEvent(receiverSpec, idle, func(ctx context.Context, _ *testEvent, _ *testStateMachine) {
+		Goto(ctx, done)
+	})
+	OnEvent(receiverSpec, idle, func(_ context.Context, _ *testEvent, _ *testStateMachine) {})
+	OnExit(receiverSpec, idle, func(_ context.Context, _ *testStateMachine) {})
+	OnEntry(receiverSpec, unused, func(_ context.Context, _ *testStateMachine) {})
+	receiver, err := receiverSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	senderSpec := NewStateMachineSpec(&hierarchyTestStateMachine{})
+	senderSpec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(senderSpec, idle, func(ctx context.Context, _ *hierarchyTestStateMachine) {
+		SendTo(ctx, receiver, &testEvent{Value: 1})
+		SendTo(ctx, receiver, &genericTestEvent[int]{Payload: 1})
+	})
+	sender, err := senderSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	return []Option{WithStateMachines(receiver, sender)}
+}
+
+func TestTest_coverage(t *testing.T) {
+	idle := "{Name:Name,Type:string,Value:idle}"
+	done := "{Name:Name,Type:string,Value:done}"
+	unused := "{Name:Name,Type:string,Value:unused}"
+	want := &Coverage{
+		Specs: []SpecCoverage{
+			{
+				Name:   "hierarchyTestStateMachine",
+				States: []StateCoverage{{State: idle, Reached: true}},
+				Handlers: []HandlerCoverage{
+					{State: idle, Handler: "OnEntry", Fired: true},
+				},
+			},
+			{
+				Name: "testStateMachine",
+				States: []StateCoverage{
+					{State: idle, Reached: true},
+					{State: done, Reached: true},
+					{State: unused},
+				},
+				Handlers: []HandlerCoverage{
+					{State: idle, Handler: "OnEvent(testEvent)", Fired: true},
+					{State: idle, Handler: "OnEvent(testEvent)", Alternative: 1, Fired: true},
+					{State: idle, Handler: "OnExit", Fired: true},
+					{State: unused, Handler: "OnEntry"},
+				},
+				UnhandledEvents: []string{"genericTestEvent[int]"},
+			},
+		},
+	}
+
+	t.Run("WithCoverage", func(t *testing.T) {
+		result, err := Test(append(newCoverageTestOptions(t), WithCoverage())...)
+		if err != nil {
+			t.Fatalf("Test() returned error: %v", err)
+		}
+		if diff := cmp.Diff(want, result.Coverage); diff != "" {
+			t.Errorf("Coverage mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("WithFailOnDeadHandlers", func(t *testing.T) {
+		result, err := Test(append(newCoverageTestOptions(t), WithFailOnDeadHandlers())...)
+		if err == nil {
+			t.Fatal("Test() should fail when a handler never fired")
+		}
+		if result == nil || result.Coverage == nil {
+			t.Fatal("Test() should return the result with coverage")
+		}
+		dead := result.Coverage.DeadHandlers()
+		wantDead := []HandlerCoverage{{State: unused, Handler: "OnEntry"}}
+		if diff := cmp.Diff(wantDead, dead); diff != "" {
+			t.Errorf("DeadHandlers() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestTest_coverageRegionsAndInstances(t *testing.T) {
+	idle := newTestState("idle")
+	busy := newTestState("busy")
+	idleDetails := "{Name:Name,Type:string,Value:idle}"
+	busyDetails := "{Name:Name,Type:string,Value:busy}"
+
+	// The main states never reach idle, while the region starts in it.
+	regionSpec := NewStateMachineSpec(&testStateMachine{})
+	regionSpec.DefineStates(busy, idle).
+		SetInitialState(busy).
+		DefineRegion("r", idle).
+		SetRegionInitialState("r", idle)
+	OnEntry(regionSpec, idle, func(_ context.Context, _ *testStateMachine) {})
+	withRegion, err := regionSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	// Another spec of the same type registers a handler the first one lacks.
+	otherSpec := NewStateMachineSpec(&testStateMachine{})
+	otherSpec.DefineStates(busy, idle).SetInitialState(busy)
+	OnEntry(otherSpec, busy, func(_ context.Context, _ *testStateMachine) {})
+	other, err := otherSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	result, err := Test(WithStateMachines(withRegion, other), WithCoverage())
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+
+	want := &Coverage{
+		Specs: []SpecCoverage{
+			{
+				Name: "testStateMachine",
+				States: []StateCoverage{
+					{State: busyDetails, Reached: true},
+					{State: idleDetails},
+					{Region: "r", State: idleDetails, Reached: true},
+				},
+				Handlers: []HandlerCoverage{
+					{State: busyDetails, Handler: "OnEntry", Fired: true},
+					{State: idleDetails, Handler: "OnEntry"},
+					{Region: "r", State: idleDetails, Handler: "OnEntry", Fired: true},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, result.Coverage); diff != "" {
+		t.Errorf("Coverage mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTest_coverageDoesNotRunHandlersAgain(t *testing.T) {
+	calls := 0
+	newOptions := func() []Option {
+		idle := newTestState("idle")
+		done := newTestState("done")
+		spec := NewStateMachineSpec(&testStateMachine{})
+		spec.DefineStates(idle, done).SetInitialState(idle)
+		OnEntry(spec, idle, func(ctx context.Context, _ *testStateMachine) {
+			calls++
+			Goto(ctx, done)
+		})
+		sm, err := spec.NewInstance()
+		if err != nil {
+			t.Fatalf("NewInstance() returned error: %v", err)
+		}
+		return []Option{WithStateMachines(sm), WithOutput(io.Discard)}
+	}
+
+	if _, err := Test(newOptions()...); err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	without := calls
+	calls = 0
+	if _, err := Test(append(newOptions(), WithCoverage())...); err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	if calls != without {
+		t.Errorf("handlers ran %d times with coverage, want %d as without it", calls, without)
+	}
+}
+
+func TestTest_coverageNumbersHandlersLikeSteps(t *testing.T) {
+	idle := newTestState("idle")
+	done := newTestState("done")
+	spec := NewStateMachineSpec(&hierarchyTestStateMachine{})
+	spec.DefineStates(idle, done).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, _ *hierarchyTestStateMachine) {
+		Goto(ctx, done)
+	})
+	OnTransition(spec, idle, func(_ context.Context, _ AbstractState, sm *hierarchyTestStateMachine) {
+		sm.Log = append(sm.Log, "transition")
+	})
+	OnEntry(spec, done, func(ctx context.Context, sm *hierarchyTestStateMachine) {
+		Halt(ctx, sm)
+	})
+	OnHalt(spec, done, func(_ context.Context, sm *hierarchyTestStateMachine) {
+		sm.Log = append(sm.Log, "halt")
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	result, err := Test(WithStateMachines(sm), WithCoverage(), WithOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+
+	// The handlers registered with OnTransition and OnHalt come after the
+	// built-in ones, in the coverage report as in the steps of a trace.
+	alternatives := make(map[string]int)
+	for _, h := range result.Coverage.Specs[0].Handlers {
+		alternatives[h.Handler] = h.Alternative
+	}
+	want := map[string]int{"OnEntry": 0, "OnTransition": 1, "OnHalt": 1}
+	if diff := cmp.Diff(want, alternatives); diff != "" {
+		t.Errorf("coverage alternatives mismatch (-want +got):\n%s", diff)
+	}
+
+	m, err := newModel(WithStateMachines(sm))
+	if err != nil {
+		t.Fatalf("newModel() returned error: %v", err)
+	}
+	if err := m.Solve(); err != nil {
+		t.Fatalf("Solve() returned error: %v", err)
+	}
+	handlers := make(map[string][]int)
+	for wid, nexts := range m.accessible {
+		for _, next := range nexts {
+			step := findStep(m.worlds[wid], m.worlds[next])
+			if step.Handler < 0 || (step.EventName != "transitionEvent" && step.EventName != "haltEvent") {
+				continue
+			}
+			if !slices.Contains(handlers[step.EventName], step.Handler) {
+				handlers[step.EventName] = append(handlers[step.EventName], step.Handler)
+			}
+		}
+	}
+	for _, hs := range handlers {
+		slices.Sort(hs)
+	}
+	wantSteps := map[string][]int{"transitionEvent": {0, 1}, "haltEvent": {0, 1}}
+	if diff := cmp.Diff(wantSteps, handlers); diff != "" {
+		t.Errorf("step handlers mismatch (-want +got):\n%s", diff)
+	}
+}
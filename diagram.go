@@ -0,0 +1,258 @@
+package goat
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DiagramFormat selects the text format a diagram is written in.
+type DiagramFormat int
+
+const (
+	// FormatMermaid writes diagrams in Mermaid syntax.
+	FormatMermaid DiagramFormat = iota
+	// FormatPlantUML writes diagrams in PlantUML syntax.
+	FormatPlantUML
+	// FormatDOT writes diagrams in the Graphviz DOT language.
+	FormatDOT
+)
+
+// String returns the name of the format.
+func (f DiagramFormat) String() string {
+	switch f {
+	case FormatMermaid:
+		return "mermaid"
+	case FormatPlantUML:
+		return "plantuml"
+	case FormatDOT:
+		return "dot"
+	default:
+		return fmt.Sprintf("DiagramFormat(%d)", int(f))
+	}
+}
+
+// WriteSequenceDiagram writes the violation as a sequence diagram in the given
+// format. Every state machine taking part in the trace becomes a lifeline,
+// every event sent between machines an arrow labelled with the event and its
+// fields, and every state change a note over the machine. The loop of a
+// temporal rule violation is drawn as a loop block.
+//
+// Parameters:
+//   - w: Destination for the diagram
+//   - format: FormatMermaid or FormatPlantUML
+//
+// Returns an error if the format is unknown or writing to w fails.
+//
+// Example:
+//
+//	result, _ := goat.Test(opts...)
+//	for _, v := range result.Violations {
+//	    _ = v.WriteSequenceDiagram(os.Stdout, goat.FormatMermaid)
+//	}
+func (v *Violation) WriteSequenceDiagram(w io.Writer, format DiagramFormat) error {
+	if format != FormatMermaid && format != FormatPlantUML {
+		return fmt.Errorf("unsupported diagram format: %s", format)
+	}
+
+	sd := &sequenceDiagram{
+		format: format,
+		states: make(map[string]string),
+	}
+	sd.writeHeader(v.Steps, v.LoopSteps)
+	sd.writeSteps(v.Steps, "    ")
+	if v.Loop != nil {
+		sd.line("    ", "loop repeats forever")
+		sd.writeSteps(v.LoopSteps, "        ")
+		sd.line("    ", "end")
+	}
+	if format == FormatPlantUML {
+		sd.sb.WriteString("@enduml\n")
+	}
+
+	_, err := io.WriteString(w, sd.sb.String())
+	return err
+}
+
+type sequenceDiagram struct {
+	sb           strings.Builder
+	format       DiagramFormat
+	participants []string
+	// states holds the last state noted for each machine and region.
+	states map[string]string
+}
+
+func (sd *sequenceDiagram) line(indent, format string, args ...any) {
+	sd.sb.WriteString(indent)
+	fmt.Fprintf(&sd.sb, format, args...)
+	sd.sb.WriteString("\n")
+}
+
+// writeHeader declares a lifeline for every machine that appears in steps,
+// in order of appearance. Machines created with Spawn are declared where they
+// are created instead.
+func (sd *sequenceDiagram) writeHeader(stepLists ...[]Step) {
+	if sd.format == FormatMermaid {
+		sd.sb.WriteString("sequenceDiagram\n")
+	} else {
+		sd.sb.WriteString("@startuml\n")
+	}
+
+	spawned := make(map[string]bool)
+	seen := make(map[string]bool)
+	var order []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			order = append(order, id)
+		}
+	}
+	for _, steps := range stepLists {
+		for _, step := range steps {
+			for _, id := range step.Spawned {
+				spawned[id] = true
+			}
+			add(step.Machine)
+			for _, sent := range step.Sent {
+				add(sent.Target)
+			}
+		}
+	}
+	sd.participants = order
+	for _, id := range order {
+		if !spawned[id] {
+			sd.line("    ", "participant %s", id)
+		}
+	}
+}
+
+func (sd *sequenceDiagram) writeSteps(steps []Step, indent string) {
+	for _, step := range steps {
+		if step.Machine == "" {
+			if len(sd.participants) > 0 {
+				sd.note(indent, sd.participants[0], "no state machine can move")
+			}
+			continue
+		}
+
+		key := queueID(step.Machine, step.Region)
+		if _, ok := sd.states[key]; !ok {
+			sd.states[key] = step.StateBefore
+			sd.note(indent, step.Machine, regionLabel(step.Region, step.StateBefore))
+		}
+
+		for _, id := range step.Spawned {
+			if sd.format == FormatMermaid {
+				sd.line(indent, "create participant %s", id)
+				sd.line(indent, "%s-->>%s: spawn", step.Machine, id)
+			} else {
+				sd.line(indent, "create %s", id)
+				sd.line(indent, "%s --> %s : spawn", step.Machine, id)
+			}
+		}
+
+		for _, sent := range step.Sent {
+			label := eventLabel(sent.EventName, sent.Details)
+			if sent.Group != "" {
+				label += " [" + sent.Group + "]"
+			}
+			if sd.format == FormatMermaid {
+				sd.line(indent, "%s->>%s: %s", step.Machine, sent.Target, escapeMermaid(label))
+			} else {
+				sd.line(indent, "%s -> %s : %s", step.Machine, sent.Target, label)
+			}
+		}
+
+		if sd.states[key] != step.StateAfter {
+			sd.states[key] = step.StateAfter
+			sd.note(indent, step.Machine, regionLabel(step.Region, step.StateAfter))
+		}
+	}
+}
+
+func (sd *sequenceDiagram) note(indent, participant, text string) {
+	if sd.format == FormatMermaid {
+		sd.line(indent, "Note over %s: %s", participant, escapeMermaid(text))
+	} else {
+		sd.line(indent, "note over %s : %s", participant, text)
+	}
+}
+
+func regionLabel(region, stateDetails string) string {
+	label := fieldsLabel(stateDetails)
+	if region == "" {
+		return label
+	}
+	return region + ": " + label
+}
+
+// eventLabel renders an event as its name followed by its fields, for
+// example "eRequest(ID=1, Name=x)".
+func eventLabel(name, details string) string {
+	if details == noFieldsMessage {
+		return name
+	}
+	return name + "(" + fieldsLabel(details) + ")"
+}
+
+// fieldsLabel turns details in the "{Name:N,Type:T,Value:V}" format into a
+// compact "N=V" list.
+func fieldsLabel(details string) string {
+	fields := parseDetails(details)
+	if len(fields) == 0 {
+		return details
+	}
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		parts = append(parts, f.name+"="+f.value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+type detailField struct {
+	name  string
+	typ   string
+	value string
+}
+
+// parseDetails splits details produced by getStateDetails, getEventDetails
+// and getStateMachineDetails back into fields. It returns nil when details
+// are not in that format.
+func parseDetails(details string) []detailField {
+	const (
+		namePrefix  = "{Name:"
+		typePrefix  = ",Type:"
+		valuePrefix = ",Value:"
+		separator   = "},{Name:"
+	)
+	if !strings.HasPrefix(details, namePrefix) || !strings.HasSuffix(details, "}") {
+		return nil
+	}
+
+	var fields []detailField
+	rest := details[len(namePrefix) : len(details)-1]
+	for {
+		name, afterName, ok := strings.Cut(rest, typePrefix)
+		if !ok {
+			return nil
+		}
+		typ, afterType, ok := strings.Cut(afterName, valuePrefix)
+		if !ok {
+			return nil
+		}
+		value, next, more := strings.Cut(afterType, separator)
+		fields = append(fields, detailField{name: name, typ: typ, value: value})
+		if !more {
+			return fields
+		}
+		rest = next
+	}
+}
+
+// escapeMermaid escapes the characters Mermaid treats as statement
+// separators or entity codes in message text.
+func escapeMermaid(s string) string {
+	return mermaidEscaper.Replace(s)
+}
+
+var mermaidEscaper = strings.NewReplacer("#", "#35;", ";", "#59;")
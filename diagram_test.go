@@ -0,0 +1,203 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestViolation_WriteSequenceDiagram(t *testing.T) {
+	violation := Violation{
+		Rule: "eventually always done",
+		Path: []WorldSnapshot{{}, {}},
+		Loop: []WorldSnapshot{{}},
+		Steps: []Step{
+			{
+				Machine:      "Client",
+				EventName:    "entryEvent",
+				EventDetails: "no fields",
+				StateBefore:  "{Name:Name,Type:string,Value:idle}",
+				StateAfter:   "{Name:Name,Type:string,Value:waiting}",
+				Sent: []SentEvent{
+					{Target: "Server", EventName: "eRequest", Details: "{Name:ID,Type:int,Value:1},{Name:Path,Type:string,Value:a;b}"},
+				},
+				Spawned: []string{"Worker"},
+			},
+		},
+		LoopSteps: []Step{
+			{
+				Machine:      "Server",
+				Region:       "audit",
+				EventName:    "eRequest",
+				EventDetails: "{Name:ID,Type:int,Value:1}",
+				StateBefore:  "{Name:Name,Type:string,Value:ready}",
+				StateAfter:   "{Name:Name,Type:string,Value:ready}",
+				Sent: []SentEvent{
+					{Target: "Client", EventName: "eResponse", Details: "no fields", Group: "[Client]"},
+				},
+			},
+			{},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		format  DiagramFormat
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "mermaid",
+			format: FormatMermaid,
+			want: `sequenceDiagram
+    participant Client
+    participant Server
+    Note over Client: Name=idle
+    create participant Worker
+    Client-->>Worker: spawn
+    Client->>Server: eRequest(ID=1, Path=a#59;b)
+    Note over Client: Name=waiting
+    loop repeats forever
+        Note over Server: audit: Name=ready
+        Server->>Client: eResponse [[Client]]
+        Note over Client: no state machine can move
+    end
+`,
+		},
+		{
+			name:   "plantuml",
+			format: FormatPlantUML,
+			want: `@startuml
+    participant Client
+    participant Server
+    note over Client : Name=idle
+    create Worker
+    Client --> Worker : spawn
+    Client -> Server : eRequest(ID=1, Path=a;b)
+    note over Client : Name=waiting
+    loop repeats forever
+        note over Server : audit: Name=ready
+        Server -> Client : eResponse [[Client]]
+        note over Client : no state machine can move
+    end
+@enduml
+`,
+		},
+		{
+			name:    "unsupported format",
+			format:  DiagramFormat(-1),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := violation.WriteSequenceDiagram(&buf, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteSequenceDiagram() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("diagram mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		details string
+		want    []detailField
+	}{
+		{
+			name:    "no fields",
+			details: noFieldsMessage,
+			want:    nil,
+		},
+		{
+			name:    "single field",
+			details: "{Name:ID,Type:int,Value:1}",
+			want:    []detailField{{name: "ID", typ: "int", value: "1"}},
+		},
+		{
+			name:    "values containing separators",
+			details: "{Name:Tags,Type:[]string,Value:[a,b]},{Name:Pos,Type:main.Point,Value:{1 2}}",
+			want: []detailField{
+				{name: "Tags", typ: "[]string", value: "[a,b]"},
+				{name: "Pos", typ: "main.Point", value: "{1 2}"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseDetails(tt.details)
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(detailField{})); diff != "" {
+				t.Errorf("parseDetails() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_buildSteps(t *testing.T) {
+	idle := newTestState("idle")
+	done := newTestState("done")
+
+	receiverSpec := NewStateMachineSpec(&testStateMachine{})
+	receiverSpec.DefineStates(idle, done).SetInitialState(idle)
+	OnEvent(receiverSpec, idle, func(ctx context.Context, _ *testEvent, _ *testStateMachine) {
+		Goto(ctx, done)
+	})
+	receiver, err := receiverSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	senderSpec := NewStateMachineSpec(&testStateMachine{})
+	senderSpec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(senderSpec, idle, func(ctx context.Context, _ *testStateMachine) {
+		SendTo(ctx, receiver, &testEvent{Value: 1})
+	})
+	sender, err := senderSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	result, err := Test(
+		WithStateMachines(receiver, sender),
+		WithRules(Always(NewCondition("not done", receiver, func(sm *testStateMachine) bool {
+			return !sameState(sm.currentState(), done)
+		}))),
+	)
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(result.Violations))
+	}
+
+	idleDetails := "{Name:Name,Type:string,Value:idle}"
+	doneDetails := "{Name:Name,Type:string,Value:done}"
+	want := []Step{
+		{Machine: "testStateMachine", EventName: "entryEvent", EventDetails: "no fields", Handler: -1, StateBefore: idleDetails, StateAfter: idleDetails},
+		{
+			Machine:      "testStateMachine_1",
+			EventName:    "entryEvent",
+			EventDetails: "no fields",
+			StateBefore:  idleDetails,
+			StateAfter:   idleDetails,
+			Sent: []SentEvent{
+				{Target: "testStateMachine", EventName: "testEvent", Details: "{Name:Value,Type:int,Value:1}"},
+			},
+		},
+		{Machine: "testStateMachine", EventName: "testEvent", EventDetails: "{Name:Value,Type:int,Value:1}", StateBefore: idleDetails, StateAfter: idleDetails},
+		{Machine: "testStateMachine", EventName: "exitEvent", EventDetails: "no fields", Handler: -1, StateBefore: idleDetails, StateAfter: idleDetails},
+		{Machine: "testStateMachine", EventName: "transitionEvent", EventDetails: "{Name:To,Type:goat.AbstractState,Value:&{{0} done}}", StateBefore: idleDetails, StateAfter: doneDetails},
+	}
+	if diff := cmp.Diff(want, result.Violations[0].Steps); diff != "" {
+		t.Errorf("Steps mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,323 @@
+package goat
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// WorldDiff describes what changed between a world of a violation trace and
+// the world before it. The first world of a trace is compared against an
+// empty world, so everything in it is reported as added.
+type WorldDiff struct {
+	// Step is the step that led to the world. It is the zero Step for the
+	// first world.
+	Step Step
+	// Machines lists the state machines that were added or changed, in the
+	// order of WorldSnapshot.StateMachines.
+	Machines []MachineDiff
+	// QueueRemoved and QueueAdded list the queued events that disappeared
+	// from and appeared in the queues.
+	QueueRemoved []EventSnapshot
+	QueueAdded   []EventSnapshot
+	// SharedVars lists the shared variables whose value changed.
+	SharedVars []FieldChange
+}
+
+// MachineDiff describes the changes of one state machine between two worlds.
+type MachineDiff struct {
+	Name string
+	// Added reports that the machine is not in the previous world. All its
+	// fields are then listed with an empty Before.
+	Added bool
+	// StateBefore and StateAfter are the main state before and after, and
+	// are both empty when the main state did not change.
+	StateBefore string
+	StateAfter  string
+	// Regions lists the orthogonal regions whose state changed.
+	Regions []FieldChange
+	// Fields lists the fields of the state machine whose value changed.
+	Fields []FieldChange
+}
+
+// FieldChange is a value that differs between two worlds.
+type FieldChange struct {
+	Name   string
+	Before string
+	After  string
+}
+
+// Diff returns, for each world of the violation trace, what changed from the
+// world before it. For a temporal rule violation the worlds of Loop follow
+// those of Path, and the loop starts from the last world of Path.
+//
+// Returns one WorldDiff per world of the trace.
+//
+// Example:
+//
+//	for i, d := range violation.Diff() {
+//	    for _, m := range d.Machines {
+//	        fmt.Println(i, m.Name, m.Fields)
+//	    }
+//	}
+func (v *Violation) Diff() []WorldDiff {
+	snapshots, steps := v.trace()
+	diffs := make([]WorldDiff, 0, len(snapshots))
+	var prev WorldSnapshot
+	for i, snapshot := range snapshots {
+		d := diffWorlds(prev, snapshot)
+		if i > 0 {
+			d.Step = steps[i-1]
+		}
+		diffs = append(diffs, d)
+		prev = snapshot
+	}
+	return diffs
+}
+
+// trace returns the worlds of the violation in order, without repeating the
+// world the loop starts from, and the steps between them.
+func (v *Violation) trace() ([]WorldSnapshot, []Step) {
+	snapshots := append([]WorldSnapshot{}, v.Path...)
+	steps := make([]Step, 0, len(v.Path)+len(v.Loop))
+	for i := range max(len(v.Path)-1, 0) {
+		steps = append(steps, stepAt(v.Steps, i))
+	}
+	if len(v.Loop) == 0 {
+		return snapshots, steps
+	}
+
+	loop := v.Loop
+	if len(v.Path) > 0 && reflect.DeepEqual(v.Path[len(v.Path)-1], v.Loop[0]) {
+		loop = loop[1:]
+		for i := range loop {
+			steps = append(steps, stepAt(v.LoopSteps, i))
+		}
+	} else {
+		steps = append(steps, Step{})
+		for i := range len(loop) - 1 {
+			steps = append(steps, stepAt(v.LoopSteps, i))
+		}
+	}
+	return append(snapshots, loop...), steps
+}
+
+func stepAt(steps []Step, i int) Step {
+	if i < len(steps) {
+		return steps[i]
+	}
+	return Step{}
+}
+
+func diffWorlds(before, after WorldSnapshot) WorldDiff {
+	var d WorldDiff
+	for _, md := range machineDiffs(before, after) {
+		if md.changed() {
+			d.Machines = append(d.Machines, md)
+		}
+	}
+
+	d.QueueRemoved = subtractEvents(before.QueuedEvents, after.QueuedEvents)
+	d.QueueAdded = subtractEvents(after.QueuedEvents, before.QueuedEvents)
+
+	previousVars := make(map[string]string, len(before.SharedVars))
+	for _, v := range before.SharedVars {
+		previousVars[v.Name] = v.Details
+	}
+	for _, v := range after.SharedVars {
+		if old, ok := previousVars[v.Name]; !ok || old != v.Details {
+			d.SharedVars = append(d.SharedVars, FieldChange{Name: v.Name, Before: old, After: v.Details})
+		}
+	}
+	return d
+}
+
+// machineDiffs returns one MachineDiff per state machine of after, in order,
+// whether it changed or not.
+func machineDiffs(before, after WorldSnapshot) []MachineDiff {
+	// Machines of the same type share a name, so the n-th machine with a
+	// name is compared with the n-th machine with that name before.
+	previous := make(map[string][]StateMachineSnapshot)
+	for _, sm := range before.StateMachines {
+		previous[sm.Name] = append(previous[sm.Name], sm)
+	}
+	seen := make(map[string]int)
+	diffs := make([]MachineDiff, 0, len(after.StateMachines))
+	for _, sm := range after.StateMachines {
+		n := seen[sm.Name]
+		seen[sm.Name]++
+		var old *StateMachineSnapshot
+		if n < len(previous[sm.Name]) {
+			old = &previous[sm.Name][n]
+		}
+		diffs = append(diffs, diffMachines(old, sm))
+	}
+	return diffs
+}
+
+func diffMachines(before *StateMachineSnapshot, after StateMachineSnapshot) MachineDiff {
+	md := MachineDiff{Name: after.Name}
+	if before == nil {
+		md.Added = true
+		before = &StateMachineSnapshot{}
+	}
+	if before.State != after.State {
+		md.StateBefore = before.State
+		md.StateAfter = after.State
+	}
+
+	previousRegions := make(map[string]string, len(before.Regions))
+	for _, r := range before.Regions {
+		previousRegions[r.Name] = r.State
+	}
+	for _, r := range after.Regions {
+		if old, ok := previousRegions[r.Name]; !ok || old != r.State {
+			md.Regions = append(md.Regions, FieldChange{Name: r.Name, Before: old, After: r.State})
+		}
+	}
+
+	previousFields := detailValues(before.Details)
+	for _, f := range parseDetails(after.Details) {
+		if old, ok := previousFields[f.name]; !ok || old != f.value {
+			md.Fields = append(md.Fields, FieldChange{Name: f.name, Before: old, After: f.value})
+		}
+	}
+	return md
+}
+
+func (md MachineDiff) changed() bool {
+	return md.Added || md.StateAfter != "" || len(md.Regions) > 0 || len(md.Fields) > 0
+}
+
+// subtractEvents returns the events of a that are not in b, counting
+// duplicates.
+func subtractEvents(a, b []EventSnapshot) []EventSnapshot {
+	remaining := make(map[EventSnapshot]int, len(b))
+	for _, e := range b {
+		remaining[e]++
+	}
+	var result []EventSnapshot
+	for _, e := range a {
+		if remaining[e] > 0 {
+			remaining[e]--
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// WriteDiff writes the violation as a trace that shows, for each world, only
+// the state machines, fields, queued events and shared variables that changed
+// from the world before, instead of the full details of every world.
+//
+// Parameters:
+//   - w: Destination for the trace
+//
+// Returns an error if writing to w fails.
+//
+// Example:
+//
+//	result, _ := goat.Test(opts...)
+//	for _, v := range result.Violations {
+//	    _ = v.WriteDiff(os.Stdout)
+//	}
+func (v *Violation) WriteDiff(w io.Writer) error {
+	var sb strings.Builder
+	if rule := strings.TrimSpace(v.Rule); rule == "" {
+		sb.WriteString("Condition failed.\n")
+	} else {
+		fmt.Fprintf(&sb, "Condition failed. Not %s.\n", rule)
+	}
+
+	diffs := v.Diff()
+	for i, d := range diffs {
+		fmt.Fprintf(&sb, "  [%d]", i)
+		if i > 0 {
+			sb.WriteString(" ")
+			sb.WriteString(describeStepText(d.Step))
+		}
+		if v.Loop == nil && i == len(diffs)-1 {
+			sb.WriteString(" <-- violation here")
+		}
+		if v.Loop != nil && i == len(v.Path)-1 {
+			sb.WriteString(" <-- loop starts here")
+		}
+		sb.WriteString("\n")
+		writeWorldDiff(&sb, d, i == 0)
+	}
+	if v.Loop != nil && len(v.Path) > 0 {
+		fmt.Fprintf(&sb, "  back to [%d]", len(v.Path)-1)
+		if len(v.LoopSteps) > 0 {
+			sb.WriteString(" ")
+			sb.WriteString(describeStepText(v.LoopSteps[len(v.LoopSteps)-1]))
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeWorldDiff(sb *strings.Builder, d WorldDiff, initial bool) {
+	if len(d.Machines) == 0 && len(d.QueueRemoved) == 0 && len(d.QueueAdded) == 0 && len(d.SharedVars) == 0 {
+		sb.WriteString("    (no changes)\n")
+		return
+	}
+	for _, m := range d.Machines {
+		sb.WriteString("    ")
+		sb.WriteString(m.Name)
+		if m.Added && !initial {
+			sb.WriteString(" (spawned)")
+		}
+		sb.WriteString("\n")
+		if m.StateAfter != "" {
+			writeChange(sb, "State", fieldsLabel(m.StateBefore), fieldsLabel(m.StateAfter), m.Added)
+		}
+		for _, r := range m.Regions {
+			writeChange(sb, "#"+r.Name, fieldsLabel(r.Before), fieldsLabel(r.After), m.Added)
+		}
+		for _, f := range m.Fields {
+			writeChange(sb, f.Name, f.Before, f.After, m.Added)
+		}
+	}
+	if len(d.QueueRemoved) > 0 || len(d.QueueAdded) > 0 {
+		sb.WriteString("    Queue\n")
+		for _, e := range d.QueueRemoved {
+			sb.WriteString("      - ")
+			sb.WriteString(queueEntryLabel(e))
+			sb.WriteString("\n")
+		}
+		for _, e := range d.QueueAdded {
+			sb.WriteString("      + ")
+			sb.WriteString(queueEntryLabel(e))
+			sb.WriteString("\n")
+		}
+	}
+	if len(d.SharedVars) > 0 {
+		sb.WriteString("    SharedVars\n")
+		for _, v := range d.SharedVars {
+			writeChange(sb, v.Name, v.Before, v.After, initial)
+		}
+	}
+}
+
+// writeChange writes a changed value as "name: before -> after", or as
+// "name = after" when the value is new.
+func writeChange(sb *strings.Builder, name, before, after string, added bool) {
+	if added {
+		fmt.Fprintf(sb, "      %s = %s\n", name, after)
+		return
+	}
+	fmt.Fprintf(sb, "      %s: %s -> %s\n", name, before, after)
+}
+
+// queueEntryLabel renders a queued event as "Target#region << event(F=V)".
+func queueEntryLabel(e EventSnapshot) string {
+	target := e.TargetMachine
+	if e.TargetRegion != "" {
+		target += "#" + e.TargetRegion
+	}
+	return target + " << " + eventLabel(e.EventName, e.Details)
+}
@@ -0,0 +1,155 @@
+package goat
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newDiffTestViolation() Violation {
+	idle := "{Name:Name,Type:string,Value:idle}"
+	busy := "{Name:Name,Type:string,Value:busy}"
+	request := EventSnapshot{TargetMachine: "Server", EventName: "eRequest", Details: "{Name:ID,Type:int,Value:1}"}
+	return Violation{
+		Rule: "Eventually always idle",
+		Path: []WorldSnapshot{
+			{
+				StateMachines: []StateMachineSnapshot{
+					{Name: "Server", State: idle, Details: "{Name:Count,Type:int,Value:0},{Name:Log,Type:string,Value:on}"},
+				},
+				QueuedEvents: []EventSnapshot{request},
+				SharedVars:   []SharedVarSnapshot{{Name: "total", Details: "0"}},
+			},
+			{
+				StateMachines: []StateMachineSnapshot{
+					{Name: "Server", State: busy, Details: "{Name:Count,Type:int,Value:1},{Name:Log,Type:string,Value:on}"},
+					{Name: "Worker", State: idle, Details: "no fields"},
+				},
+				QueuedEvents: []EventSnapshot{},
+				SharedVars:   []SharedVarSnapshot{{Name: "total", Details: "1"}},
+			},
+		},
+		Loop: []WorldSnapshot{
+			{
+				StateMachines: []StateMachineSnapshot{
+					{Name: "Server", State: busy, Details: "{Name:Count,Type:int,Value:1},{Name:Log,Type:string,Value:on}"},
+					{Name: "Worker", State: idle, Details: "no fields"},
+				},
+				QueuedEvents: []EventSnapshot{},
+				SharedVars:   []SharedVarSnapshot{{Name: "total", Details: "1"}},
+			},
+		},
+		Steps: []Step{
+			{Machine: "Server", EventName: "eRequest", EventDetails: "{Name:ID,Type:int,Value:1}", StateBefore: idle, StateAfter: busy, Spawned: []string{"Worker"}},
+		},
+		LoopSteps: []Step{{}},
+	}
+}
+
+func TestViolation_Diff(t *testing.T) {
+	violation := newDiffTestViolation()
+
+	want := []WorldDiff{
+		{
+			Machines: []MachineDiff{
+				{
+					Name:       "Server",
+					Added:      true,
+					StateAfter: "{Name:Name,Type:string,Value:idle}",
+					Fields: []FieldChange{
+						{Name: "Count", After: "0"},
+						{Name: "Log", After: "on"},
+					},
+				},
+			},
+			QueueAdded: []EventSnapshot{
+				{TargetMachine: "Server", EventName: "eRequest", Details: "{Name:ID,Type:int,Value:1}"},
+			},
+			SharedVars: []FieldChange{{Name: "total", After: "0"}},
+		},
+		{
+			Step: violation.Steps[0],
+			Machines: []MachineDiff{
+				{
+					Name:        "Server",
+					StateBefore: "{Name:Name,Type:string,Value:idle}",
+					StateAfter:  "{Name:Name,Type:string,Value:busy}",
+					Fields:      []FieldChange{{Name: "Count", Before: "0", After: "1"}},
+				},
+				{Name: "Worker", Added: true, StateAfter: "{Name:Name,Type:string,Value:idle}"},
+			},
+			QueueRemoved: []EventSnapshot{
+				{TargetMachine: "Server", EventName: "eRequest", Details: "{Name:ID,Type:int,Value:1}"},
+			},
+			SharedVars: []FieldChange{{Name: "total", Before: "0", After: "1"}},
+		},
+	}
+	if diff := cmp.Diff(want, violation.Diff()); diff != "" {
+		t.Errorf("Diff() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestViolation_WriteDiff(t *testing.T) {
+	violation := newDiffTestViolation()
+
+	var buf bytes.Buffer
+	if err := violation.WriteDiff(&buf); err != nil {
+		t.Fatalf("WriteDiff() returned error: %v", err)
+	}
+
+	want := `Condition failed. Not Eventually always idle.
+  [0]
+    Server
+      State = Name=idle
+      Count = 0
+      Log = on
+    Queue
+      + Server << eRequest(ID=1)
+    SharedVars
+      total = 0
+  [1] Server processed eRequest(ID=1) <-- loop starts here
+    Server
+      State: Name=idle -> Name=busy
+      Count: 0 -> 1
+    Worker (spawned)
+      State = Name=idle
+    Queue
+      - Server << eRequest(ID=1)
+    SharedVars
+      total: 0 -> 1
+  back to [1] no state machine can move
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("WriteDiff() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestViolation_WriteDiff_invariant(t *testing.T) {
+	result, err := Test(
+		WithStateMachines(newTestStateMachine(newTestState("s"))),
+		WithRules(Always(BoolCondition("never", false))),
+	)
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(result.Violations))
+	}
+
+	var buf bytes.Buffer
+	if err := result.Violations[0].WriteDiff(&buf); err != nil {
+		t.Fatalf("WriteDiff() returned error: %v", err)
+	}
+
+	want := `Condition failed. Not Always never.
+  [0] <-- violation here
+    testStateMachine
+      State = Name=s
+    Queue
+      + testStateMachine << entryEvent
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("WriteDiff() mismatch (-want +got):\n%s", diff)
+	}
+}
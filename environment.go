@@ -1,10 +1,40 @@
 package goat
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/goatx/goat/internal/handlerctx"
+)
 
 type environment struct {
 	machines map[string]AbstractStateMachine
 	queue    map[string][]AbstractEvent
+	// activeRegion is the region of the machine whose event is being
+	// handled; the empty string refers to the machine's main states.
+	activeRegion string
+	// vars holds the values of the shared variables declared with
+	// WithSharedVar.
+	vars map[string]any
+	// maxInstances bounds the number of machines Spawn may grow the
+	// environment to; zero means unbounded.
+	maxInstances int
+	// err is the first error a handler ran into, such as Spawn with an
+	// invalid spec or one reported through handlerctx. It stops model
+	// checking once the handler returns.
+	err error
+	// violations names the rules the handler reported through handlerctx
+	// that the world it leads to breaks. Solve moves them to the world, so
+	// they are no part of its state.
+	violations []ConditionName
+}
+
+// fail records err unless an earlier error was recorded.
+func (e *environment) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
 }
 
 type (
@@ -15,7 +45,23 @@ type (
 func withEnvAndSM(env *environment, sm AbstractStateMachine) context.Context {
 	ctx := context.WithValue(context.Background(), envKey{}, env)
 	ctx = context.WithValue(ctx, smKey{}, sm)
-	return ctx
+	return handlerctx.NewContext(ctx, handlerReporter{env: env})
+}
+
+// handlerReporter passes to the environment of a handler what the packages
+// building on goat report through handlerctx.
+type handlerReporter struct {
+	env *environment
+}
+
+func (r handlerReporter) Fail(err error) {
+	r.env.fail(err)
+}
+
+func (r handlerReporter) Violate(rule string) {
+	if name := ConditionName(rule); !slices.Contains(r.env.violations, name) {
+		r.env.violations = append(r.env.violations, name)
+	}
 }
 
 func getEnvFromContext(ctx context.Context) *environment {
@@ -34,6 +80,23 @@ func getSMFromContext(ctx context.Context) AbstractStateMachine {
 
 type localState struct {
 	env environment
+	// handlerState and handlerIndex identify the handler that produced the
+	// state: the details of the state it is registered for and its position
+	// among that state's handlers. handlerState is empty when no handler
+	// processed the event. handlerAlternative is the position of the handler
+	// among those the state has for the event.
+	handlerState       string
+	handlerIndex       int
+	handlerAlternative int
+}
+
+// handler returns the alternative of the handler that produced the state, or
+// -1 when no handler did.
+func (ls localState) handler() int {
+	if ls.handlerState == "" {
+		return -1
+	}
+	return ls.handlerAlternative
 }
 
 func (e *environment) clone() environment {
@@ -53,8 +116,11 @@ func (e *environment) clone() environment {
 	}
 
 	ec := environment{
-		machines: machines,
-		queue:    queue,
+		machines:     machines,
+		queue:        queue,
+		vars:         cloneSharedVars(e.vars),
+		activeRegion: e.activeRegion,
+		maxInstances: e.maxInstances,
 	}
 	return ec
 }
@@ -63,6 +129,20 @@ func (e *environment) enqueueEvent(target AbstractStateMachine, event AbstractEv
 	e.queue[target.id()] = append(e.queue[target.id()], event)
 }
 
+func (e *environment) enqueueRegionEvent(target AbstractStateMachine, region string, event AbstractEvent) {
+	qid := queueID(target.id(), region)
+	e.queue[qid] = append(e.queue[qid], event)
+}
+
+// queueID returns the queue key of the named region of a state machine.
+// The main states of a machine use the machine ID itself.
+func queueID(smID, region string) string {
+	if region == "" {
+		return smID
+	}
+	return smID + "#" + region
+}
+
 func (e *environment) dequeueEvent(smID string) (AbstractEvent, bool) {
 	events, ok := e.queue[smID]
 	if !ok {
@@ -80,6 +160,7 @@ func (e *environment) dequeueEvent(smID string) (AbstractEvent, bool) {
 // SendTo sends an event to a specific state machine.
 // This function must be called from within event handlers registered with
 // OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+// If the target has orthogonal regions, each region receives its own copy.
 //
 // Parameters:
 //   - ctx: Context passed to the event handler
@@ -92,6 +173,10 @@ func (e *environment) dequeueEvent(smID string) (AbstractEvent, bool) {
 //	    goat.SendTo(ctx, otherSM, Event{Name: "NOTIFY"})
 //	})
 func SendTo(ctx context.Context, target AbstractStateMachine, event AbstractEvent) {
+	send(ctx, target, event)
+}
+
+func send(ctx context.Context, target AbstractStateMachine, event AbstractEvent) {
 	env := getEnvFromContext(ctx)
 
 	if event != nil {
@@ -103,12 +188,21 @@ func SendTo(ctx context.Context, target AbstractStateMachine, event AbstractEven
 	}
 
 	env.enqueueEvent(target, event)
+	if event == nil {
+		return
+	}
+	for _, r := range getInnerStateMachine(target).regions {
+		env.enqueueRegionEvent(target, r.name, cloneEvent(event))
+	}
 }
 
 // Goto triggers a state transition for the current state machine.
 // This function must be called from within event handlers registered with
 // OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
 // It automatically generates the necessary sequence of events (Exit, Transition, Entry).
+// For nested states declared with DefineSubstates, the parent states that are
+// left or entered are exited innermost first and entered outermost first.
+// When called from a handler of an orthogonal region, the region transitions.
 //
 // Parameters:
 //   - ctx: Context passed to the event handler
@@ -122,9 +216,42 @@ func SendTo(ctx context.Context, target AbstractStateMachine, event AbstractEven
 func Goto(ctx context.Context, state AbstractState) {
 	env := getEnvFromContext(ctx)
 	sm := getSMFromContext(ctx)
-	env.enqueueEvent(sm, &exitEvent{})
-	env.enqueueEvent(sm, &transitionEvent{To: state})
-	env.enqueueEvent(sm, &entryEvent{})
+	region := env.activeRegion
+	// The ancestors to exit and enter are queued by expandTransition once
+	// the exit is processed, as earlier transitions may move the state
+	// machine before then.
+	env.enqueueRegionEvent(sm, region, &exitEvent{})
+	env.enqueueRegionEvent(sm, region, &transitionEvent{To: state})
+	env.enqueueRegionEvent(sm, region, &entryEvent{})
+}
+
+// expandTransition queues the exits and entries of the ancestor states left
+// and entered by the transition queued after an exit event of Goto, around
+// that transition. It is called when the exit event is dequeued, so that the
+// path starts from the state the region is in at that time.
+func (e *environment) expandTransition(sm *StateMachine, smID, region string) {
+	qid := queueID(smID, region)
+	events := e.queue[qid]
+	if len(events) == 0 {
+		return
+	}
+	transition, ok := events[0].(*transitionEvent)
+	if !ok {
+		return
+	}
+	exits, entries := sm.transitionPath(sm.stateIn(region), transition.To)
+	if len(exits) == 0 && len(entries) == 0 {
+		return
+	}
+	expanded := make([]AbstractEvent, 0, len(events)+len(exits)+len(entries))
+	for _, s := range exits {
+		expanded = append(expanded, &ancestorExitEvent{State: s})
+	}
+	expanded = append(expanded, transition)
+	for _, s := range entries {
+		expanded = append(expanded, &ancestorEntryEvent{State: s})
+	}
+	e.queue[qid] = append(expanded, events[1:]...)
 }
 
 // Halt stops the execution of a specific state machine permanently.
@@ -146,6 +273,52 @@ func Halt(ctx context.Context, target AbstractStateMachine) {
 	env.enqueueEvent(target, &haltEvent{})
 }
 
+// Spawn creates a new state machine instance from spec while the model is
+// being checked. This function must be called from within event handlers
+// registered with OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+// The instance gets a deterministic ID derived from its type name, starts in
+// the spec's initial state, and takes part in model checking like the
+// machines passed to WithStateMachines.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//   - spec: The specification to create the instance from
+//   - init: Optional function to initialize the instance's fields before it starts
+//
+// Returns the new instance and true, or the zero value and false when the
+// bound configured with WithMaxInstances has been reached or the spec is
+// invalid. An invalid spec also makes Test return the error NewInstance
+// reports for it.
+//
+// Example:
+//
+//	goat.OnEvent(spec, ServingState{}, func(ctx context.Context, event *Request, sm *Server) {
+//	    worker, ok := goat.Spawn(ctx, workerSpec, func(w *Worker) {
+//	        w.Server = sm
+//	    })
+//	    if ok {
+//	        goat.SendTo(ctx, worker, &Job{ID: event.ID})
+//	    }
+//	})
+func Spawn[T AbstractStateMachine](ctx context.Context, spec *StateMachineSpec[T], init func(T)) (T, bool) {
+	var zero T
+	env := getEnvFromContext(ctx)
+	if env.maxInstances > 0 && len(env.machines) >= env.maxInstances {
+		return zero, false
+	}
+
+	instance, err := spec.NewInstance()
+	if err != nil {
+		env.fail(fmt.Errorf("cannot spawn %s: %w", getStateMachineName(spec.prototype), err))
+		return zero, false
+	}
+	if init != nil {
+		init(instance)
+	}
+	env.addMachine(instance)
+	return instance, true
+}
+
 // NewHandlerContext creates a context with a minimal environment for executing handlers.
 // This is used when you need to execute handlers outside of the normal model checking flow.
 //
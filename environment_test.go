@@ -2,9 +2,12 @@ package goat
 
 import (
 	"context"
+	"errors"
+	"io"
 	"reflect"
 	"testing"
 
+	"github.com/goatx/goat/internal/handlerctx"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
@@ -34,7 +37,7 @@ func TestEnvironment_clone(t *testing.T) {
 			Event[*testStateMachine, *testStateMachine]{},
 			Event[AbstractStateMachine, AbstractStateMachine]{},
 		),
-		cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders"),
+		cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders", "definition"),
 	}
 	if diff := cmp.Diff(original, cloned, opts); diff != "" {
 		t.Errorf("environment mismatch (-original +cloned):\n%s", diff)
@@ -327,3 +330,174 @@ func TestHalt(t *testing.T) {
 		}
 	})
 }
+
+func TestSendTo_regions(t *testing.T) {
+	spec := NewStateMachineSpec(&testStateMachine{})
+	spec.DefineStates(newTestState("main")).
+		SetInitialState(newTestState("main")).
+		DefineRegion("r", newTestState("region")).
+		SetRegionInitialState("r", newTestState("region"))
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+	env := newTestEnvironment(sm)
+	ctx := withEnvAndSM(&env, sm)
+
+	event := &testEvent{Value: 7}
+	SendTo(ctx, sm, event)
+
+	mainQueue := env.queue[sm.id()]
+	regionQueue := env.queue[queueID(sm.id(), "r")]
+	if len(mainQueue) != 1 || len(regionQueue) != 1 {
+		t.Fatalf("expected one event in each queue, got main=%d region=%d", len(mainQueue), len(regionQueue))
+	}
+	if mainQueue[0] != event {
+		t.Errorf("main queue should hold the sent event")
+	}
+	copied, ok := regionQueue[0].(*testEvent)
+	if !ok || copied == event || copied.Value != 7 {
+		t.Errorf("region queue should hold a copy of the sent event, got %#v", regionQueue[0])
+	}
+	if copied.Sender() != sm {
+		t.Errorf("region copy should keep routing info")
+	}
+}
+
+func TestSpawn(t *testing.T) {
+	newSpec := func() *StateMachineSpec[*hierarchyTestStateMachine] {
+		spec := NewStateMachineSpec(&hierarchyTestStateMachine{})
+		spec.DefineStates(newTestState("initial")).SetInitialState(newTestState("initial"))
+		return spec
+	}
+
+	t.Run("registers instance with handlers and entry event", func(t *testing.T) {
+		spec := newSpec()
+		parent := newTestStateMachine(newTestState("parent"))
+		env := newTestEnvironment(parent)
+		ctx := withEnvAndSM(&env, parent)
+
+		first, ok := Spawn(ctx, spec, func(sm *hierarchyTestStateMachine) {
+			sm.Log = []string{"initialized"}
+		})
+		if !ok {
+			t.Fatal("Spawn() should succeed without a bound")
+		}
+		second, _ := Spawn(ctx, spec, nil)
+
+		if first.id() != "hierarchyTestStateMachine" || second.id() != "hierarchyTestStateMachine_1" {
+			t.Errorf("unexpected IDs %q and %q", first.id(), second.id())
+		}
+		if env.machines[first.id()] != first {
+			t.Error("spawned instance should be registered in the environment")
+		}
+		if diff := cmp.Diff([]string{"initialized"}, first.Log); diff != "" {
+			t.Errorf("init should be applied (-want +got):\n%s", diff)
+		}
+		if len(getInnerStateMachine(first).EventHandlers) == 0 {
+			t.Error("spawned instance should have its event handlers built")
+		}
+		queue := env.queue[first.id()]
+		if len(queue) != 1 {
+			t.Fatalf("expected 1 queued event, got %d", len(queue))
+		}
+		if _, ok := queue[0].(*entryEvent); !ok {
+			t.Errorf("queued event mismatch: expected *entryEvent, got %T", queue[0])
+		}
+	})
+
+	t.Run("respects max instances", func(t *testing.T) {
+		spec := newSpec()
+		parent := newTestStateMachine(newTestState("parent"))
+		env := newTestEnvironment(parent)
+		env.maxInstances = 2
+		ctx := withEnvAndSM(&env, parent)
+
+		if _, ok := Spawn(ctx, spec, nil); !ok {
+			t.Fatal("first Spawn() should succeed")
+		}
+		if sm, ok := Spawn(ctx, spec, nil); ok || sm != nil {
+			t.Errorf("Spawn() beyond the bound = (%v, %v), want (nil, false)", sm, ok)
+		}
+		if len(env.machines) != 2 {
+			t.Errorf("len(machines) = %d, want 2", len(env.machines))
+		}
+	})
+
+	t.Run("works outside Test", func(t *testing.T) {
+		ctx := NewHandlerContext(nil)
+
+		sm, ok := Spawn(ctx, newSpec(), nil)
+		if !ok {
+			t.Fatal("Spawn() should succeed in a handler context")
+		}
+		if env := getEnvFromContext(ctx); env.machines[sm.id()] != sm {
+			t.Error("spawned instance should be registered in the environment")
+		}
+	})
+
+	t.Run("reports an invalid spec", func(t *testing.T) {
+		invalid := NewStateMachineSpec(&hierarchyTestStateMachine{})
+		parentSpec := NewStateMachineSpec(&testStateMachine{})
+		idle := newTestState("idle")
+		parentSpec.DefineStates(idle).SetInitialState(idle)
+		OnEntry(parentSpec, idle, func(ctx context.Context, _ *testStateMachine) {
+			if sm, ok := Spawn(ctx, invalid, nil); ok || sm != nil {
+				t.Errorf("Spawn() of an invalid spec = (%v, %v), want (nil, false)", sm, ok)
+			}
+		})
+		parent, err := parentSpec.NewInstance()
+		if err != nil {
+			t.Fatalf("NewInstance() returned error: %v", err)
+		}
+
+		_, err = Test(WithStateMachines(parent), WithOutput(io.Discard))
+		want := "cannot spawn hierarchyTestStateMachine: state machine spec has no initial state"
+		if err == nil || err.Error() != want {
+			t.Errorf("Test() error = %v, want %q", err, want)
+		}
+	})
+}
+
+func TestHandlerReporter_fail(t *testing.T) {
+	spec := NewStateMachineSpec(&testStateMachine{})
+	idle := newTestState("idle")
+	spec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, _ *testStateMachine) {
+		handlerctx.Fail(ctx, errors.New("invalid call"))
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	if _, err := Test(WithStateMachines(sm), WithOutput(io.Discard)); err == nil || err.Error() != "invalid call" {
+		t.Errorf("Test() error = %v, want %q", err, "invalid call")
+	}
+}
+
+func TestHandlerReporter_violate(t *testing.T) {
+	spec := NewStateMachineSpec(&testStateMachine{})
+	idle := newTestState("idle")
+	spec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, _ *testStateMachine) {
+		handlerctx.Violate(ctx, "valid input")
+		handlerctx.Violate(ctx, "valid input")
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	result, err := Test(WithStateMachines(sm), WithOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	var violated []string
+	for _, v := range result.Violations {
+		violated = append(violated, v.Rule)
+	}
+	if diff := cmp.Diff([]string{"Always valid input"}, violated); diff != "" {
+		t.Errorf("violated rules mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -9,6 +9,8 @@ import (
 type AbstractEvent interface {
 	isEvent() bool
 	setRoutingInfo(AbstractStateMachine, AbstractStateMachine)
+	setGroup(string)
+	groupName() string
 }
 
 // Event is the base struct that should be embedded in all event implementations.
@@ -27,6 +29,7 @@ type Event[Sender AbstractStateMachine, Recipient AbstractStateMachine] struct {
 
 	sender    Sender
 	recipient Recipient
+	group     string
 }
 
 // UnTypedEvent is a convenience alias for an Event that does not specify
@@ -99,6 +102,22 @@ func (e *Event[Sender, Recipient]) setRoutingInfo(sender, recipient AbstractStat
 	}
 }
 
+func (e *Event[Sender, Recipient]) setGroup(name string) {
+	if e == nil {
+		return
+	}
+	e.group = name
+}
+
+// groupName returns the name of the group the event was broadcast to, or
+// the empty string if it was sent with SendTo.
+func (e *Event[Sender, Recipient]) groupName() string {
+	if e == nil {
+		return ""
+	}
+	return e.group
+}
+
 type entryEvent struct {
 	UnTypedEvent
 }
@@ -116,6 +135,20 @@ type haltEvent struct {
 	UnTypedEvent
 }
 
+// ancestorEntryEvent runs the entry handlers of State, an ancestor of the
+// current state that is entered on the way to a nested state.
+type ancestorEntryEvent struct {
+	UnTypedEvent
+	State AbstractState
+}
+
+// ancestorExitEvent runs the exit handlers of State, an ancestor of the
+// current state that is left on the way out of a nested state.
+type ancestorExitEvent struct {
+	UnTypedEvent
+	State AbstractState
+}
+
 func newEventPrototype[T AbstractEvent]() AbstractEvent {
 	var zero T
 	eventType := reflect.TypeOf(zero)
@@ -189,13 +222,7 @@ func getEventDetails(e AbstractEvent) string {
 		}
 
 		if fieldName != "Event" && fieldName != "UnTypedEvent" {
-			if field.CanInterface() {
-				fieldType := field.Type().String()
-				fieldValue := field.Interface()
-				fieldDetails = append(fieldDetails, fmt.Sprintf("{Name:%s,Type:%s,Value:%v}", fieldName, fieldType, fieldValue))
-			} else {
-				fieldDetails = append(fieldDetails, fmt.Sprintf("{Name:%s,Type:%s,Value:[UNACCESSIBLE]}", fieldName, field.Type().String()))
-			}
+			fieldDetails = append(fieldDetails, fieldDetail(fieldName, field))
 		}
 	}
 
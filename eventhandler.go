@@ -334,7 +334,7 @@ func (h *transitionHandlers) handle(env environment, smID string, event Abstract
 		ec := env.clone()
 		sm := ec.machines[smID]
 		f(event.(*transitionEvent).To, &ec)
-		sm.setCurrentState(event.(*transitionEvent).To)
+		getInnerStateMachine(sm).setStateIn(ec.activeRegion, event.(*transitionEvent).To)
 		lss = append(lss, localState{env: ec})
 	}
 	return lss, nil
@@ -369,7 +369,7 @@ func (*defaultOnTransitionHandler) handle(env environment, smID string, event Ab
 	}
 	ec := env.clone()
 	sm := ec.machines[smID]
-	sm.setCurrentState(event.(*transitionEvent).To)
+	getInnerStateMachine(sm).setStateIn(ec.activeRegion, event.(*transitionEvent).To)
 	return []localState{{env: ec}}, nil
 }
 
@@ -366,7 +366,7 @@ func TestTransitionHandlers_handle(t *testing.T) {
 					Event[*testStateMachine, *testStateMachine]{},
 					Event[AbstractStateMachine, AbstractStateMachine]{},
 				),
-				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders"),
+				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders", "definition"),
 			}
 			if diff := cmp.Diff(tt.wantStates, states, opts); diff != "" {
 				t.Errorf("States mismatch (-want +got):\n%s", diff)
@@ -468,7 +468,7 @@ func TestHaltHandlers_handle(t *testing.T) {
 					Event[*testStateMachine, *testStateMachine]{},
 					Event[AbstractStateMachine, AbstractStateMachine]{},
 				),
-				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders"),
+				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders", "definition"),
 			}
 			if diff := cmp.Diff(tt.wantStates, states, opts); diff != "" {
 				t.Errorf("States mismatch (-want +got):\n%s", diff)
@@ -567,7 +567,7 @@ func TestEntryHandlers_handle(t *testing.T) {
 					Event[*testStateMachine, *testStateMachine]{},
 					Event[AbstractStateMachine, AbstractStateMachine]{},
 				),
-				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders"),
+				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders", "definition"),
 			}
 			if diff := cmp.Diff(tt.wantStates, states, opts); diff != "" {
 				t.Errorf("States mismatch (-want +got):\n%s", diff)
@@ -662,7 +662,7 @@ func TestExitHandlers_handle(t *testing.T) {
 					Event[*testStateMachine, *testStateMachine]{},
 					Event[AbstractStateMachine, AbstractStateMachine]{},
 				),
-				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders"),
+				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders", "definition"),
 			}
 			if diff := cmp.Diff(tt.wantStates, states, opts); diff != "" {
 				t.Errorf("States mismatch (-want +got):\n%s", diff)
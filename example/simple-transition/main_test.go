@@ -74,6 +74,7 @@ func TestSimpleTransition(t *testing.T) {
 
 	cmpOpts := cmp.Options{
 		cmpopts.IgnoreFields(goat.Summary{}, "ExecutionTimeMs"),
+		cmpopts.IgnoreFields(goat.Violation{}, "Steps", "LoopSteps"),
 	}
 	if diff := cmp.Diff(expected, result, cmpOpts...); diff != "" {
 		t.Errorf("result mismatch (-want +got):\n%s", diff)
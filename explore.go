@@ -0,0 +1,310 @@
+package goat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Explore starts an interactive session for walking through the worlds of a
+// model one step at a time. The session shows the current world and the
+// steps enabled in it: which state machine can process which event, and
+// with which handler when several are registered. Commands read from the
+// input pick a step, undo it, jump to a world by its ID, and evaluate the
+// conditions of the rules. Type "help" for the list of commands.
+//
+// The session reads commands from os.Stdin and writes to os.Stdout, unless
+// WithInput or WithOutput is used, so it can also be driven by a script.
+// It ends with the "quit" command or at the end of the input.
+//
+// Parameters:
+//   - opts: Configuration options, as passed to Test
+//
+// Returns an error if the model cannot be built, a step fails, or reading or
+// writing fails.
+//
+// Example:
+//
+//	err := goat.Explore(goat.WithStateMachines(server, client), goat.WithRules(rules...))
+func Explore(opts ...Option) error {
+	model, err := newModel(opts...)
+	if err != nil {
+		return err
+	}
+
+	s := &exploreSession{
+		m:       &model,
+		in:      model.input,
+		out:     model.output,
+		history: []exploreEntry{{world: model.initial, how: "initial world"}},
+	}
+	if s.in == nil {
+		s.in = os.Stdin
+	}
+	if s.out == nil {
+		s.out = os.Stdout
+	}
+	return s.run()
+}
+
+// WithInput makes Explore read its commands from r instead of os.Stdin.
+//
+// Parameters:
+//   - r: Reader to read commands from, one per line
+//
+// Returns an Option that can be passed to Explore().
+//
+// Example:
+//
+//	goat.Explore(goat.WithStateMachines(sm), goat.WithInput(strings.NewReader("steps\n0\nquit\n")))
+func WithInput(r io.Reader) Option {
+	return optionFunc(func(o *options) {
+		o.input = r
+	})
+}
+
+type exploreSession struct {
+	m   *model
+	in  io.Reader
+	out io.Writer
+	// history holds the worlds visited, the current one last.
+	history []exploreEntry
+	// steps caches the steps enabled in the current world.
+	steps []enabledStep
+}
+
+type exploreEntry struct {
+	world world
+	how   string
+}
+
+// enabledStep is a step that can be taken from a world.
+type enabledStep struct {
+	step Step
+	next world
+}
+
+const exploreHelp = `Commands:
+  show             show the current world
+  steps            list the steps enabled in the current world
+  <n>, step <n>    take step n of the list
+  undo             go back to the previous world
+  goto <id>        jump to the world with the given ID
+  eval [name]      evaluate one condition, or all of them
+  path             list the steps taken so far
+  help             show this help
+  quit             end the session
+`
+
+func (s *exploreSession) run() error {
+	if err := s.show(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(s.in)
+	for {
+		s.printf("> ")
+		if !scanner.Scan() {
+			s.printf("\n")
+			return scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		command, args := fields[0], fields[1:]
+		switch command {
+		case "quit", "exit":
+			return nil
+		case "help":
+			s.printf("%s", exploreHelp)
+		case "show":
+			err = s.show()
+		case "steps":
+			err = s.listSteps()
+		case "step":
+			err = s.take(args)
+		case "undo":
+			err = s.undo()
+		case "goto":
+			err = s.jump(args)
+		case "eval":
+			s.eval(args)
+		case "path":
+			s.path()
+		default:
+			if _, convErr := strconv.Atoi(command); convErr == nil {
+				err = s.take(fields)
+			} else {
+				s.printf("unknown command %q, type help for the list of commands\n", command)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *exploreSession) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(s.out, format, args...)
+}
+
+func (s *exploreSession) current() world {
+	return s.history[len(s.history)-1].world
+}
+
+// moveTo makes w the current world and shows it.
+func (s *exploreSession) moveTo(w world, how string) error {
+	s.history = append(s.history, exploreEntry{world: w, how: how})
+	s.steps = nil
+	return s.show()
+}
+
+func (s *exploreSession) show() error {
+	w := s.current()
+	s.printf("World %d (depth %d):\n%s\n", w.id, len(s.history)-1, w.label())
+	return s.listSteps()
+}
+
+func (s *exploreSession) listSteps() error {
+	steps, err := s.enabledSteps()
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		s.printf("No steps are enabled: no state machine can move.\n")
+		return nil
+	}
+	s.printf("Enabled steps:\n")
+	for i, es := range steps {
+		s.printf("  [%d] %s -> world %d\n", i, enabledStepText(es.step, steps), es.next.id)
+	}
+	return nil
+}
+
+// enabledSteps returns the steps enabled in the current world, in the order
+// stepGlobal explores them, so that they lead to the worlds it finds.
+func (s *exploreSession) enabledSteps() ([]enabledStep, error) {
+	if s.steps != nil {
+		return s.steps, nil
+	}
+
+	env := s.current().env
+	gss, err := globalSteps(env)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]enabledStep, 0, len(gss))
+	for _, gs := range gss {
+		step := describeStep(env, gs.env, gs.smID, gs.region)
+		step.Handler = gs.handler()
+		steps = append(steps, enabledStep{step: step, next: newWorld(gs.env)})
+	}
+	s.steps = steps
+	return steps, nil
+}
+
+// enabledStepText describes a step of the list, naming its handler when
+// other steps let the same machine process the same event.
+func enabledStepText(step Step, steps []enabledStep) string {
+	text := describeStepText(step)
+	if step.StateAfter != step.StateBefore {
+		text += ", moving to " + regionLabel(step.Region, step.StateAfter)
+	}
+	for _, other := range steps {
+		if other.step.Machine == step.Machine && other.step.Region == step.Region && other.step.Handler != step.Handler {
+			return fmt.Sprintf("%s (handler %d)", text, step.Handler)
+		}
+	}
+	return text
+}
+
+func (s *exploreSession) take(args []string) error {
+	if len(args) != 1 {
+		s.printf("usage: step <n>\n")
+		return nil
+	}
+	steps, err := s.enabledSteps()
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 || n >= len(steps) {
+		s.printf("no step %s, there are %d enabled steps\n", args[0], len(steps))
+		return nil
+	}
+	return s.moveTo(steps[n].next, describeStepText(steps[n].step))
+}
+
+func (s *exploreSession) undo() error {
+	if len(s.history) == 1 {
+		s.printf("already at the initial world\n")
+		return nil
+	}
+	s.history = s.history[:len(s.history)-1]
+	s.steps = nil
+	return s.show()
+}
+
+func (s *exploreSession) jump(args []string) error {
+	if len(args) != 1 {
+		s.printf("usage: goto <id>\n")
+		return nil
+	}
+	wid, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		s.printf("invalid world ID %q\n", args[0])
+		return nil
+	}
+	// World IDs are only known once the model has been explored, which is
+	// done on the first jump.
+	if len(s.m.worlds) == 0 {
+		if err := s.m.Solve(); err != nil {
+			return err
+		}
+	}
+	w, ok := s.m.worlds[worldID(wid)]
+	if !ok {
+		s.printf("world %d is not reachable\n", wid)
+		return nil
+	}
+	return s.moveTo(w, fmt.Sprintf("jumped to world %d", wid))
+}
+
+func (s *exploreSession) eval(args []string) {
+	if len(s.m.conds) == 0 {
+		s.printf("no conditions are defined, pass them to WithRules\n")
+		return
+	}
+	conds := make(map[string]Condition, len(s.m.conds))
+	names := make([]string, 0, len(s.m.conds))
+	for name, cond := range s.m.conds {
+		conds[name.String()] = cond
+		names = append(names, name.String())
+	}
+	sort.Strings(names)
+	if len(args) > 0 {
+		names = []string{strings.Join(args, " ")}
+	}
+
+	for _, name := range names {
+		cond, ok := conds[name]
+		if !ok {
+			s.printf("no condition named %q\n", name)
+			continue
+		}
+		s.printf("%s: %t\n", name, cond.Evaluate(s.current()))
+	}
+}
+
+func (s *exploreSession) path() {
+	for i, entry := range s.history {
+		s.printf("  [%d] %s -> world %d\n", i, entry.how, entry.world.id)
+	}
+}
@@ -0,0 +1,174 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func runExploreScript(t *testing.T, script ...string) string {
+	t.Helper()
+	var calls []string
+	var buf bytes.Buffer
+	opts := append(newReplayTestOptions(t, &calls),
+		WithInput(strings.NewReader(strings.Join(script, "\n"))),
+		WithOutput(&buf),
+	)
+	if err := Explore(opts...); err != nil {
+		t.Fatalf("Explore() returned error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestExplore(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:   "initial world",
+			script: nil,
+			want: []string{
+				"World 2417515890736912310 (depth 0):\nStateMachines:\n",
+				"QueuedEvents:\nhierarchyTestStateMachine << entryEvent;\ntestStateMachine << entryEvent;\n",
+				"Enabled steps:\n" +
+					"  [0] hierarchyTestStateMachine processed entryEvent and sent testEvent(Value=1) to testStateMachine -> world 15671854091166684018\n" +
+					"  [1] testStateMachine processed entryEvent -> world 5820131086502100909\n",
+			},
+		},
+		{
+			name:   "steps with handler alternatives",
+			script: []string{"1", "step 0"},
+			want: []string{
+				"World 7783295032884369249 (depth 2):",
+				"  [0] testStateMachine processed testEvent(Value=1) (handler 0) -> world 12930999529488533785\n" +
+					"  [1] testStateMachine processed testEvent(Value=1) (handler 1) -> world 17327818806388591370\n",
+			},
+		},
+		{
+			name:   "undo and path",
+			script: []string{"1", "0", "undo", "path", "undo", "undo"},
+			want: []string{
+				"> World 5820131086502100909 (depth 1):",
+				"> " +
+					"  [0] initial world -> world 2417515890736912310\n" +
+					"  [1] testStateMachine processed entryEvent -> world 5820131086502100909\n",
+				"> already at the initial world\n",
+			},
+		},
+		{
+			name:   "eval",
+			script: []string{"eval", "eval not rejected", "eval missing"},
+			want: []string{
+				"> not rejected: true\n> not rejected: true\n",
+				"> no condition named \"missing\"\n",
+			},
+		},
+		{
+			name:   "invalid commands",
+			script: []string{"bogus", "7", "step", "goto x", "goto 1"},
+			want: []string{
+				"> unknown command \"bogus\", type help for the list of commands\n",
+				"> no step 7, there are 2 enabled steps\n",
+				"> usage: step <n>\n",
+				"> invalid world ID \"x\"\n",
+				"> world 1 is not reachable\n",
+			},
+		},
+		{
+			name:    "quit",
+			script:  []string{"quit", "help"},
+			want:    []string{"-> world 5820131086502100909\n> "},
+			notWant: []string{"Commands:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runExploreScript(t, tt.script...)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output does not contain %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("output contains %q:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestExplore_goto(t *testing.T) {
+	// Walk to the world where the receiver rejected the event, then jump
+	// there directly in a new session.
+	out := runExploreScript(t, "1", "0", "1", "0", "0")
+	ids := regexp.MustCompile(`World (\d+) \(depth 5\)`).FindStringSubmatch(out)
+	if ids == nil {
+		t.Fatalf("no world at depth 5 in output:\n%s", out)
+	}
+
+	out = runExploreScript(t, "goto "+ids[1], "eval", "path")
+	for _, want := range []string{
+		"> World " + ids[1] + " (depth 1):",
+		"> not rejected: false\n",
+		"  [1] jumped to world " + ids[1] + " -> world " + ids[1] + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExploreSession_enabledStepsMatchStepGlobal(t *testing.T) {
+	idle := newTestState("idle")
+	spec := NewStateMachineSpec(&testStateMachine{})
+	spec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, sm *testStateMachine) {
+		// The event is still queued once the machine halted.
+		Halt(ctx, sm)
+		SendTo(ctx, sm, &testEvent{Value: 1})
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+	m, err := newModel(WithStateMachines(sm), WithReporter(NewQuietReporter()))
+	if err != nil {
+		t.Fatalf("newModel() returned error: %v", err)
+	}
+	if err := m.Solve(); err != nil {
+		t.Fatalf("Solve() returned error: %v", err)
+	}
+
+	for _, w := range m.worlds {
+		nexts, err := stepGlobal(w)
+		if err != nil {
+			t.Fatalf("stepGlobal() returned error: %v", err)
+		}
+		var want []worldID
+		for _, next := range nexts {
+			want = append(want, next.id)
+		}
+
+		s := &exploreSession{m: &m, history: []exploreEntry{{world: w}}}
+		steps, err := s.enabledSteps()
+		if err != nil {
+			t.Fatalf("enabledSteps() returned error: %v", err)
+		}
+		var got []worldID
+		for _, es := range steps {
+			got = append(got, es.next.id)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("enabled steps of world %d mismatch (-stepGlobal +enabledSteps):\n%s", w.id, diff)
+		}
+	}
+}
@@ -0,0 +1,623 @@
+package goat
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// ResultSchemaVersion is the version of the document layout written by
+// Result.WriteJSON and recorded by Result.WriteJUnit and Result.WriteSARIF.
+// It changes only when a field is removed or changes meaning; adding fields
+// keeps the version.
+const ResultSchemaVersion = "1"
+
+type resultJSON struct {
+	SchemaVersion string              `json:"schema_version"`
+	Summary       resultSummaryJSON   `json:"summary"`
+	Violations    []violationJSON     `json:"violations"`
+	Coverage      *resultCoverageJSON `json:"coverage,omitempty"`
+}
+
+type resultSummaryJSON struct {
+	TotalWorlds     int   `json:"total_worlds"`
+	ExecutionTimeMs int64 `json:"execution_time_ms"`
+	Violations      int   `json:"violations"`
+}
+
+type violationJSON struct {
+	Rule string `json:"rule"`
+	// Kind is "invariant" for violations of Always and "temporal" for the
+	// others.
+	Kind      string              `json:"kind"`
+	Path      []worldSnapshotJSON `json:"path"`
+	Loop      []worldSnapshotJSON `json:"loop,omitempty"`
+	Steps     []stepJSON          `json:"steps"`
+	LoopSteps []stepJSON          `json:"loop_steps,omitempty"`
+}
+
+type worldSnapshotJSON struct {
+	StateMachines []stateMachineSnapshotJSON `json:"state_machines"`
+	QueuedEvents  []eventSnapshotJSON        `json:"queued_events"`
+	SharedVars    []sharedVarSnapshotJSON    `json:"shared_vars,omitempty"`
+}
+
+type stateMachineSnapshotJSON struct {
+	Name    string               `json:"name"`
+	State   string               `json:"state"`
+	Details string               `json:"details"`
+	Regions []regionSnapshotJSON `json:"regions,omitempty"`
+}
+
+type regionSnapshotJSON struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+type eventSnapshotJSON struct {
+	TargetMachine string `json:"target_machine"`
+	TargetRegion  string `json:"target_region,omitempty"`
+	EventName     string `json:"event_name"`
+	Details       string `json:"details"`
+	Group         string `json:"group,omitempty"`
+}
+
+type sharedVarSnapshotJSON struct {
+	Name    string `json:"name"`
+	Details string `json:"details"`
+}
+
+type stepJSON struct {
+	Machine      string          `json:"machine"`
+	Region       string          `json:"region,omitempty"`
+	EventName    string          `json:"event_name,omitempty"`
+	EventDetails string          `json:"event_details,omitempty"`
+	Handler      int             `json:"handler"`
+	StateBefore  string          `json:"state_before,omitempty"`
+	StateAfter   string          `json:"state_after,omitempty"`
+	Sent         []sentEventJSON `json:"sent,omitempty"`
+	Spawned      []string        `json:"spawned,omitempty"`
+}
+
+type sentEventJSON struct {
+	Target    string `json:"target"`
+	EventName string `json:"event_name"`
+	Details   string `json:"details"`
+	Group     string `json:"group,omitempty"`
+}
+
+type resultCoverageJSON struct {
+	Specs []specCoverageJSON `json:"specs"`
+}
+
+type specCoverageJSON struct {
+	Name            string                `json:"name"`
+	States          []stateCoverageJSON   `json:"states"`
+	Handlers        []handlerCoverageJSON `json:"handlers"`
+	UnhandledEvents []string              `json:"unhandled_events,omitempty"`
+}
+
+type stateCoverageJSON struct {
+	Region  string `json:"region,omitempty"`
+	State   string `json:"state"`
+	Reached bool   `json:"reached"`
+}
+
+type handlerCoverageJSON struct {
+	Region      string `json:"region,omitempty"`
+	State       string `json:"state"`
+	Handler     string `json:"handler"`
+	Alternative int    `json:"alternative"`
+	Fired       bool   `json:"fired"`
+}
+
+// WriteJSON writes the result as an indented JSON document. The document
+// records ResultSchemaVersion in its "schema_version" field and contains the
+// summary, every violation with its worlds and steps, and the coverage when
+// it was computed.
+//
+// Parameters:
+//   - w: Writer to output the JSON document to
+//
+// Returns an error if encoding or writing fails.
+//
+// Example:
+//
+//	result, _ := goat.Test(opts...)
+//	err := result.WriteJSON(os.Stdout)
+func (r *Result) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.toJSON())
+}
+
+func (r *Result) toJSON() resultJSON {
+	doc := resultJSON{
+		SchemaVersion: ResultSchemaVersion,
+		Summary: resultSummaryJSON{
+			TotalWorlds:     r.Summary.TotalWorlds,
+			ExecutionTimeMs: r.Summary.ExecutionTimeMs,
+			Violations:      len(r.Violations),
+		},
+		Violations: make([]violationJSON, 0, len(r.Violations)),
+	}
+	for _, v := range r.Violations {
+		doc.Violations = append(doc.Violations, violationJSON{
+			Rule:      v.Rule,
+			Kind:      violationKind(v),
+			Path:      worldSnapshotsToJSON(v.Path),
+			Loop:      worldSnapshotsToJSON(v.Loop),
+			Steps:     stepsToJSON(v.Steps),
+			LoopSteps: stepsToJSON(v.LoopSteps),
+		})
+	}
+	if r.Coverage != nil {
+		doc.Coverage = coverageToJSON(r.Coverage)
+	}
+	return doc
+}
+
+func violationKind(v Violation) string {
+	if v.Loop == nil {
+		return "invariant"
+	}
+	return "temporal"
+}
+
+func worldSnapshotsToJSON(snapshots []WorldSnapshot) []worldSnapshotJSON {
+	if snapshots == nil {
+		return nil
+	}
+	worlds := make([]worldSnapshotJSON, 0, len(snapshots))
+	for _, s := range snapshots {
+		world := worldSnapshotJSON{
+			StateMachines: make([]stateMachineSnapshotJSON, 0, len(s.StateMachines)),
+			QueuedEvents:  make([]eventSnapshotJSON, 0, len(s.QueuedEvents)),
+		}
+		for _, sm := range s.StateMachines {
+			smJSON := stateMachineSnapshotJSON{Name: sm.Name, State: sm.State, Details: sm.Details}
+			for _, r := range sm.Regions {
+				smJSON.Regions = append(smJSON.Regions, regionSnapshotJSON(r))
+			}
+			world.StateMachines = append(world.StateMachines, smJSON)
+		}
+		for _, e := range s.QueuedEvents {
+			world.QueuedEvents = append(world.QueuedEvents, eventSnapshotJSON(e))
+		}
+		for _, v := range s.SharedVars {
+			world.SharedVars = append(world.SharedVars, sharedVarSnapshotJSON(v))
+		}
+		worlds = append(worlds, world)
+	}
+	return worlds
+}
+
+func stepsToJSON(steps []Step) []stepJSON {
+	result := make([]stepJSON, 0, len(steps))
+	for _, s := range steps {
+		step := stepJSON{
+			Machine:      s.Machine,
+			Region:       s.Region,
+			EventName:    s.EventName,
+			EventDetails: s.EventDetails,
+			Handler:      s.Handler,
+			StateBefore:  s.StateBefore,
+			StateAfter:   s.StateAfter,
+			Spawned:      s.Spawned,
+		}
+		for _, e := range s.Sent {
+			step.Sent = append(step.Sent, sentEventJSON(e))
+		}
+		result = append(result, step)
+	}
+	return result
+}
+
+func coverageToJSON(c *Coverage) *resultCoverageJSON {
+	doc := &resultCoverageJSON{Specs: make([]specCoverageJSON, 0, len(c.Specs))}
+	for _, spec := range c.Specs {
+		sc := specCoverageJSON{
+			Name:            spec.Name,
+			States:          make([]stateCoverageJSON, 0, len(spec.States)),
+			Handlers:        make([]handlerCoverageJSON, 0, len(spec.Handlers)),
+			UnhandledEvents: spec.UnhandledEvents,
+		}
+		for _, s := range spec.States {
+			sc.States = append(sc.States, stateCoverageJSON(s))
+		}
+		for _, h := range spec.Handlers {
+			sc.Handlers = append(sc.Handlers, handlerCoverageJSON(h))
+		}
+		doc.Specs = append(doc.Specs, sc)
+	}
+	return doc
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// WriteJUnit writes the result as a JUnit XML report with one failing test
+// case per violation, whose body is the violation trace, and one failing test
+// case per handler that never fired when coverage was computed. A run
+// without failures is reported as a single passing test case. The suite
+// properties record ResultSchemaVersion and the number of explored worlds.
+//
+// Parameters:
+//   - w: Writer to output the XML report to
+//
+// Returns an error if encoding or writing fails.
+//
+// Example:
+//
+//	result, _ := goat.Test(opts...)
+//	err := result.WriteJUnit(file)
+func (r *Result) WriteJUnit(w io.Writer) error {
+	const className = "goat"
+	seconds := fmt.Sprintf("%.3f", float64(r.Summary.ExecutionTimeMs)/1000)
+
+	suite := junitTestSuite{
+		Name: className,
+		Time: seconds,
+		Properties: []junitProperty{
+			{Name: "schema_version", Value: ResultSchemaVersion},
+			{Name: "total_worlds", Value: fmt.Sprintf("%d", r.Summary.TotalWorlds)},
+		},
+	}
+	// CI tools merge test cases of the same name, so violations of rules
+	// sharing a title, such as unnamed invariants, are numbered.
+	titles := make(map[string]int)
+	for _, v := range r.Violations {
+		name := violationTitle(v)
+		if titles[name]++; titles[name] > 1 {
+			name = fmt.Sprintf("%s (%d)", name, titles[name])
+		}
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Name:      name,
+			ClassName: className,
+			Failure: &junitFailure{
+				Message: violationTitle(v),
+				Type:    violationKind(v),
+				Body:    violationText(v),
+			},
+		})
+	}
+	if r.Coverage != nil {
+		for _, spec := range r.Coverage.Specs {
+			for _, h := range spec.Handlers {
+				if h.Fired {
+					continue
+				}
+				message := fmt.Sprintf("%s: %s in %s never fired", spec.Name, h.label(), regionLabel(h.Region, h.State))
+				suite.Cases = append(suite.Cases, junitTestCase{
+					Name:      message,
+					ClassName: className,
+					Failure:   &junitFailure{Message: message, Type: "dead-handler"},
+				})
+			}
+		}
+	}
+	if len(suite.Cases) == 0 {
+		suite.Cases = append(suite.Cases, junitTestCase{Name: "model checking", ClassName: className})
+	}
+	for _, c := range suite.Cases {
+		if c.Failure != nil {
+			suite.Failures++
+		}
+	}
+	suite.Tests = len(suite.Cases)
+
+	doc := junitTestSuites{
+		Name:     className,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     seconds,
+		Suites:   []junitTestSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// violationTitle returns the rule a violation broke, or a generic title for
+// invariants without a name.
+func violationTitle(v Violation) string {
+	if strings.TrimSpace(v.Rule) == "" {
+		return "Condition failed"
+	}
+	return "Not " + v.Rule
+}
+
+// violationText renders a single violation the way Test prints it.
+func violationText(v Violation) string {
+	var sb strings.Builder
+	if v.Loop == nil {
+		writeInvariantViolations(&sb, []Violation{v})
+	} else {
+		writeTemporalViolations(&sb, []Violation{v})
+	}
+	return sb.String()
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool         `json:"tool"`
+	Results    []sarifResult     `json:"results"`
+	Properties map[string]string `json:"properties"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                `json:"ruleId"`
+	Level               string                `json:"level"`
+	Message             sarifMessage          `json:"message"`
+	PartialFingerprints map[string]string     `json:"partialFingerprints"`
+	Locations           []sarifResultLocation `json:"locations,omitempty"`
+	CodeFlows           []sarifCodeFlow       `json:"codeFlows,omitempty"`
+}
+
+type sarifCodeFlow struct {
+	ThreadFlows []sarifThreadFlow `json:"threadFlows"`
+}
+
+type sarifThreadFlow struct {
+	Locations []sarifThreadFlowLocation `json:"locations"`
+}
+
+type sarifThreadFlowLocation struct {
+	Location sarifLocation `json:"location"`
+}
+
+type sarifLocation struct {
+	Message sarifMessage `json:"message"`
+}
+
+type sarifResultLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFOption configures the log written by Result.WriteSARIF.
+type SARIFOption func(*sarifConfig)
+
+type sarifConfig struct {
+	artifactURI string
+}
+
+// WithArtifactURI sets the file, relative to the repository root, that the
+// results of a SARIF log are located in, typically the file defining the
+// specs. An empty uri writes the results without a location.
+func WithArtifactURI(uri string) SARIFOption {
+	return func(c *sarifConfig) {
+		c.artifactURI = uri
+	}
+}
+
+// WriteSARIF writes the result as a SARIF 2.1.0 log for code scanning
+// tools. Every violated rule becomes a SARIF rule, and every violation a
+// result whose code flow lists the steps of its trace. A result is located
+// in the file calling WriteSARIF, relative to the root of its module, unless
+// WithArtifactURI names another, and carries fingerprints derived from its
+// rule and from its trace so that tools can track it across runs. The run
+// properties record ResultSchemaVersion.
+//
+// Parameters:
+//   - w: Writer to output the SARIF log to
+//   - opts: Options such as WithArtifactURI
+//
+// Returns an error if encoding or writing fails.
+//
+// Example:
+//
+//	result, _ := goat.Test(opts...)
+//	err := result.WriteSARIF(file)
+func (r *Result) WriteSARIF(w io.Writer, opts ...SARIFOption) error {
+	config := sarifConfig{artifactURI: callerSource(1)}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "goat",
+			InformationURI: "https://github.com/goatx/goat",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+		Properties: map[string]string{
+			"schemaVersion": ResultSchemaVersion,
+			"totalWorlds":   fmt.Sprintf("%d", r.Summary.TotalWorlds),
+		},
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range r.Violations {
+		id := sarifRuleID(v)
+		if !seen[id] {
+			seen[id] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               id,
+				ShortDescription: sarifMessage{Text: violationTitle(v)},
+			})
+		}
+
+		var locations []sarifThreadFlowLocation
+		for _, step := range append(append([]Step{}, v.Steps...), v.LoopSteps...) {
+			locations = append(locations, sarifThreadFlowLocation{
+				Location: sarifLocation{Message: sarifMessage{Text: describeStepText(step)}},
+			})
+		}
+		result := sarifResult{
+			RuleID:  id,
+			Level:   "error",
+			Message: sarifMessage{Text: violationTitle(v)},
+			PartialFingerprints: map[string]string{
+				"goatRule/v1":  id,
+				"goatTrace/v1": sarifTraceFingerprint(v),
+			},
+		}
+		if config.artifactURI != "" {
+			result.Locations = []sarifResultLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: config.artifactURI}},
+			}}
+		}
+		if len(locations) > 0 {
+			result.CodeFlows = []sarifCodeFlow{{ThreadFlows: []sarifThreadFlow{{Locations: locations}}}}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	doc := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// sarifRuleID derives a stable identifier from the violated rule.
+func sarifRuleID(v Violation) string {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(violationKind(v) + ":" + v.Rule))
+	return fmt.Sprintf("goat/%s/%016x", violationKind(v), hasher.Sum64())
+}
+
+// sarifTraceFingerprint derives an identifier from the violated rule and the
+// steps of the trace reaching the violation, so that distinct
+// counterexamples of the same rule are told apart. Only the machines, events
+// and handlers of the steps are used, so that the fingerprint survives
+// changes to the fields of the machines and events.
+func sarifTraceFingerprint(v Violation) string {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(violationKind(v) + ":" + v.Rule))
+	for _, step := range v.Steps {
+		_, _ = fmt.Fprintf(hasher, "\n%s %s %d", step.Machine, step.EventName, step.Handler)
+	}
+	for _, step := range v.LoopSteps {
+		_, _ = fmt.Fprintf(hasher, "\nloop %s %s %d", step.Machine, step.EventName, step.Handler)
+	}
+	return fmt.Sprintf("%016x", hasher.Sum64())
+}
+
+// callerSource returns the file of the caller skip frames above it, relative
+// to the root of its module and with forward slashes, as code scanning tools
+// expect of artifact URIs. It returns the absolute path when no go.mod is
+// found above the file, and an empty string when the caller is unknown.
+func callerSource(skip int) string {
+	_, file, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	if !filepath.IsAbs(file) {
+		// Binaries built with -trimpath record files by the import path of
+		// their package, which needs no file system to be made relative.
+		mainPath := ""
+		if info, ok := debug.ReadBuildInfo(); ok {
+			mainPath = info.Main.Path
+		}
+		return trimmedSource(file, mainPath)
+	}
+	for dir := filepath.Dir(file); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			if rel, err := filepath.Rel(dir, file); err == nil {
+				return filepath.ToSlash(rel)
+			}
+			break
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+// trimmedSource returns a file recorded by a -trimpath build, such as
+// "example.com/mod/pkg/spec_test.go", relative to the root of the main
+// module mainPath, or as recorded when it belongs to another module.
+func trimmedSource(file, mainPath string) string {
+	file = filepath.ToSlash(file)
+	if mainPath == "" {
+		return file
+	}
+	if rel, ok := strings.CutPrefix(file, mainPath+"/"); ok {
+		return rel
+	}
+	return file
+}
@@ -0,0 +1,357 @@
+package goat
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newExportTestResult() *Result {
+	sm := StateMachineSnapshot{Name: "Server", State: "{Name:Name,Type:string,Value:idle}", Details: "no fields"}
+	return &Result{
+		Violations: []Violation{
+			{
+				Rule: "Always ready",
+				Path: []WorldSnapshot{
+					{StateMachines: []StateMachineSnapshot{sm}, QueuedEvents: []EventSnapshot{{TargetMachine: "Server", EventName: "entryEvent", Details: "no fields"}}},
+					{StateMachines: []StateMachineSnapshot{sm}, QueuedEvents: []EventSnapshot{}},
+				},
+				Steps: []Step{
+					{
+						Machine:      "Server",
+						EventName:    "entryEvent",
+						EventDetails: "no fields",
+						StateBefore:  sm.State,
+						StateAfter:   sm.State,
+						Sent:         []SentEvent{{Target: "Client", EventName: "eReady", Details: "no fields"}},
+					},
+				},
+			},
+		},
+		Summary: Summary{TotalWorlds: 3, ExecutionTimeMs: 1500},
+		Coverage: &Coverage{
+			Specs: []SpecCoverage{
+				{
+					Name:     "Server",
+					States:   []StateCoverage{{State: sm.State, Reached: true}},
+					Handlers: []HandlerCoverage{{State: sm.State, Handler: "OnExit"}},
+				},
+			},
+		},
+	}
+}
+
+func TestResult_WriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newExportTestResult().WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() returned error: %v", err)
+	}
+
+	want := `{
+  "schema_version": "1",
+  "summary": {
+    "total_worlds": 3,
+    "execution_time_ms": 1500,
+    "violations": 1
+  },
+  "violations": [
+    {
+      "rule": "Always ready",
+      "kind": "invariant",
+      "path": [
+        {
+          "state_machines": [
+            {
+              "name": "Server",
+              "state": "{Name:Name,Type:string,Value:idle}",
+              "details": "no fields"
+            }
+          ],
+          "queued_events": [
+            {
+              "target_machine": "Server",
+              "event_name": "entryEvent",
+              "details": "no fields"
+            }
+          ]
+        },
+        {
+          "state_machines": [
+            {
+              "name": "Server",
+              "state": "{Name:Name,Type:string,Value:idle}",
+              "details": "no fields"
+            }
+          ],
+          "queued_events": []
+        }
+      ],
+      "steps": [
+        {
+          "machine": "Server",
+          "event_name": "entryEvent",
+          "event_details": "no fields",
+          "handler": 0,
+          "state_before": "{Name:Name,Type:string,Value:idle}",
+          "state_after": "{Name:Name,Type:string,Value:idle}",
+          "sent": [
+            {
+              "target": "Client",
+              "event_name": "eReady",
+              "details": "no fields"
+            }
+          ]
+        }
+      ]
+    }
+  ],
+  "coverage": {
+    "specs": [
+      {
+        "name": "Server",
+        "states": [
+          {
+            "state": "{Name:Name,Type:string,Value:idle}",
+            "reached": true
+          }
+        ],
+        "handlers": [
+          {
+            "state": "{Name:Name,Type:string,Value:idle}",
+            "handler": "OnExit",
+            "alternative": 0,
+            "fired": false
+          }
+        ]
+      }
+    ]
+  }
+}
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("WriteJSON() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResult_WriteJUnit(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *Result
+		want   string
+	}{
+		{
+			name:   "violations and dead handlers",
+			result: newExportTestResult(),
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="goat" tests="2" failures="2" time="1.500">
+  <testsuite name="goat" tests="2" failures="2" time="1.500">
+    <properties>
+      <property name="schema_version" value="1"></property>
+      <property name="total_worlds" value="3"></property>
+    </properties>
+    <testcase name="Not Always ready" classname="goat">
+      <failure message="Not Always ready" type="invariant"><![CDATA[Condition failed. Not Always ready.
+Path (length = 2):
+  [0]
+  StateMachines:
+    Name: Server, Detail: no fields, State: {Name:Name,Type:string,Value:idle}
+  QueuedEvents:
+    StateMachine: Server, Event: entryEvent, Detail: no fields
+  [1] <-- violation here
+  StateMachines:
+    Name: Server, Detail: no fields, State: {Name:Name,Type:string,Value:idle}
+  QueuedEvents:
+]]></failure>
+    </testcase>
+    <testcase name="Server: OnExit in Name=idle never fired" classname="goat">
+      <failure message="Server: OnExit in Name=idle never fired" type="dead-handler"></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`,
+		},
+		{
+			name:   "no violations",
+			result: &Result{Summary: Summary{TotalWorlds: 2}},
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="goat" tests="1" failures="0" time="0.000">
+  <testsuite name="goat" tests="1" failures="0" time="0.000">
+    <properties>
+      <property name="schema_version" value="1"></property>
+      <property name="total_worlds" value="2"></property>
+    </properties>
+    <testcase name="model checking" classname="goat"></testcase>
+  </testsuite>
+</testsuites>
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.result.WriteJUnit(&buf); err != nil {
+				t.Fatalf("WriteJUnit() returned error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("WriteJUnit() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResult_WriteJUnit_uniqueNames(t *testing.T) {
+	result := &Result{Violations: []Violation{{}, {}, {Rule: "Always ready"}}}
+
+	var buf bytes.Buffer
+	if err := result.WriteJUnit(&buf); err != nil {
+		t.Fatalf("WriteJUnit() returned error: %v", err)
+	}
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v", err)
+	}
+	var names []string
+	for _, c := range doc.Suites[0].Cases {
+		names = append(names, c.Name)
+	}
+	want := []string{"Condition failed", "Condition failed (2)", "Not Always ready"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("test case names mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResult_WriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newExportTestResult().WriteSARIF(&buf); err != nil {
+		t.Fatalf("WriteSARIF() returned error: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF log: version %q with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Properties["schemaVersion"] != ResultSchemaVersion {
+		t.Errorf("schemaVersion = %q, want %q", run.Properties["schemaVersion"], ResultSchemaVersion)
+	}
+	if len(run.Tool.Driver.Rules) != 1 || len(run.Results) != 1 {
+		t.Fatalf("expected 1 rule and 1 result, got %d and %d", len(run.Tool.Driver.Rules), len(run.Results))
+	}
+	result := run.Results[0]
+	if result.RuleID != run.Tool.Driver.Rules[0].ID || result.Message.Text != "Not Always ready" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	wantFlow := []sarifThreadFlowLocation{
+		{Location: sarifLocation{Message: sarifMessage{Text: "Server processed entryEvent and sent eReady to Client"}}},
+	}
+	if len(result.CodeFlows) != 1 || len(result.CodeFlows[0].ThreadFlows) != 1 {
+		t.Fatalf("expected a single thread flow, got %+v", result.CodeFlows)
+	}
+	if diff := cmp.Diff(wantFlow, result.CodeFlows[0].ThreadFlows[0].Locations); diff != "" {
+		t.Errorf("thread flow mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResult_WriteSARIF_locationAndFingerprints(t *testing.T) {
+	result := newExportTestResult()
+	other := result.Violations[0]
+	other.Steps = []Step{{Machine: "Server", EventName: "eStop", EventDetails: "no fields"}}
+	result.Violations = append(result.Violations, other)
+
+	tests := []struct {
+		name string
+		opts []SARIFOption
+		want []sarifResultLocation
+	}{
+		{
+			name: "caller file",
+			want: []sarifResultLocation{
+				{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "export_test.go"}}},
+			},
+		},
+		{
+			name: "configured artifact URI",
+			opts: []SARIFOption{WithArtifactURI("spec/server_test.go")},
+			want: []sarifResultLocation{
+				{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "spec/server_test.go"}}},
+			},
+		},
+		{
+			name: "no location",
+			opts: []SARIFOption{WithArtifactURI("")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := result.WriteSARIF(&buf, tt.opts...); err != nil {
+				t.Fatalf("WriteSARIF() returned error: %v", err)
+			}
+			var log sarifLog
+			if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+				t.Fatalf("output is not valid JSON: %v", err)
+			}
+			results := log.Runs[0].Results
+			if len(results) != 2 {
+				t.Fatalf("expected 2 results, got %d", len(results))
+			}
+			for _, r := range results {
+				if diff := cmp.Diff(tt.want, r.Locations); diff != "" {
+					t.Errorf("locations mismatch (-want +got):\n%s", diff)
+				}
+			}
+			if results[0].PartialFingerprints["goatRule/v1"] != results[1].PartialFingerprints["goatRule/v1"] {
+				t.Errorf("rule fingerprints differ for the same rule: %v and %v", results[0].PartialFingerprints, results[1].PartialFingerprints)
+			}
+			if results[0].PartialFingerprints["goatTrace/v1"] == results[1].PartialFingerprints["goatTrace/v1"] {
+				t.Errorf("trace fingerprints are equal for different traces: %v", results[0].PartialFingerprints)
+			}
+		})
+	}
+}
+
+func TestSarifTraceFingerprint_ignoresDetails(t *testing.T) {
+	v := newExportTestResult().Violations[0]
+	changed := v
+	changed.Steps = append([]Step{}, v.Steps...)
+	changed.Steps[0].EventDetails = "{Name:Value,Type:int,Value:2}"
+	changed.Steps[0].StateAfter = "{Name:Name,Type:string,Value:busy}"
+	changed.Path = nil
+
+	if sarifTraceFingerprint(v) != sarifTraceFingerprint(changed) {
+		t.Error("trace fingerprint should not depend on the details of the trace")
+	}
+	changed.Steps[0].Handler++
+	if sarifTraceFingerprint(v) == sarifTraceFingerprint(changed) {
+		t.Error("trace fingerprint should depend on the handlers of the steps")
+	}
+}
+
+func TestTrimmedSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		mainPath string
+		want     string
+	}{
+		{name: "file of the main module", file: "example.com/mod/spec/server_test.go", mainPath: "example.com/mod", want: "spec/server_test.go"},
+		{name: "file of another module", file: "example.com/other/spec.go", mainPath: "example.com/mod", want: "example.com/other/spec.go"},
+		{name: "unknown main module", file: "example.com/mod/spec.go", want: "example.com/mod/spec.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimmedSource(tt.file, tt.mainPath); got != tt.want {
+				t.Errorf("trimmedSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
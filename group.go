@@ -0,0 +1,100 @@
+package goat
+
+import (
+	"context"
+	"strings"
+)
+
+// MachineGroup is a named set of state machines that events can be
+// multicast to. Create groups with Group and send to them with Multicast.
+//
+// A *MachineGroup field on a state machine is a reference, like a pointer
+// to another state machine, and is not part of the machine's state.
+type MachineGroup struct {
+	name    string
+	members []AbstractStateMachine
+}
+
+// Group declares a named group of state machines.
+//
+// Parameters:
+//   - name: The group name shown in traces for events multicast to the group
+//   - sms: The members of the group
+//
+// Returns a group that can be passed to Multicast.
+//
+// Example:
+//
+//	replicas := goat.Group("replicas", replica1, replica2, replica3)
+//	goat.OnEvent(spec, LeaderState{}, func(ctx context.Context, event *Write, sm *Leader) {
+//	    goat.Multicast(ctx, replicas, &Replicate{Value: event.Value})
+//	})
+func Group[T AbstractStateMachine](name string, sms ...T) *MachineGroup {
+	members := make([]AbstractStateMachine, len(sms))
+	for i, sm := range sms {
+		members[i] = sm
+	}
+	return &MachineGroup{name: name, members: members}
+}
+
+// Name returns the name of the group.
+func (g *MachineGroup) Name() string {
+	return g.name
+}
+
+// Members returns the state machines in the group.
+func (g *MachineGroup) Members() []AbstractStateMachine {
+	return append([]AbstractStateMachine{}, g.members...)
+}
+
+// Broadcast sends a copy of an event to each of the given state machines.
+// This function must be called from within event handlers registered with
+// OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+// All copies are queued in the same step, and each copy records the
+// recipients so traces show the event as a single broadcast.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//   - targets: The state machines that should receive the event
+//   - event: The event to send; each recipient receives its own copy
+//
+// Example:
+//
+//	goat.OnEntry(spec, ReadyState{}, func(ctx context.Context, sm *Node) {
+//	    goat.Broadcast(ctx, sm.Peers, &Gossip{Version: sm.Version})
+//	})
+func Broadcast[T AbstractStateMachine](ctx context.Context, targets []T, event AbstractEvent) {
+	members := make([]AbstractStateMachine, len(targets))
+	ids := make([]string, len(targets))
+	for i, target := range targets {
+		members[i] = target
+		ids[i] = target.id()
+	}
+	sendAll(ctx, "["+strings.Join(ids, ",")+"]", members, event)
+}
+
+// Multicast sends a copy of an event to each member of a group.
+// This function must be called from within event handlers registered with
+// OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+// All copies are queued in the same step, and each copy records the group
+// name so traces show the event as a single multicast.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//   - group: The group created with Group whose members should receive the event
+//   - event: The event to send; each member receives its own copy
+//
+// Example:
+//
+//	goat.Multicast(ctx, replicas, &Replicate{Value: 1})
+func Multicast(ctx context.Context, group *MachineGroup, event AbstractEvent) {
+	sendAll(ctx, group.name, group.members, event)
+}
+
+func sendAll(ctx context.Context, name string, targets []AbstractStateMachine, event AbstractEvent) {
+	for _, target := range targets {
+		evc := cloneEvent(event)
+		evc.setGroup(name)
+		send(ctx, target, evc)
+	}
+}
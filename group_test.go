@@ -0,0 +1,102 @@
+package goat
+
+import "testing"
+
+func TestGroup(t *testing.T) {
+	sm1 := newTestStateMachine(newTestState("a"))
+	sm2 := newTestStateMachine(newTestState("b"))
+
+	group := Group("replicas", sm1, sm2)
+
+	if group.Name() != "replicas" {
+		t.Errorf("Name() = %q, want %q", group.Name(), "replicas")
+	}
+	members := group.Members()
+	if len(members) != 2 || members[0] != sm1 || members[1] != sm2 {
+		t.Errorf("Members() = %v, want [sm1 sm2]", members)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(t *testing.T, sender *testStateMachine, targets []*testStateMachine, event AbstractEvent)
+	}{
+		{
+			name: "broadcast to a slice of machines",
+			send: func(t *testing.T, sender *testStateMachine, targets []*testStateMachine, event AbstractEvent) {
+				env := newTestEnvironment(append([]*testStateMachine{sender}, targets...)...)
+				Broadcast(withEnvAndSM(&env, sender), targets, event)
+				assertBroadcastQueues(t, env, sender, targets, event, "[r1,r2]")
+			},
+		},
+		{
+			name: "multicast to a named group",
+			send: func(t *testing.T, sender *testStateMachine, targets []*testStateMachine, event AbstractEvent) {
+				env := newTestEnvironment(append([]*testStateMachine{sender}, targets...)...)
+				Multicast(withEnvAndSM(&env, sender), Group("replicas", targets...), event)
+				assertBroadcastQueues(t, env, sender, targets, event, "replicas")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := newTestStateMachine(newTestState("sender"))
+			getInnerStateMachine(sender).smID = "sender"
+			r1 := newTestStateMachine(newTestState("r1"))
+			getInnerStateMachine(r1).smID = "r1"
+			r2 := newTestStateMachine(newTestState("r2"))
+			getInnerStateMachine(r2).smID = "r2"
+
+			tt.send(t, sender, []*testStateMachine{r1, r2}, &testEvent{Value: 5})
+		})
+	}
+}
+
+func assertBroadcastQueues(t *testing.T, env environment, sender *testStateMachine, targets []*testStateMachine, event AbstractEvent, wantGroup string) {
+	t.Helper()
+	seen := make(map[AbstractEvent]bool)
+	for _, target := range targets {
+		queue := env.queue[target.id()]
+		if len(queue) != 1 {
+			t.Fatalf("expected 1 event for %s, got %d", target.id(), len(queue))
+		}
+		ev, ok := queue[0].(*testEvent)
+		if !ok {
+			t.Fatalf("queued event mismatch: expected *testEvent, got %T", queue[0])
+		}
+		if ev == event || seen[ev] {
+			t.Errorf("each recipient should receive its own copy of the event")
+		}
+		seen[ev] = true
+		if ev.Value != 5 {
+			t.Errorf("copied event value = %d, want 5", ev.Value)
+		}
+		if ev.Sender() != sender || ev.Recipient() != target {
+			t.Errorf("routing info of copy for %s is not set", target.id())
+		}
+		if ev.groupName() != wantGroup {
+			t.Errorf("groupName() = %q, want %q", ev.groupName(), wantGroup)
+		}
+	}
+	if len(env.queue[sender.id()]) != 0 {
+		t.Errorf("sender should not receive the event")
+	}
+}
+
+func TestBroadcast_worldIdentity(t *testing.T) {
+	sender := newTestStateMachine(newTestState("sender"))
+	getInnerStateMachine(sender).smID = "sender"
+	target := newTestStateMachine(newTestState("r1"))
+	getInnerStateMachine(target).smID = "r1"
+
+	sent := newTestEnvironment(sender, target)
+	SendTo(withEnvAndSM(&sent, sender), target, &testEvent{Value: 5})
+	broadcast := newTestEnvironment(sender, target)
+	Multicast(withEnvAndSM(&broadcast, sender), Group("replicas", target), &testEvent{Value: 5})
+
+	if id(sent) == id(broadcast) {
+		t.Error("a broadcast and a SendTo of the same event should be different worlds")
+	}
+}
@@ -22,4 +22,8 @@ type TestCase struct {
 	Input      map[string]any
 	OutputType string
 	Output     map[string]any
+	// ErrorCode is the name of the gRPC status code the call is expected to
+	// fail with, such as "NotFound", or "" when it is expected to succeed.
+	ErrorCode    string
+	ErrorMessage string
 }
@@ -0,0 +1,68 @@
+// Package enums holds the values of the Go types registered as enums, shared
+// by the generators so that a type is registered once for all of them.
+package enums
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// Type is the set of Go types that can be registered as enums.
+type Type interface {
+	~string | ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// Value is a value of a registered type.
+type Value struct {
+	// Literal is the value as written in Go: the string of a string type, or
+	// the decimal number of an integer type.
+	Literal string
+	// Name is what the String method of the value returns, or Literal when
+	// the type has none.
+	Name string
+}
+
+var (
+	mu         sync.Mutex
+	registered = make(map[reflect.Type][]Value)
+)
+
+// Register records the values of E in the order given, replacing those of
+// an earlier registration.
+func Register[E Type](values ...E) {
+	enumValues := make([]Value, 0, len(values))
+	for _, v := range values {
+		literal := literal(reflect.ValueOf(v))
+		name := literal
+		if s, ok := any(v).(fmt.Stringer); ok {
+			name = s.String()
+		}
+		enumValues = append(enumValues, Value{Literal: literal, Name: name})
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	registered[reflect.TypeFor[E]()] = enumValues
+}
+
+// Lookup returns the values registered for t, or nil.
+func Lookup(t reflect.Type) []Value {
+	mu.Lock()
+	defer mu.Unlock()
+	return registered[t]
+}
+
+// literal returns a value as written in Go, without calling the String
+// method integer enums often have.
+func literal(v reflect.Value) string {
+	switch {
+	case v.Kind() == reflect.String:
+		return v.String()
+	case v.CanInt():
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+}
@@ -0,0 +1,71 @@
+package enums
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type testColor string
+
+type testLevel uint8
+
+type testPriority int32
+
+func (p testPriority) String() string {
+	return [...]string{"", "low", "high"}[p]
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func()
+		goType   reflect.Type
+		want     []Value
+	}{
+		{
+			name:     "string values keep their order",
+			register: func() { Register[testColor]("red", "", "LightBlue") },
+			goType:   reflect.TypeFor[testColor](),
+			want: []Value{
+				{Literal: "red", Name: "red"},
+				{Literal: "", Name: ""},
+				{Literal: "LightBlue", Name: "LightBlue"},
+			},
+		},
+		{
+			name:     "unsigned values are written as numbers",
+			register: func() { Register[testLevel](5, 0) },
+			goType:   reflect.TypeFor[testLevel](),
+			want: []Value{
+				{Literal: "5", Name: "5"},
+				{Literal: "0", Name: "0"},
+			},
+		},
+		{
+			name:     "integer values are named with String",
+			register: func() { Register[testPriority](2, 1) },
+			goType:   reflect.TypeFor[testPriority](),
+			want: []Value{
+				{Literal: "2", Name: "high"},
+				{Literal: "1", Name: "low"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.register()
+			if diff := cmp.Diff(tt.want, Lookup(tt.goType)); diff != "" {
+				t.Errorf("Lookup() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLookup_unregistered(t *testing.T) {
+	if got := Lookup(reflect.TypeFor[string]()); got != nil {
+		t.Errorf("Lookup() = %v, want nil", got)
+	}
+}
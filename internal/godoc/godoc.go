@@ -0,0 +1,223 @@
+// Package godoc looks up the doc comments of struct types and their fields
+// in the Go source of their packages.
+package godoc
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type typeDoc struct {
+	doc    string
+	fields map[string]string
+}
+
+// Docs looks up doc comments in the source of the packages it finds from
+// those registered with RegisterCaller. The source of a package is only
+// found and parsed when a doc comment of one of its types is asked for. The
+// zero value is ready to use.
+type Docs struct {
+	mu sync.Mutex
+	// dirs maps package paths to their source directory, or to "" when go
+	// list could not find it.
+	dirs map[string]string
+	// listDir is the directory go list runs in: that of the last package
+	// registered, so that the packages of its module can be found.
+	listDir string
+	// parsed caches the doc comments of the types declared in a directory.
+	parsed map[string]map[string]*typeDoc
+}
+
+// RegisterCaller records the source directory of the package of a function
+// on the call stack. The doc comments of the types of other packages are
+// looked up in the directories go list finds for them from there, which
+// must be in the module using those packages. skip is the number of frames
+// to ascend from the caller of RegisterCaller, as in runtime.Caller.
+func (d *Docs) RegisterCaller(skip int) {
+	pc, file, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return
+	}
+	pkgPath := packagePath(fn.Name())
+	if pkgPath == "" {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dirs == nil {
+		d.dirs = make(map[string]string)
+	}
+	d.dirs[pkgPath] = filepath.Dir(file)
+	d.listDir = filepath.Dir(file)
+}
+
+// Type returns the doc comment of a named type, or "" when t is nil, has
+// no doc comment or the source of its package cannot be found.
+func (d *Docs) Type(t reflect.Type) string {
+	if doc := d.lookup(t); doc != nil {
+		return doc.doc
+	}
+	return ""
+}
+
+// Field returns the doc comment of a field of a named struct type, or its
+// line comment when it has no doc comment.
+func (d *Docs) Field(t reflect.Type, name string) string {
+	if doc := d.lookup(t); doc != nil {
+		return doc.fields[name]
+	}
+	return ""
+}
+
+func (d *Docs) lookup(t reflect.Type) *typeDoc {
+	if t == nil || t.Name() == "" {
+		return nil
+	}
+	// Generic types are declared without their type arguments.
+	name, _, _ := strings.Cut(t.Name(), "[")
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dirs == nil {
+		d.dirs = make(map[string]string)
+	}
+	dir, ok := d.dirs[t.PkgPath()]
+	if !ok {
+		dir = packageDir(d.listDir, t.PkgPath())
+		d.dirs[t.PkgPath()] = dir
+	}
+	if dir == "" {
+		return nil
+	}
+	if d.parsed == nil {
+		d.parsed = make(map[string]map[string]*typeDoc)
+	}
+	docs, ok := d.parsed[dir]
+	if !ok {
+		docs = parseDir(dir)
+		d.parsed[dir] = docs
+	}
+	return docs[name]
+}
+
+// packagePath returns the package path of a function name reported by
+// runtime.FuncForPC, such as "example.com/pkg.(*T).Method".
+func packagePath(funcName string) string {
+	slash := strings.LastIndex(funcName, "/")
+	dot := strings.Index(funcName[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return funcName[:slash+1+dot]
+}
+
+// packageDir returns the source directory of a package as go list finds it
+// from listDir, or "" when no package was registered yet or go list fails.
+// go list is run rather than go/packages, which runs it as well, so that
+// the module needs no dependency for it.
+func packageDir(listDir, pkgPath string) string {
+	if listDir == "" || pkgPath == "" {
+		return ""
+	}
+	// #nosec G204 - the package path comes from reflect
+	cmd := exec.Command("go", "list", "-f", "{{.Dir}}", pkgPath)
+	cmd.Dir = listDir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// parseDir returns the doc comments of the struct types declared in the Go
+// files of dir, including test files. Files that do not parse are skipped.
+func parseDir(dir string) map[string]*typeDoc {
+	docs := make(map[string]*typeDoc)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return docs
+	}
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				td := &typeDoc{doc: text(doc), fields: make(map[string]string)}
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					for _, f := range st.Fields.List {
+						comment := text(f.Doc)
+						if comment == "" {
+							comment = text(f.Comment)
+						}
+						for _, name := range fieldNames(f) {
+							td.fields[name] = comment
+						}
+					}
+				}
+				docs[ts.Name.Name] = td
+			}
+		}
+	}
+	return docs
+}
+
+// fieldNames returns the names of a struct field declaration, which is the
+// type name for an embedded field.
+func fieldNames(f *ast.Field) []string {
+	if len(f.Names) > 0 {
+		names := make([]string, len(f.Names))
+		for i, name := range f.Names {
+			names[i] = name.Name
+		}
+		return names
+	}
+	expr := f.Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			return []string{e.Sel.Name}
+		case *ast.Ident:
+			return []string{e.Name}
+		default:
+			return nil
+		}
+	}
+}
+
+func text(group *ast.CommentGroup) string {
+	return strings.TrimSpace(group.Text())
+}
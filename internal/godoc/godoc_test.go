@@ -0,0 +1,103 @@
+package godoc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/goatx/goat/internal/godoc/godoctest"
+)
+
+// documented is a type with a doc comment.
+//
+// It has two paragraphs.
+type documented struct {
+	// Name is documented above.
+	Name string
+	Age  int // Age is documented at the end of the line.
+	embedded
+	Plain bool
+}
+
+type embedded struct{}
+
+// generic is a generic type.
+type generic[T any] struct {
+	// Value holds the T.
+	Value T
+}
+
+type (
+	// grouped is declared in a group.
+	grouped struct{}
+)
+
+func TestDocs(t *testing.T) {
+	var docs Docs
+	docs.RegisterCaller(0)
+
+	tests := []struct {
+		name  string
+		typ   reflect.Type
+		field string
+		want  string
+	}{
+		{name: "type", typ: reflect.TypeFor[documented](), want: "documented is a type with a doc comment.\n\nIt has two paragraphs."},
+		{name: "doc comment", typ: reflect.TypeFor[documented](), field: "Name", want: "Name is documented above."},
+		{name: "line comment", typ: reflect.TypeFor[documented](), field: "Age", want: "Age is documented at the end of the line."},
+		{name: "embedded", typ: reflect.TypeFor[documented](), field: "embedded", want: ""},
+		{name: "undocumented field", typ: reflect.TypeFor[documented](), field: "Plain", want: ""},
+		{name: "undocumented type", typ: reflect.TypeFor[embedded](), want: ""},
+		{name: "generic type", typ: reflect.TypeFor[generic[int]](), want: "generic is a generic type."},
+		{name: "generic field", typ: reflect.TypeFor[generic[string]](), field: "Value", want: "Value holds the T."},
+		{name: "grouped type", typ: reflect.TypeFor[grouped](), want: "grouped is declared in a group."},
+		{name: "type of another package", typ: reflect.TypeFor[godoctest.Message](), want: "Message is declared in another package."},
+		{name: "field of another package", typ: reflect.TypeFor[godoctest.Message](), field: "ID", want: "ID is documented above."},
+		{name: "unnamed type", typ: reflect.TypeFor[struct{ A int }](), field: "A", want: ""},
+		{name: "nil type", typ: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := docs.Type(tt.typ)
+			if tt.field != "" {
+				got = docs.Field(tt.typ, tt.field)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		funcName string
+		want     string
+	}{
+		{"Function", "github.com/goatx/goat/protobuf.OnMessage", "github.com/goatx/goat/protobuf"},
+		{"Method", "example.com/pkg.(*Service).Register", "example.com/pkg"},
+		{"Closure", "example.com/pkg.setup.func1", "example.com/pkg"},
+		{"Main", "main.createSpec", "main"},
+		{"Invalid", "nodot", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := packagePath(tt.funcName); got != tt.want {
+				t.Fatalf("packagePath(%q) = %q, want %q", tt.funcName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocs_unregistered(t *testing.T) {
+	var docs Docs
+	if got := docs.Type(reflect.TypeFor[godoctest.Message]()); got != "" {
+		t.Errorf("Type() = %q without a registered package, want \"\"", got)
+	}
+}
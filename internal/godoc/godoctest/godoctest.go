@@ -0,0 +1,9 @@
+// Package godoctest declares documented types for the tests of package
+// godoc, in a package other than the one registering itself.
+package godoctest
+
+// Message is declared in another package.
+type Message struct {
+	// ID is documented above.
+	ID string
+}
@@ -0,0 +1,39 @@
+// Package handlerctx lets the packages building on goat report to the model
+// checker from a handler, through its context, without adding to the API of
+// goat.
+package handlerctx
+
+import "context"
+
+// Reporter receives what handlers report. goat puts one in the context of
+// every handler.
+type Reporter interface {
+	// Fail stops model checking with err once the handler returns.
+	Fail(err error)
+	// Violate reports that the world the handler leads to breaks the rule
+	// named rule.
+	Violate(rule string)
+}
+
+type key struct{}
+
+// NewContext returns a copy of ctx carrying r.
+func NewContext(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, key{}, r)
+}
+
+// Fail reports err to the Reporter of ctx, and does nothing when ctx has
+// none.
+func Fail(ctx context.Context, err error) {
+	if r, ok := ctx.Value(key{}).(Reporter); ok {
+		r.Fail(err)
+	}
+}
+
+// Violate reports to the Reporter of ctx that the handler breaks the rule
+// named rule, and does nothing when ctx has none.
+func Violate(ctx context.Context, rule string) {
+	if r, ok := ctx.Value(key{}).(Reporter); ok {
+		r.Violate(rule)
+	}
+}
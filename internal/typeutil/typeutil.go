@@ -1,6 +1,7 @@
 package typeutil
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -32,3 +33,20 @@ func NameOf[T any]() string {
 	}
 	return t.Name()
 }
+
+// NameConflict returns the error of two distinct types a and b given the
+// same name in a generated file, which can only define one of them.
+func NameConflict(name string, a, b reflect.Type) error {
+	x, y := typeString(a), typeString(b)
+	if x > y {
+		x, y = y, x
+	}
+	return fmt.Errorf("%s and %s are both named %s", x, y, name)
+}
+
+func typeString(t reflect.Type) string {
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
@@ -0,0 +1,364 @@
+// Package validate parses the constraints declared on struct fields with the
+// validate tag, checks values against them, and reports the messages that
+// break them as a violation of the model.
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/goatx/goat/internal/handlerctx"
+)
+
+// Constraints are the constraints of a field, declared with a tag such as
+// `validate:"required,minlen=1,maxlen=20,pattern=^[a-z]+$"`:
+//   - required: the value is not zero, nil or empty
+//   - min=N, max=N: the inclusive bounds of a number
+//   - minlen=N, maxlen=N: the inclusive bounds of the length of a string,
+//     in characters, of a []byte, in bytes, or of a list or map, in items
+//   - pattern=RE: a regular expression strings must match; it comes last,
+//     as it may contain commas
+//   - in=a|b|c: the values a string or integer may take
+//
+// The constraints other than required, minlen and maxlen apply to each item
+// of a list.
+type Constraints struct {
+	Required bool
+	Min      *float64
+	Max      *float64
+	MinLen   *int
+	MaxLen   *int
+	Pattern  string
+	In       []string
+
+	pattern *regexp.Regexp
+}
+
+// Field returns the constraints of a struct field, or nil when it has no
+// validate tag. It returns an error when the tag is malformed or declares a
+// constraint the type of the field cannot have.
+func Field(f reflect.StructField) (*Constraints, error) {
+	tag, ok := f.Tag.Lookup("validate")
+	if !ok {
+		return nil, nil
+	}
+	return parse(tag, f.Type)
+}
+
+// constraintKeys are the constraints that take a value.
+var constraintKeys = []string{"min", "max", "minlen", "maxlen", "pattern", "in"}
+
+func parse(tag string, t reflect.Type) (*Constraints, error) {
+	c := &Constraints{}
+	t = deref(t)
+	item := t
+	if isList(t) {
+		item = deref(t.Elem())
+	}
+
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "pattern=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+		key, value, hasValue := strings.Cut(strings.TrimSpace(part), "=")
+		if key == "required" && !hasValue {
+			c.Required = true
+			continue
+		}
+		if !slices.Contains(constraintKeys, key) {
+			return nil, fmt.Errorf("unknown constraint %q", key)
+		}
+		if !hasValue || value == "" {
+			return nil, fmt.Errorf("%q needs a value", key)
+		}
+
+		switch key {
+		case "min", "max":
+			if !isNumber(item) {
+				return nil, fmt.Errorf("%s needs a number, not %s", key, item)
+			}
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s=%s: %w", key, value, err)
+			}
+			if isInteger(item) && bound != float64(int64(bound)) {
+				return nil, fmt.Errorf("%s=%s: %s needs an integer", key, value, item)
+			}
+			if key == "min" {
+				c.Min = &bound
+			} else {
+				c.Max = &bound
+			}
+		case "minlen", "maxlen":
+			if t.Kind() != reflect.String && !isBytes(t) && !isList(t) && t.Kind() != reflect.Map {
+				return nil, fmt.Errorf("%s needs a string, bytes, list or map, not %s", key, t)
+			}
+			length, err := strconv.Atoi(value)
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("%s=%s: not a length", key, value)
+			}
+			if key == "minlen" {
+				c.MinLen = &length
+			} else {
+				c.MaxLen = &length
+			}
+		case "pattern":
+			if item.Kind() != reflect.String {
+				return nil, fmt.Errorf("pattern needs a string, not %s", item)
+			}
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("pattern=%s: %w", value, err)
+			}
+			c.Pattern, c.pattern = value, re
+		case "in":
+			if item.Kind() != reflect.String && !isInteger(item) {
+				return nil, fmt.Errorf("in needs a string or integer, not %s", item)
+			}
+			c.In = strings.Split(value, "|")
+			if isInteger(item) {
+				for _, v := range c.In {
+					if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+						return nil, fmt.Errorf("in=%s: %s is not an integer", value, v)
+					}
+				}
+			}
+		}
+	}
+
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		return nil, fmt.Errorf("min %v is greater than max %v", *c.Min, *c.Max)
+	}
+	if c.MinLen != nil && c.MaxLen != nil && *c.MinLen > *c.MaxLen {
+		return nil, fmt.Errorf("minlen %d is greater than maxlen %d", *c.MinLen, *c.MaxLen)
+	}
+	return c, nil
+}
+
+// Check returns an error describing how v breaks the constraints, or nil.
+func (c *Constraints) Check(v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if c.Required {
+				return errors.New("is required")
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+	if c.Required && isEmpty(v) {
+		return errors.New("is required")
+	}
+
+	if err := c.checkLength(v); err != nil {
+		return err
+	}
+	if isList(v.Type()) {
+		for i := 0; i < v.Len(); i++ {
+			if err := c.checkItem(v.Index(i)); err != nil {
+				return fmt.Errorf("item %d %w", i, err)
+			}
+		}
+		return nil
+	}
+	return c.checkItem(v)
+}
+
+func (c *Constraints) checkLength(v reflect.Value) error {
+	var length int
+	var unit string
+	switch {
+	case v.Kind() == reflect.String:
+		length, unit = utf8.RuneCountInString(v.String()), "characters"
+	case isBytes(v.Type()):
+		length, unit = v.Len(), "bytes"
+	case isList(v.Type()) || v.Kind() == reflect.Map:
+		length, unit = v.Len(), "items"
+	default:
+		return nil
+	}
+	if c.MinLen != nil && length < *c.MinLen {
+		return fmt.Errorf("must have at least %d %s", *c.MinLen, unit)
+	}
+	if c.MaxLen != nil && length > *c.MaxLen {
+		return fmt.Errorf("must have at most %d %s", *c.MaxLen, unit)
+	}
+	return nil
+}
+
+func (c *Constraints) checkItem(v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var value string
+	switch {
+	case isNumber(v.Type()):
+		n := number(v)
+		if c.Min != nil && n < *c.Min {
+			return fmt.Errorf("must be at least %v", *c.Min)
+		}
+		if c.Max != nil && n > *c.Max {
+			return fmt.Errorf("must be at most %v", *c.Max)
+		}
+		value = strconv.FormatFloat(n, 'f', -1, 64)
+	case v.Kind() == reflect.String:
+		value = v.String()
+		if c.pattern != nil && !c.pattern.MatchString(value) {
+			return fmt.Errorf("must match %s", c.Pattern)
+		}
+	default:
+		return nil
+	}
+	if len(c.In) > 0 && !slices.Contains(c.In, value) {
+		return fmt.Errorf("must be one of %s", strings.Join(c.In, ", "))
+	}
+	return nil
+}
+
+type fieldConstraints struct {
+	index       int
+	constraints *Constraints
+}
+
+// fields caches the constraints of the fields of struct types.
+var fields sync.Map
+
+func structConstraints(t reflect.Type) []fieldConstraints {
+	if cached, ok := fields.Load(t); ok {
+		return cached.([]fieldConstraints)
+	}
+	var result []fieldConstraints
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		c, err := Field(f)
+		if err != nil {
+			// Malformed tags are reported when the type is analyzed.
+			c = nil
+		}
+		result = append(result, fieldConstraints{index: i, constraints: c})
+	}
+	fields.Store(t, result)
+	return result
+}
+
+// Struct checks the fields of a struct, and of the structs it holds, against
+// their constraints. It returns a line for each field breaking them, such as
+// "Address.City is required".
+func Struct(v reflect.Value) []string {
+	var problems []string
+	checkStruct(v, "", &problems)
+	return problems
+}
+
+func checkStruct(v reflect.Value, path string, problems *[]string) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, f := range structConstraints(v.Type()) {
+			field := v.Type().Field(f.index)
+			value := v.Field(f.index)
+			if f.constraints != nil {
+				if err := f.constraints.Check(value); err != nil {
+					*problems = append(*problems, path+field.Name+" "+err.Error())
+				}
+			}
+			// The fields of embedded structs are promoted.
+			if field.Anonymous {
+				checkStruct(value, path, problems)
+			} else {
+				checkStruct(value, path+field.Name+".", problems)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if isBytes(v.Type()) {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			checkStruct(v.Index(i), fmt.Sprintf("%s[%d].", strings.TrimSuffix(path, "."), i), problems)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			checkStruct(v.MapIndex(key), fmt.Sprintf("%s[%v].", strings.TrimSuffix(path, "."), key), problems)
+		}
+	}
+}
+
+// Report checks a message sent by a handler and reports a violation of the
+// rule named rule when it breaks the constraints of its fields.
+func Report(ctx context.Context, rule string, message any) {
+	if len(Struct(reflect.ValueOf(message))) > 0 {
+		handlerctx.Violate(ctx, rule)
+	}
+}
+
+func deref(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+func isBytes(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+func isList(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && !isBytes(t)
+}
+
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isNumber(t reflect.Type) bool {
+	return isInteger(t) || t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+}
+
+func number(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int())
+	case v.CanUint():
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,150 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type address struct {
+	City  string `validate:"required"`
+	Lines []string
+}
+
+type room struct {
+	ID       int64             `validate:"min=1"`
+	Name     string            `validate:"minlen=1,maxlen=5,pattern=^[a-z]{1,5}$"`
+	Kind     string            `validate:"in=small|large"`
+	Floor    *int32            `validate:"min=0,max=10"`
+	Scores   []float64         `validate:"maxlen=2,min=0"`
+	Tags     map[string]string `validate:"required"`
+	Avatar   []byte            `validate:"maxlen=3"`
+	Address  address
+	Previous []*address
+	Owned
+	hidden string `validate:"required"`
+}
+
+type Owned struct {
+	Owner string `validate:"required"`
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestField(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		tag     string
+		typ     reflect.Type
+		want    *Constraints
+		wantErr string
+	}{
+		{name: "required", tag: "required", typ: reflect.TypeFor[string](), want: &Constraints{Required: true}},
+		{name: "bounds", tag: "min=1,max=2.5", typ: reflect.TypeFor[float64](), want: &Constraints{Min: ptr(1.0), Max: ptr(2.5)}},
+		{name: "lengths", tag: "minlen=1,maxlen=3", typ: reflect.TypeFor[[]int](), want: &Constraints{MinLen: ptr(1), MaxLen: ptr(3)}},
+		{name: "pattern with commas", tag: "required,pattern=^a{1,2}$", typ: reflect.TypeFor[*string](), want: &Constraints{Required: true, Pattern: "^a{1,2}$"}},
+		{name: "integers in", tag: "in=1|2", typ: reflect.TypeFor[int](), want: &Constraints{In: []string{"1", "2"}}},
+		{name: "item bounds", tag: "min=0", typ: reflect.TypeFor[[]*int32](), want: &Constraints{Min: ptr(0.0)}},
+		{name: "fractional bound on integer", tag: "max=1.5", typ: reflect.TypeFor[int32](), wantErr: "max=1.5: int32 needs an integer"},
+		{name: "min on string", tag: "min=1", typ: reflect.TypeFor[string](), wantErr: "min needs a number, not string"},
+		{name: "minlen on number", tag: "minlen=1", typ: reflect.TypeFor[int](), wantErr: "minlen needs a string, bytes, list or map, not int"},
+		{name: "negative length", tag: "maxlen=-1", typ: reflect.TypeFor[string](), wantErr: "maxlen=-1: not a length"},
+		{name: "bad pattern", tag: "pattern=(", typ: reflect.TypeFor[string](), wantErr: "pattern=(: error parsing regexp: missing closing ): `(`"},
+		{name: "in on float", tag: "in=1", typ: reflect.TypeFor[float32](), wantErr: "in needs a string or integer, not float32"},
+		{name: "in not integer", tag: "in=1|a", typ: reflect.TypeFor[int](), wantErr: "in=1|a: a is not an integer"},
+		{name: "missing value", tag: "min", typ: reflect.TypeFor[int](), wantErr: `"min" needs a value`},
+		{name: "unknown", tag: "email", typ: reflect.TypeFor[string](), wantErr: `unknown constraint "email"`},
+		{name: "unknown with value", tag: "format=email", typ: reflect.TypeFor[string](), wantErr: `unknown constraint "format"`},
+		{name: "min over max", tag: "min=3,max=1", typ: reflect.TypeFor[int](), wantErr: "min 3 is greater than max 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := reflect.StructField{Name: "F", Type: tt.typ, Tag: reflect.StructTag(`validate:"` + tt.tag + `"`)}
+			got, err := Field(f)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Field() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Field() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(Constraints{})); diff != "" {
+				t.Errorf("Field() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("untagged", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := Field(reflect.StructField{Name: "F", Type: reflect.TypeFor[string]()})
+		if got != nil || err != nil {
+			t.Errorf("Field() = %v, %v, want nil, nil", got, err)
+		}
+	})
+}
+
+func TestStruct(t *testing.T) {
+	t.Parallel()
+
+	valid := room{
+		ID:      1,
+		Name:    "abc",
+		Kind:    "small",
+		Floor:   ptr(int32(3)),
+		Scores:  []float64{0.5},
+		Tags:    map[string]string{"a": "b"},
+		Avatar:  []byte{1},
+		Address: address{City: "Tokyo"},
+		Owned: Owned{
+			Owner: "alice",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*room)
+		want   []string
+	}{
+		{name: "valid", modify: func(*room) {}},
+		{name: "below min", modify: func(r *room) { r.ID = 0 }, want: []string{"ID must be at least 1"}},
+		{name: "too long", modify: func(r *room) { r.Name = "abcdef" }, want: []string{"Name must have at most 5 characters"}},
+		{name: "too short", modify: func(r *room) { r.Name = "" }, want: []string{"Name must have at least 1 characters"}},
+		{name: "pattern", modify: func(r *room) { r.Name = "ABC" }, want: []string{"Name must match ^[a-z]{1,5}$"}},
+		{name: "in", modify: func(r *room) { r.Kind = "huge" }, want: []string{"Kind must be one of small, large"}},
+		{name: "nil pointer", modify: func(r *room) { r.Floor = nil }},
+		{name: "pointer above max", modify: func(r *room) { r.Floor = ptr(int32(11)) }, want: []string{"Floor must be at most 10"}},
+		{name: "too many items", modify: func(r *room) { r.Scores = []float64{1, 2, 3} }, want: []string{"Scores must have at most 2 items"}},
+		{name: "item below min", modify: func(r *room) { r.Scores = []float64{1, -1} }, want: []string{"Scores item 1 must be at least 0"}},
+		{name: "required map", modify: func(r *room) { r.Tags = map[string]string{} }, want: []string{"Tags is required"}},
+		{name: "too many bytes", modify: func(r *room) { r.Avatar = []byte{1, 2, 3, 4} }, want: []string{"Avatar must have at most 3 bytes"}},
+		{name: "nested", modify: func(r *room) { r.Address.City = "" }, want: []string{"Address.City is required"}},
+		{name: "list of structs", modify: func(r *room) { r.Previous = []*address{{City: "Osaka"}, {}} }, want: []string{"Previous[1].City is required"}},
+		{name: "promoted", modify: func(r *room) { r.Owner = "" }, want: []string{"Owner is required"}},
+		{name: "unexported", modify: func(r *room) { r.hidden = "" }},
+		{name: "several", modify: func(r *room) { r.ID, r.Kind = 0, "" }, want: []string{"ID must be at least 1", "Kind must be one of small, large"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := valid
+			tt.modify(&r)
+			if diff := cmp.Diff(tt.want, Struct(reflect.ValueOf(&r))); diff != "" {
+				t.Errorf("Struct() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
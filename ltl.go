@@ -21,17 +21,33 @@ type lasso struct {
 func (*lasso) temporalEvidence() {}
 
 func (m *model) checkLTL() []temporalRuleResult {
+	ticker := m.startProgress(PhaseTemporal)
+	violations := len(m.failedInvariantNames())
 	results := make([]temporalRuleResult, 0, len(m.ltlRules))
 	for _, r := range m.ltlRules {
-		holds, lasso := m.checkBA(r.ba())
+		// A single rule can take long on a large model, so the product
+		// exploration reports progress too.
+		tick := func(productNodes int) {
+			if ticker.due() {
+				ticker.report(Progress{Worlds: len(m.worlds), Rules: len(m.ltlRules), RulesChecked: len(results), ProductNodes: productNodes, Violations: violations}, false)
+			}
+		}
+		holds, lasso := m.checkBA(r.ba(), tick)
 		if !holds {
 			m.hasLTLViolation = true
+			violations++
 		}
 		result := temporalRuleResult{Rule: r.name(), Satisfied: holds}
 		if lasso != nil {
 			result.Evidence = lasso
 		}
 		results = append(results, result)
+		if ticker.due() && len(results) < len(m.ltlRules) {
+			ticker.report(Progress{Worlds: len(m.worlds), Rules: len(m.ltlRules), RulesChecked: len(results), Violations: violations}, false)
+		}
+	}
+	if len(m.ltlRules) > 0 {
+		ticker.report(Progress{Worlds: len(m.worlds), Rules: len(m.ltlRules), RulesChecked: len(results), Violations: violations}, true)
 	}
 	return results
 }
@@ -41,13 +57,16 @@ type prodNode struct {
 	s baState
 }
 
-func (m *model) checkBA(b *ba) (bool, *lasso) {
+// checkBA looks for an accepting cycle in the product of the worlds with b,
+// calling tick with the number of product nodes found as it explores them.
+func (m *model) checkBA(b *ba, tick func(productNodes int)) (bool, *lasso) {
 	start := prodNode{w: m.initial.id, s: b.initial}
 	graph := make(map[prodNode][]prodNode)
 	pre := map[prodNode]prodNode{start: start}
 	queue := []prodNode{start}
 
 	for len(queue) > 0 {
+		tick(len(pre))
 		n := queue[0]
 		queue = queue[1:]
 		labels := m.labels[n.w]
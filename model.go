@@ -3,10 +3,12 @@ package goat
 import (
 	"fmt"
 	"hash/fnv"
-	stdos "os"
+	"io"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type model struct {
@@ -19,6 +21,19 @@ type model struct {
 	ltlRules              []ltlRule
 	hasLTLViolation       bool
 	labels                map[worldID]map[ConditionName]bool
+	reportCoverage        bool
+	failOnDeadHandlers    bool
+	// reporter is set by WithReporter and WithOutput. See report.
+	reporter Reporter
+	progress func(Progress)
+	// progressInterval is the time between two progress reports, or 0 for
+	// defaultProgressInterval.
+	progressInterval time.Duration
+	// input and output are set by WithInput and WithOutput for Explore.
+	input  io.Reader
+	output io.Writer
+	// explored is filled by Solve when set, for coverage and stateDiagrams.
+	explored *exploration
 }
 
 type worldID uint64
@@ -56,68 +71,113 @@ func id(env environment) worldID {
 	for _, smID := range smIDs {
 		sm := env.machines[smID]
 		strs = append(strs, fmt.Sprintf("%s=%s;%s", sm.id(), getStateMachineDetails(sm), getStateDetails(sm.currentState())))
+		for _, r := range getInnerStateMachine(sm).regions {
+			strs = append(strs, fmt.Sprintf("%s=%s", queueID(smID, r.name), getStateDetails(r.state)))
+		}
 	}
 
 	qeNames := make([]string, 0)
 	for smID, events := range env.queue {
 		for _, event := range events {
-			qeNames = append(qeNames, fmt.Sprintf("%s<<%s;%s", smID, getEventName(event), getEventDetails(event)))
+			name := fmt.Sprintf("%s<<%s;%s", smID, getEventName(event), getEventDetails(event))
+			// Snapshots show the group an event was broadcast to, so it
+			// tells worlds apart like the fields of the event do.
+			if group := event.groupName(); group != "" {
+				name += "@" + group
+			}
+			qeNames = append(qeNames, name)
 		}
 	}
 	sort.Strings(qeNames)
 	strs = append(strs, qeNames...)
+	for _, name := range sharedVarNames(env.vars) {
+		strs = append(strs, fmt.Sprintf("$%s=%s", name, getSharedVarDetails(env.vars[name])))
+	}
 	hasher := fnv.New64a()
 	_, _ = hasher.Write([]byte(strings.Join(strs, ",")))
 	return worldID(hasher.Sum64())
 }
 
 func initialWorld(sms ...AbstractStateMachine) world {
-	machines := make(map[string]AbstractStateMachine)
-	queue := make(map[string][]AbstractEvent)
-	nameCounts := make(map[string]int)
+	env := environment{
+		machines: make(map[string]AbstractStateMachine),
+		queue:    make(map[string][]AbstractEvent),
+	}
 
 	for _, sm := range sms {
-		baseName := sm.id()
-		count := nameCounts[baseName]
-
-		var finalID string
-		if count == 0 {
-			finalID = baseName
-		} else {
-			finalID = baseName + "_" + strconv.Itoa(count)
+		env.addMachine(sm)
+	}
+
+	return newWorld(env)
+}
+
+// addMachine registers sm under the first free ID derived from its type name,
+// builds its event handlers and queues the events that enter its initial
+// states.
+func (e *environment) addMachine(sm AbstractStateMachine) {
+	if e.machines == nil {
+		e.machines = make(map[string]AbstractStateMachine)
+	}
+	if e.queue == nil {
+		e.queue = make(map[string][]AbstractEvent)
+	}
+	baseName := sm.id()
+	finalID := baseName
+	for count := 1; ; count++ {
+		if _, exists := e.machines[finalID]; !exists {
+			break
 		}
+		finalID = baseName + "_" + strconv.Itoa(count)
+	}
 
-		// Update the state machine's ID
-		innerSM := getInnerStateMachine(sm)
-		innerSM.smID = finalID
-
-		innerSM.EventHandlers = make(map[AbstractState][]handlerInfo)
-		for state, builders := range innerSM.HandlerBuilders {
-			for _, builderInfo := range builders {
-				handler := builderInfo.builder(finalID)
-				innerSM.EventHandlers[state] = append(innerSM.EventHandlers[state], handlerInfo{
-					event:   builderInfo.event,
-					handler: handler,
-				})
-			}
+	// Update the state machine's ID
+	innerSM := getInnerStateMachine(sm)
+	innerSM.smID = finalID
+
+	innerSM.EventHandlers = make(map[AbstractState][]handlerInfo)
+	for state, builders := range innerSM.HandlerBuilders {
+		for _, builderInfo := range builders {
+			handler := builderInfo.builder(finalID)
+			innerSM.EventHandlers[state] = append(innerSM.EventHandlers[state], handlerInfo{
+				event:   builderInfo.event,
+				handler: handler,
+			})
 		}
+	}
 
-		machines[finalID] = sm
-		queue[finalID] = []AbstractEvent{&entryEvent{}}
-		nameCounts[baseName]++
+	e.machines[finalID] = sm
+	e.queue[finalID] = initialEvents(innerSM, innerSM.State)
+	for _, r := range innerSM.regions {
+		e.queue[queueID(finalID, r.name)] = initialEvents(innerSM, r.state)
 	}
+}
 
-	env := environment{
-		machines: machines,
-		queue:    queue,
+// initialEvents returns the events that enter the initial state of sm,
+// entering its ancestors outermost first when the state is nested.
+func initialEvents(sm *StateMachine, initial AbstractState) []AbstractEvent {
+	lineage := sm.lineage(initial)
+	events := make([]AbstractEvent, 0, len(lineage))
+	for i := len(lineage) - 1; i >= 1; i-- {
+		events = append(events, &ancestorEntryEvent{State: lineage[i]})
 	}
+	return append(events, &entryEvent{})
+}
 
-	return newWorld(env)
+// stepLocal processes the next event queued for the named region of a state
+// machine. The empty region refers to the machine's main states.
+func stepLocal(env environment, smID, region string) ([]localState, error) {
+	return stepLocalHandler(env, smID, region, anyHandler)
 }
 
-func stepLocal(env environment, smID string) ([]localState, error) {
+// anyHandler makes stepLocalHandler run every handler of the event.
+const anyHandler = -1
+
+// stepLocalHandler is stepLocal running only the given alternative among the
+// handlers the handling state has for the event, or every handler when
+// handler is anyHandler.
+func stepLocalHandler(env environment, smID, region string, handler int) ([]localState, error) {
 	ec := env.clone()
-	event, ok := ec.dequeueEvent(smID)
+	event, ok := ec.dequeueEvent(queueID(smID, region))
 	if !ok {
 		return nil, nil
 	}
@@ -128,53 +188,145 @@ func stepLocal(env environment, smID string) ([]localState, error) {
 			if innerSm.halted {
 				return []localState{{env: env.clone()}}, nil
 			}
-			for state, his := range innerSm.EventHandlers {
-				if sameState(state, sm.currentState()) {
-					lss := make([]localState, 0)
-					for _, hi := range his {
-						if sameEvent(hi.event, event) {
-							states, err := hi.handler.handle(ec, smID, event)
-							if err != nil {
-								return nil, err
-							}
-							lss = append(lss, states...)
-						}
-					}
-					if len(lss) > 0 {
-						return lss, nil
+			if _, ok := event.(*exitEvent); ok {
+				ec.expandTransition(innerSm, smID, region)
+			}
+			ec.activeRegion = region
+			scopes, dispatched := innerSm.handlerScopes(region, event)
+			for _, scope := range scopes {
+				lss, err := handleInScope(ec, innerSm, smID, scope, dispatched, handler)
+				if err != nil {
+					return nil, err
+				}
+				if len(lss) > 0 {
+					for i := range lss {
+						lss[i].env.activeRegion = ""
 					}
-					return []localState{{env: ec}}, nil
+					return lss, nil
 				}
 			}
+			ec.activeRegion = ""
+			return []localState{{env: ec}}, nil
 		}
 	}
 	return []localState{{env: ec}}, nil
 }
 
+func handleInScope(env environment, sm *StateMachine, smID string, scope AbstractState, event AbstractEvent, only int) ([]localState, error) {
+	for state, his := range sm.EventHandlers {
+		if !sameState(state, scope) {
+			continue
+		}
+		lss := make([]localState, 0)
+		scopeDetails := ""
+		alternative := -1
+		for i, hi := range his {
+			if sameEvent(hi.event, event) {
+				alternative++
+				if only != anyHandler && alternative != only {
+					continue
+				}
+				states, err := hi.handler.handle(env, smID, event)
+				if err != nil {
+					return nil, err
+				}
+				for _, ls := range states {
+					if ls.env.err != nil {
+						return nil, ls.env.err
+					}
+				}
+				if len(states) > 0 && scopeDetails == "" {
+					scopeDetails = getStateDetails(scope)
+				}
+				for j := range states {
+					states[j].handlerState = scopeDetails
+					states[j].handlerIndex = i
+					states[j].handlerAlternative = alternative
+				}
+				lss = append(lss, states...)
+			}
+		}
+		if alternative >= 0 && len(lss) == 0 && only != anyHandler {
+			return nil, fmt.Errorf("state %s has %d handler(s) for %s, not handler %d", fieldsLabel(getStateDetails(scope)), alternative+1, getEventName(event), only)
+		}
+		return lss, nil
+	}
+	return nil, nil
+}
+
+// exploration holds what Solve observed of the steps it explored, so that
+// the coverage report and the state diagrams do not run the handlers again.
+type exploration struct {
+	usages   map[string]*specUsage
+	diagrams map[string]*stateDiagram
+}
+
+func newExploration() *exploration {
+	return &exploration{
+		usages:   make(map[string]*specUsage),
+		diagrams: make(map[string]*stateDiagram),
+	}
+}
+
+// record records the steps gss enabled in env.
+func (x *exploration) record(env environment, gss []globalStep) {
+	x.recordCoverage(env, gss)
+	x.recordTransitions(env, gss)
+}
+
 func stepGlobal(w world) ([]world, error) {
-	ws := make([]world, 0)
+	gss, err := globalSteps(w.env)
+	if err != nil {
+		return nil, err
+	}
+	return stepWorlds(gss), nil
+}
 
-	env := w.env
+func stepWorlds(gss []globalStep) []world {
+	ws := make([]world, 0, len(gss))
+	for _, gs := range gss {
+		ws = append(ws, newWorld(gs.env))
+	}
+	return ws
+}
 
-	smIDs := make([]string, 0)
+// sortedMachineIDs returns the IDs of the machines of env, sorted.
+func sortedMachineIDs(env environment) []string {
+	smIDs := make([]string, 0, len(env.machines))
 	for smID := range env.machines {
 		smIDs = append(smIDs, smID)
 	}
 	sort.Strings(smIDs)
+	return smIDs
+}
+
+// globalStep is a local state reached by the machine smID processing the
+// next event of a region.
+type globalStep struct {
+	localState
+	smID   string
+	region string
+}
 
+// globalSteps returns the steps enabled in env, ordered by machine ID and
+// region.
+func globalSteps(env environment) ([]globalStep, error) {
+	smIDs := sortedMachineIDs(env)
+	gss := make([]globalStep, 0)
 	for _, smID := range smIDs {
-		states, err := stepLocal(env, smID)
-		if err != nil {
-			return nil, err
-		}
+		for _, region := range regionNames(env.machines[smID]) {
+			states, err := stepLocal(env, smID, region)
+			if err != nil {
+				return nil, err
+			}
 
-		for _, state := range states {
-			w := newWorld(state.env)
-			ws = append(ws, w)
+			for _, state := range states {
+				gss = append(gss, globalStep{localState: state, smID: smID, region: region})
+			}
 		}
 	}
 
-	return ws, nil
+	return gss, nil
 }
 
 func newModel(opts ...Option) (model, error) {
@@ -182,8 +334,17 @@ func newModel(opts ...Option) (model, error) {
 	if len(os.sms) == 0 {
 		return model{}, fmt.Errorf("no state machines provided")
 	}
-	warnShallowPointerFields(stdos.Stderr, os.sms)
+	reporter := os.reporter
+	if reporter == nil {
+		reporter = defaultReporter()
+	}
+	warnLines(reporter, func(w io.Writer) { warnShallowPointerFields(w, os.sms) })
 	initial := initialWorld(os.sms...)
+	initial.env.maxInstances = os.maxInstances
+	if os.sharedVars != nil {
+		initial.env.vars = cloneSharedVars(os.sharedVars)
+		initial = newWorld(initial.env)
+	}
 	m := model{
 		initial:    initial,
 		worlds:     make(worlds),
@@ -192,6 +353,17 @@ func newModel(opts ...Option) (model, error) {
 		invariants: os.invariants,
 		ltlRules:   os.ltlRules,
 		labels:     make(map[worldID]map[ConditionName]bool),
+
+		reportCoverage:     os.coverage || os.failOnDeadHandlers,
+		failOnDeadHandlers: os.failOnDeadHandlers,
+		reporter:           os.reporter,
+		progress:           os.progress,
+		progressInterval:   os.progressInterval,
+		input:              os.input,
+		output:             os.output,
+	}
+	if m.reportCoverage {
+		m.explored = newExploration()
 	}
 	m.labelWorld(initial)
 	return m, nil
@@ -207,38 +379,84 @@ func (m *model) labelWorld(w world) {
 }
 
 func (m *model) Solve() error {
+	ticker := m.startProgress(PhaseExplore)
 	m.worlds.insert(m.initial)
 	stack := []world{m.initial}
+	depths := []int{0}
+	maxDepth := 0
+	violated := make(map[ConditionName]bool)
 
 	for len(stack) > 0 {
-		current := stack[len(stack)-1]
+		// Handlers may have reported violations of the world after it was
+		// pushed.
+		current := m.worlds[stack[len(stack)-1].id]
+		depth := depths[len(depths)-1]
 		stack = stack[:len(stack)-1]
+		depths = depths[:len(depths)-1]
+		maxDepth = max(maxDepth, depth)
 
 		if failed := m.evaluateInvariants(current); len(failed) > 0 {
 			m.hasInvariantViolation = true
-			current.failedInvariants = append(current.failedInvariants, failed...)
+			current.failedInvariants = appendNew(current.failedInvariants, failed)
 			m.worlds[current.id] = current
+			for _, name := range failed {
+				violated[name] = true
+			}
 		}
 
 		acc := make([]worldID, 0)
-		nexts, err := stepGlobal(current)
+		gss, err := globalSteps(current.env)
 		if err != nil {
 			return err
 		}
-		for _, next := range nexts {
+		if m.explored != nil {
+			m.explored.record(current.env, gss)
+		}
+		for _, next := range stepWorlds(gss) {
 			acc = append(acc, next.id)
-			if !m.worlds.member(next) {
-				m.worlds.insert(next)
-				m.labelWorld(next)
-				stack = append(stack, next)
+			// The rules handlers report broken are moved from the state of
+			// the world to its failed invariants.
+			reported := next.env.violations
+			next.env.violations = nil
+			for _, name := range reported {
+				m.hasInvariantViolation = true
+				violated[name] = true
 			}
+			if m.worlds.member(next) {
+				if len(reported) > 0 {
+					w := m.worlds[next.id]
+					w.failedInvariants = appendNew(w.failedInvariants, reported)
+					m.worlds[next.id] = w
+				}
+				continue
+			}
+			next.failedInvariants = reported
+			m.worlds.insert(next)
+			m.labelWorld(next)
+			stack = append(stack, next)
+			depths = append(depths, depth+1)
 		}
 		m.accessible[current.id] = acc
+
+		if ticker.due() {
+			ticker.report(Progress{Worlds: len(m.worlds), Frontier: len(stack), Depth: maxDepth, Violations: len(violated)}, false)
+		}
 	}
 
+	ticker.report(Progress{Worlds: len(m.worlds), Depth: maxDepth, Violations: len(violated)}, true)
 	return nil
 }
 
+// appendNew appends to names those of added it does not hold.
+func appendNew(names, added []ConditionName) []ConditionName {
+	for _, name := range added {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func (m *model) evaluateInvariants(w world) []ConditionName {
 	failed := make([]ConditionName, 0)
 	for _, name := range m.invariants {
@@ -250,10 +468,19 @@ func (m *model) evaluateInvariants(w world) []ConditionName {
 }
 
 type options struct {
-	sms        []AbstractStateMachine
-	conds      map[ConditionName]Condition
-	invariants []ConditionName
-	ltlRules   []ltlRule
+	sms                []AbstractStateMachine
+	maxInstances       int
+	sharedVars         map[string]any
+	coverage           bool
+	failOnDeadHandlers bool
+	reporter           Reporter
+	progress           func(Progress)
+	progressInterval   time.Duration
+	input              io.Reader
+	output             io.Writer
+	conds              map[ConditionName]Condition
+	invariants         []ConditionName
+	ltlRules           []ltlRule
 }
 
 // Option is a configuration option for model checking operations.
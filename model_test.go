@@ -1,7 +1,9 @@
 package goat
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -127,7 +129,7 @@ func TestModel_Solve(t *testing.T) {
 				expected := tt.want()
 
 				opts := cmp.Options{
-					cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders"),
+					cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders", "definition"),
 					cmpopts.IgnoreFields(model{}, "conds", "invariants", "labels"), // Ignore function pointers and maps
 					cmp.AllowUnexported(
 						model{},
@@ -297,7 +299,7 @@ func TestInitialWorld(t *testing.T) {
 
 			opts := cmp.Options{
 				cmpopts.IgnoreFields(world{}, "id"),
-				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders"),
+				cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders", "definition"),
 				cmp.AllowUnexported(
 					world{},
 					environment{},
@@ -453,7 +455,7 @@ func TestStepGlobal(t *testing.T) {
 
 				opts := cmp.Options{
 					cmpopts.IgnoreFields(world{}, "id"),
-					cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders"),
+					cmpopts.IgnoreFields(StateMachine{}, "EventHandlers", "HandlerBuilders", "definition"),
 					cmp.AllowUnexported(
 						world{},
 						environment{},
@@ -474,3 +476,272 @@ func TestStepGlobal(t *testing.T) {
 		})
 	}
 }
+
+type hierarchyTestStateMachine struct {
+	StateMachine
+	Log []string
+}
+
+func TestModel_Solve_nestedStates(t *testing.T) {
+	idle := newTestState("idle")
+	running := newTestState("running")
+	healthy := newTestState("healthy")
+
+	spec := NewStateMachineSpec(&hierarchyTestStateMachine{})
+	spec.DefineStates(idle, running).
+		DefineSubstates(running, healthy).
+		SetInitialState(healthy)
+
+	logEntry := func(entry string) func(context.Context, *hierarchyTestStateMachine) {
+		return func(_ context.Context, sm *hierarchyTestStateMachine) {
+			sm.Log = append(sm.Log, entry)
+		}
+	}
+	OnEntry(spec, running, logEntry("enter running"))
+	OnEntry(spec, healthy, func(ctx context.Context, sm *hierarchyTestStateMachine) {
+		sm.Log = append(sm.Log, "enter healthy")
+		SendTo(ctx, sm, &testEvent{})
+	})
+	OnExit(spec, healthy, logEntry("exit healthy"))
+	OnExit(spec, running, logEntry("exit running"))
+	OnEntry(spec, idle, logEntry("enter idle"))
+	// Registered on the parent only; must apply while in the healthy substate.
+	OnEvent(spec, running, func(ctx context.Context, _ *testEvent, sm *hierarchyTestStateMachine) {
+		sm.Log = append(sm.Log, "handle event")
+		Goto(ctx, idle)
+	})
+
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+	m, err := newModel(WithStateMachines(sm))
+	if err != nil {
+		t.Fatalf("newModel() returned error: %v", err)
+	}
+	if err := m.Solve(); err != nil {
+		t.Fatalf("Solve() returned error: %v", err)
+	}
+
+	var final *hierarchyTestStateMachine
+	for _, w := range m.worlds {
+		if len(m.accessible[w.id]) == 0 {
+			final = w.env.machines[sm.id()].(*hierarchyTestStateMachine)
+		}
+	}
+	if final == nil {
+		t.Fatal("no terminal world found")
+	}
+
+	want := []string{
+		"enter running",
+		"enter healthy",
+		"handle event",
+		"exit healthy",
+		"exit running",
+		"enter idle",
+	}
+	if diff := cmp.Diff(want, final.Log); diff != "" {
+		t.Errorf("handler order mismatch (-want +got):\n%s", diff)
+	}
+	if !sameState(final.currentState(), idle) {
+		t.Errorf("final state = %s, want idle", getStateDetails(final.currentState()))
+	}
+}
+
+func TestModel_Solve_consecutiveGotos(t *testing.T) {
+	idle := newTestState("idle")
+	running := newTestState("running")
+	healthy := newTestState("healthy")
+	degraded := newTestState("degraded")
+
+	spec := NewStateMachineSpec(&hierarchyTestStateMachine{})
+	spec.DefineStates(idle, running).
+		DefineSubstates(running, healthy, degraded).
+		SetInitialState(healthy)
+
+	OnEvent(spec, healthy, func(ctx context.Context, _ *testEvent, sm *hierarchyTestStateMachine) {
+		// The second transition starts from idle, not from healthy.
+		Goto(ctx, idle)
+		Goto(ctx, degraded)
+	})
+	OnEntry(spec, healthy, func(ctx context.Context, sm *hierarchyTestStateMachine) {
+		SendTo(ctx, sm, &testEvent{})
+	})
+	OnEntry(spec, running, func(_ context.Context, sm *hierarchyTestStateMachine) {
+		sm.Log = append(sm.Log, "enter running")
+	})
+	OnExit(spec, running, func(_ context.Context, sm *hierarchyTestStateMachine) {
+		sm.Log = append(sm.Log, "exit running")
+	})
+
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+	m, err := newModel(WithStateMachines(sm))
+	if err != nil {
+		t.Fatalf("newModel() returned error: %v", err)
+	}
+	if err := m.Solve(); err != nil {
+		t.Fatalf("Solve() returned error: %v", err)
+	}
+
+	var final *hierarchyTestStateMachine
+	for _, w := range m.worlds {
+		if len(m.accessible[w.id]) == 0 {
+			final = w.env.machines[sm.id()].(*hierarchyTestStateMachine)
+		}
+	}
+	if final == nil {
+		t.Fatal("no terminal world found")
+	}
+	want := []string{"enter running", "exit running", "enter running"}
+	if diff := cmp.Diff(want, final.Log); diff != "" {
+		t.Errorf("handler order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Solve_nestedHaltAndTransition(t *testing.T) {
+	running := newTestState("running")
+	healthy := newTestState("healthy")
+	degraded := newTestState("degraded")
+
+	spec := NewStateMachineSpec(&hierarchyTestStateMachine{})
+	spec.DefineStates(running).
+		DefineSubstates(running, healthy, degraded).
+		SetInitialState(healthy)
+
+	OnEntry(spec, healthy, func(ctx context.Context, sm *hierarchyTestStateMachine) {
+		Goto(ctx, degraded)
+	})
+	OnEntry(spec, degraded, func(ctx context.Context, sm *hierarchyTestStateMachine) {
+		Halt(ctx, sm)
+	})
+	// Registered on the parent only; must apply while in its substates.
+	OnTransition(spec, running, func(_ context.Context, to AbstractState, sm *hierarchyTestStateMachine) {
+		sm.Log = append(sm.Log, "transition to "+getStateDetails(to))
+	})
+	OnHalt(spec, running, func(_ context.Context, sm *hierarchyTestStateMachine) {
+		sm.Log = append(sm.Log, "halt")
+	})
+
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+	m, err := newModel(WithStateMachines(sm))
+	if err != nil {
+		t.Fatalf("newModel() returned error: %v", err)
+	}
+	if err := m.Solve(); err != nil {
+		t.Fatalf("Solve() returned error: %v", err)
+	}
+
+	// The default transition and halt handlers remain alternatives, as they
+	// do for handlers registered on the current state itself.
+	want := []string{"transition to " + getStateDetails(degraded), "halt"}
+	var logs [][]string
+	for _, w := range m.worlds {
+		if len(m.accessible[w.id]) == 0 {
+			logs = append(logs, w.env.machines[sm.id()].(*hierarchyTestStateMachine).Log)
+		}
+	}
+	if !slices.ContainsFunc(logs, func(log []string) bool { return slices.Equal(log, want) }) {
+		t.Errorf("terminal logs = %q, want one of them to be %q", logs, want)
+	}
+}
+
+func TestModel_Solve_orthogonalRegions(t *testing.T) {
+	disconnected := newTestState("disconnected")
+	connected := newTestState("connected")
+	follower := newTestState("follower")
+	leader := newTestState("leader")
+
+	spec := NewStateMachineSpec(&testStateMachine{})
+	spec.DefineStates(disconnected, connected).
+		SetInitialState(disconnected).
+		DefineRegion("leadership", follower, leader).
+		SetRegionInitialState("leadership", follower)
+
+	OnEntry(spec, disconnected, func(ctx context.Context, _ *testStateMachine) {
+		Goto(ctx, connected)
+	})
+	OnEntry(spec, follower, func(ctx context.Context, _ *testStateMachine) {
+		Goto(ctx, leader)
+	})
+
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+	m, err := newModel(WithStateMachines(sm))
+	if err != nil {
+		t.Fatalf("newModel() returned error: %v", err)
+	}
+	if err := m.Solve(); err != nil {
+		t.Fatalf("Solve() returned error: %v", err)
+	}
+
+	type combination struct{ main, region string }
+	seen := make(map[combination]bool)
+	for _, w := range m.worlds {
+		machine := getInnerStateMachine(w.env.machines[sm.id()])
+		seen[combination{
+			main:   machine.State.(*testState).Name,
+			region: machine.RegionState("leadership").(*testState).Name,
+		}] = true
+	}
+
+	want := map[combination]bool{
+		{main: "disconnected", region: "follower"}: true,
+		{main: "disconnected", region: "leader"}:   true,
+		{main: "connected", region: "follower"}:    true,
+		{main: "connected", region: "leader"}:      true,
+	}
+	if diff := cmp.Diff(want, seen); diff != "" {
+		t.Errorf("reached state combinations mismatch (-want +got):\n%s", diff)
+	}
+
+	// Each region processes its entry, exit, transition and entry events
+	// independently, so every interleaving of the two 4-step sequences is a
+	// distinct world.
+	if len(m.worlds) != 25 {
+		t.Errorf("len(worlds) = %d, want 25", len(m.worlds))
+	}
+}
+
+func TestModel_Solve_spawn(t *testing.T) {
+	initial := newTestState("initial")
+	spec := NewStateMachineSpec(&testStateMachine{})
+	spec.DefineStates(initial).SetInitialState(initial)
+	// Every instance spawns another one on entry; only the bound stops it.
+	OnEntry(spec, initial, func(ctx context.Context, _ *testStateMachine) {
+		Spawn(ctx, spec, nil)
+	})
+
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+	m, err := newModel(WithStateMachines(sm), WithMaxInstances(3))
+	if err != nil {
+		t.Fatalf("newModel() returned error: %v", err)
+	}
+	if err := m.Solve(); err != nil {
+		t.Fatalf("Solve() returned error: %v", err)
+	}
+
+	maxMachines := 0
+	for _, w := range m.worlds {
+		maxMachines = max(maxMachines, len(w.env.machines))
+	}
+	if maxMachines != 3 {
+		t.Errorf("largest world has %d machines, want 3", maxMachines)
+	}
+
+	// initial, spawned _1, spawned _2 and all entries processed.
+	if len(m.worlds) != 4 {
+		t.Errorf("len(worlds) = %d, want 4", len(m.worlds))
+	}
+}
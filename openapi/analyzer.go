@@ -1,7 +1,11 @@
 package openapi
 
 import (
+	"reflect"
+	"slices"
 	"sort"
+
+	"github.com/goatx/goat/internal/typeutil"
 )
 
 type schemaAnalyzer struct{}
@@ -17,6 +21,7 @@ type pathOperation struct {
 	RequestSchema  *schemaDefinition
 	Responses      []operationResponse
 	IsBodyOptional bool
+	Description    string
 }
 
 type operationResponse struct {
@@ -34,13 +39,21 @@ type definitions struct {
 	Paths   []*pathDefinition
 }
 
-func (*schemaAnalyzer) analyzeSpecs(specs ...AbstractServiceSpec) *definitions {
+// analyzeSpecs collects the definitions of specs. It returns an error when
+// distinct Go types are given the same schema name, as only one of them
+// could be defined.
+func (*schemaAnalyzer) analyzeSpecs(specs ...AbstractServiceSpec) (*definitions, error) {
 	definitions := &definitions{}
 
 	operations := make(map[string]map[string]*pathOperation)
+	// Schemas shared by several specs, such as nested types, are written once.
+	schemaTypes := make(map[string]reflect.Type)
 
 	for _, spec := range specs {
-		specSchemas := spec.getSchemas()
+		if conflicts := spec.getNameConflicts(); len(conflicts) > 0 {
+			return nil, conflicts[0]
+		}
+		specSchemas := describeSchemas(spec)
 		endpoints := spec.getEndpoints()
 
 		for _, endpoint := range endpoints {
@@ -58,6 +71,7 @@ func (*schemaAnalyzer) analyzeSpecs(specs ...AbstractServiceSpec) *definitions {
 					RequestRef:     endpoint.RequestType,
 					RequestSchema:  specSchemas[endpoint.RequestType],
 					IsBodyOptional: endpoint.IsBodyOptional,
+					Description:    endpoint.Description,
 				}
 				methods[endpoint.Method.String()] = op
 			}
@@ -69,7 +83,15 @@ func (*schemaAnalyzer) analyzeSpecs(specs ...AbstractServiceSpec) *definitions {
 		}
 
 		schemas := make([]*schemaDefinition, 0, len(specSchemas))
-		for _, schema := range specSchemas {
+		types := spec.getSchemaTypes()
+		for name, schema := range specSchemas {
+			if t, ok := schemaTypes[name]; ok {
+				if t != types[name] {
+					return nil, typeutil.NameConflict(name, t, types[name])
+				}
+				continue
+			}
+			schemaTypes[name] = types[name]
 			schemas = append(schemas, schema)
 		}
 		sort.Slice(schemas, func(i, j int) bool {
@@ -92,5 +114,28 @@ func (*schemaAnalyzer) analyzeSpecs(specs ...AbstractServiceSpec) *definitions {
 		return definitions.Paths[i].Path < definitions.Paths[j].Path
 	})
 
-	return definitions
+	return definitions, nil
+}
+
+// describeSchemas returns copies of the schemas of spec, by name, whose
+// descriptions and those of their fields are the doc comments of the Go
+// types and fields they stand for. The source is only looked up here, so
+// that registering endpoints does not run go list.
+func describeSchemas(spec AbstractServiceSpec) map[string]*schemaDefinition {
+	docs := spec.getDocs()
+	types := spec.getSchemaTypes()
+	sources := spec.getFieldSources()
+	schemas := make(map[string]*schemaDefinition, len(spec.getSchemas()))
+	for name, schema := range spec.getSchemas() {
+		clone := *schema
+		clone.Description = docs.Type(types[name])
+		clone.Fields = slices.Clone(schema.Fields)
+		for i := range clone.Fields {
+			if source, ok := sources[name][clone.Fields[i].Name]; ok {
+				clone.Fields[i].Description = docs.Field(source.owner, source.name)
+			}
+		}
+		schemas[name] = &clone
+	}
+	return schemas
 }
@@ -250,11 +250,74 @@ func TestSchemaAnalyzer_analyzeSpecs(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := newSchemaAnalyzer()
-			got := a.analyzeSpecs(tt.specs...)
-
+			got, err := a.analyzeSpecs(tt.specs...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("analyzeSpecs() error = %v, wantErr %v", err, tt.wantErr)
+			}
 			if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
 				t.Errorf("analyzeSpecs() mismatch (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
+
+func TestSchemaAnalyzer_analyzeSpecs_nameConflict(t *testing.T) {
+	// Two distinct types, declared in different scopes, named alike.
+	type TestUser struct {
+		Schema[*TestService1, *TestService1]
+		Name string
+	}
+	firstSpec := func() AbstractServiceSpec {
+		spec := NewServiceSpec(&TestService1{})
+		state := &TestIdleState{}
+		spec.DefineStates(state).SetInitialState(state)
+		OnRequest(spec, state, HTTPMethodPost, "/first",
+			func(ctx context.Context, event *TestUser, sm *TestService1) Response[*TestResponse1] {
+				return SendTo(ctx, sm, &TestResponse1{})
+			})
+		return spec
+	}
+	secondSpec := func() AbstractServiceSpec {
+		type TestUser struct {
+			Schema[*TestService1, *TestService1]
+			ID int64
+		}
+		spec := NewServiceSpec(&TestService1{})
+		state := &TestIdleState{}
+		spec.DefineStates(state).SetInitialState(state)
+		OnRequest(spec, state, HTTPMethodPost, "/second",
+			func(ctx context.Context, event *TestUser, sm *TestService1) Response[*TestResponse1] {
+				return SendTo(ctx, sm, &TestResponse1{})
+			})
+		return spec
+	}
+
+	tests := []struct {
+		name  string
+		specs []AbstractServiceSpec
+	}{
+		{name: "in different specs", specs: []AbstractServiceSpec{firstSpec(), secondSpec()}},
+		{name: "in one spec", specs: []AbstractServiceSpec{func() AbstractServiceSpec {
+			spec := firstSpec().(*ServiceSpec[*TestService1])
+			type TestUser struct {
+				Schema[*TestService1, *TestService1]
+				Email string
+			}
+			OnRequest(spec, &TestIdleState{}, HTTPMethodPost, "/third",
+				func(ctx context.Context, event *TestUser, sm *TestService1) Response[*TestResponse1] {
+					return SendTo(ctx, sm, &TestResponse1{})
+				})
+			return spec
+		}()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newSchemaAnalyzer().analyzeSpecs(tt.specs...)
+			want := "github.com/goatx/goat/openapi.TestUser and github.com/goatx/goat/openapi.TestUser are both named TestUser"
+			if err == nil || err.Error() != want {
+				t.Errorf("analyzeSpecs() error = %v, want %q", err, want)
+			}
+		})
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"log"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/goatx/goat"
 	"github.com/goatx/goat/internal/strcase"
 	"github.com/goatx/goat/internal/typeutil"
+	"github.com/goatx/goat/internal/validate"
 )
 
 // Response represents a response that will be sent back in an OpenAPI RPC handler.
@@ -27,6 +29,13 @@ func (responseImpl[O]) isResponse() {}
 // SendTo sends an OpenAPI response event to the target state machine and returns a Response.
 // This is the only way to create a Response, enforcing that all responses go through the proper event system.
 //
+// The model checker reports a violation of the rule "valid schemas" when the
+// event breaks the constraints declared on its fields with the validate
+// struct tag. The constraints are those of the protobuf package: required,
+// min=N, max=N, minlen=N, maxlen=N, pattern=RE and in=a|b|c. Generate writes
+// them as the JSON Schema keywords required, minimum, maximum, minLength,
+// maxLength, minItems, maxItems, pattern and enum.
+//
 // Type parameters:
 //   - O: The response schema type (must implement AbstractSchema)
 //
@@ -37,7 +46,18 @@ func (responseImpl[O]) isResponse() {}
 //
 // Returns:
 //   - Response[O]: A sealed response type that can only be created through this function
+//
+// Example:
+//
+//	type CreateUserResponse struct {
+//	    openapi.Schema[*Server, *Client]
+//	    Name string `validate:"required,maxlen=20"`
+//	    Age  int32  `validate:"min=0,max=150"`
+//	}
+//
+//	return openapi.SendTo(ctx, req.Sender(), &CreateUserResponse{Name: name, Age: age})
 func SendTo[O AbstractSchema](ctx context.Context, target goat.AbstractStateMachine, event O) Response[O] {
+	validate.Report(ctx, validSchemasRule, event)
 	goat.SendTo(ctx, target, event)
 	return responseImpl[O]{event: event}
 }
@@ -49,6 +69,7 @@ type requestConfig struct {
 	operationID    string
 	statusCode     StatusCode
 	isBodyOptional bool
+	description    string
 }
 
 // WithOperationID sets a custom operation ID for the endpoint.
@@ -74,6 +95,15 @@ func WithRequestBodyOptional() RequestOption {
 	}
 }
 
+// WithDescription sets the description of the endpoint in the generated
+// OpenAPI spec. Schemas and their properties are described with the doc
+// comments of their Go types and fields instead.
+func WithDescription(description string) RequestOption {
+	return func(c *requestConfig) {
+		c.description = description
+	}
+}
+
 // NewServiceSpec creates a new OpenAPI service specification for the given
 // state machine prototype. The prototype defines which state machine implementation
 // will receive requests and how its states will be exposed via OpenAPI.
@@ -108,7 +138,16 @@ func NewServiceSpec[T goat.AbstractStateMachine](prototype T) *ServiceSpec[T] {
 //   - method: The HTTP method (e.g., HTTPMethodGet, HTTPMethodPost)
 //   - path: The URL path for the endpoint (e.g., "/users/{id}")
 //   - handler: The function that handles requests for this endpoint
-//   - opts: Optional configuration options (WithOperationID, WithStatusCode)
+//   - opts: Optional configuration options (WithOperationID, WithStatusCode,
+//     WithDescription)
+//
+// The request and response schemas are described with the doc comments of
+// their Go types and fields, read from the source of the packages declaring
+// them as go list finds it from the package calling OnRequest.
+//
+// Struct fields are written as component schemas referenced with $ref, maps
+// as objects with additionalProperties, pointers as nullable, time.Time as a
+// date-time string, and types registered with RegisterEnum as enums.
 func OnRequest[T goat.AbstractStateMachine, I AbstractSchema, O AbstractSchema](
 	spec *ServiceSpec[T],
 	state goat.AbstractState,
@@ -120,6 +159,7 @@ func OnRequest[T goat.AbstractStateMachine, I AbstractSchema, O AbstractSchema](
 	if method == nil {
 		panic("http method must not be nil")
 	}
+	spec.docs.RegisterCaller(1)
 
 	config := &requestConfig{
 		operationID:    "",
@@ -145,14 +185,15 @@ func OnRequest[T goat.AbstractStateMachine, I AbstractSchema, O AbstractSchema](
 		ResponseType:   responseTypeName,
 		StatusCode:     config.statusCode,
 		IsBodyOptional: config.isBodyOptional,
+		Description:    config.description,
 	}
 
 	spec.addEndpoint(&metadata)
 
-	requestSchema := analyzeSchema(requestEvent)
-	responseSchema := analyzeSchema(responseEvent)
-	spec.addSchema(requestSchema)
-	spec.addSchema(responseSchema)
+	analyzer := newComponentAnalyzer()
+	analyzer.analyzeStruct(derefType(reflect.TypeOf(requestEvent)), "")
+	analyzer.analyzeStruct(derefType(reflect.TypeOf(responseEvent)), "")
+	spec.addAnalysis(analyzer)
 
 	wrappedHandler := func(ctx context.Context, event I, sm T) {
 		_ = handler(ctx, event, sm)
@@ -195,28 +236,59 @@ func newSchemaPrototype[T AbstractSchema]() T {
 }
 
 func analyzeSchema[S AbstractSchema](instance S) *schemaDefinition {
-	schemaType := reflect.TypeOf(instance)
-	if schemaType.Kind() == reflect.Ptr {
-		schemaType = schemaType.Elem()
+	return newComponentAnalyzer().analyzeStruct(derefType(reflect.TypeOf(instance)), "")
+}
+
+// componentAnalyzer collects a schema together with the component schemas
+// its fields refer to: nested structs and registered enums.
+type componentAnalyzer struct {
+	schemas map[string]*schemaDefinition
+	// names holds the schema name given to each type analyzed.
+	names map[reflect.Type]string
+	// fieldSources maps schema and field names to the Go fields they stand
+	// for.
+	fieldSources map[string]map[string]fieldSource
+}
+
+func newComponentAnalyzer() *componentAnalyzer {
+	return &componentAnalyzer{
+		schemas:      make(map[string]*schemaDefinition),
+		names:        make(map[reflect.Type]string),
+		fieldSources: make(map[string]map[string]fieldSource),
 	}
+}
 
-	var fields []schemaField
+// analyzeStruct analyzes a struct type as a schema, named after the type, or
+// name when the type has no name.
+func (a *componentAnalyzer) analyzeStruct(t reflect.Type, name string) *schemaDefinition {
+	if name, ok := a.names[t]; ok {
+		return a.schemas[name]
+	}
+	if t.Name() != "" {
+		name = t.Name()
+	}
+	schema := &schemaDefinition{Name: name}
+	// Register the schema before its fields so recursive types refer to it.
+	a.names[t] = name
+	a.schemas[name] = schema
 
-	for i := 0; i < schemaType.NumField(); i++ {
-		field := schemaType.Field(i)
+	a.addFields(schema, t)
+	return schema
+}
 
-		if !field.IsExported() {
-			continue
-		}
-		if field.Type == reflect.TypeOf(Schema[goat.AbstractStateMachine, goat.AbstractStateMachine]{}) {
+func (a *componentAnalyzer) addFields(schema *schemaDefinition, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if !field.IsExported() || field.Name == "_" {
 			continue
 		}
-		if field.Name == "_" {
+		if typeutil.IsEventType(field.Type) || isSchemaType(field.Type) {
 			continue
 		}
-
-		openAPIType, format, isArray := mapGoField(field.Type)
-		if openAPIType == "" {
+		// The fields of embedded structs are promoted, as in encoding/json.
+		if field.Anonymous && derefType(field.Type).Kind() == reflect.Struct {
+			a.addFields(schema, derefType(field.Type))
 			continue
 		}
 
@@ -224,27 +296,109 @@ func analyzeSchema[S AbstractSchema](instance S) *schemaDefinition {
 
 		newField := schemaField{
 			Name:      fieldName,
-			Type:      openAPIType,
-			Format:    format,
-			IsArray:   isArray,
 			Required:  isRequired,
 			ParamType: paramType,
 		}
+		if err := a.fieldType(&newField, field.Type, schema.Name+field.Name); err != nil {
+			log.Printf("[WARNING] openapi: ignoring field %s: %v", field.Name, err)
+			continue
+		}
+
+		constraints, err := validate.Field(field)
+		if err != nil {
+			log.Printf("[WARNING] openapi: ignoring invalid validate tag on field %s: %v", field.Name, err)
+			constraints = nil
+		}
+		if constraints != nil && constraints.Required {
+			newField.Required = true
+		}
+		newField.Constraints = constraints
+
+		schema.Fields = append(schema.Fields, newField)
+		if a.fieldSources[schema.Name] == nil {
+			a.fieldSources[schema.Name] = make(map[string]fieldSource)
+		}
+		a.fieldSources[schema.Name][fieldName] = fieldSource{owner: t, name: field.Name}
+	}
+}
+
+// fieldType describes the Go type t in field: its items when it is a list,
+// its values when it is a map, the component schema it refers to when it is
+// a struct or a registered enum, and whether it is nullable when it is a
+// pointer. Structs are analyzed as schemas; nameHint names them when they
+// are anonymous.
+func (a *componentAnalyzer) fieldType(field *schemaField, t reflect.Type, nameHint string) error {
+	if t.Kind() == reflect.Pointer {
+		field.Nullable = true
+		t = t.Elem()
+	}
 
-		fields = append(fields, newField)
+	switch {
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		// []byte is encoded as a base64 string, as in encoding/json.
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		if field.IsArray {
+			return fmt.Errorf("%s: lists of lists are not supported", t)
+		}
+		field.IsArray = true
+		elem := schemaField{IsArray: true}
+		if err := a.fieldType(&elem, derefType(t.Elem()), nameHint); err != nil {
+			return err
+		}
+		field.Type, field.Format, field.Ref, field.Value = elem.Type, elem.Format, elem.Ref, elem.Value
+		return nil
+	case t.Kind() == reflect.Map:
+		if t.Key().Kind() != reflect.String && !isIntegerKind(t.Key().Kind()) {
+			return fmt.Errorf("%s: map keys must be strings or integers", t)
+		}
+		value := &schemaField{}
+		if err := a.fieldType(value, t.Elem(), nameHint); err != nil {
+			return err
+		}
+		field.Type, field.Value = "object", value
+		return nil
 	}
 
-	return &schemaDefinition{
-		Name:   schemaType.Name(),
-		Fields: fields,
+	if values := lookupEnum(t); values != nil {
+		name := t.Name()
+		if _, ok := a.schemas[name]; !ok {
+			typeName, format, _ := mapGoField(t)
+			a.names[t] = name
+			a.schemas[name] = &schemaDefinition{
+				Name: name,
+				Enum: &enumDefinition{Type: typeName, Format: format, Values: values},
+			}
+		}
+		field.Ref = name
+		return nil
 	}
+
+	if typeName, format, _ := mapGoField(t); typeName != "" {
+		field.Type, field.Format = typeName, format
+		return nil
+	}
+
+	if t.Kind() == reflect.Struct {
+		if typeutil.IsEventType(t) || isSchemaType(t) {
+			return fmt.Errorf("%s cannot be a field", t)
+		}
+		field.Ref = a.analyzeStruct(t, nameHint).Name
+		return nil
+	}
+	return fmt.Errorf("%s has no OpenAPI equivalent", t)
 }
 
 func mapGoField(goType reflect.Type) (typeName, format string, isArray bool) {
+	if goType.Kind() == reflect.Slice && goType.Elem().Kind() == reflect.Uint8 {
+		return "string", "byte", false
+	}
 	if goType.Kind() == reflect.Slice {
 		elemType, format, _ := mapGoField(goType.Elem())
 		return elemType, format, true
 	}
+	if goType == reflect.TypeFor[time.Time]() {
+		return "string", "date-time", false
+	}
 
 	switch goType.Kind() {
 	case reflect.String:
@@ -264,6 +418,23 @@ func mapGoField(goType reflect.Type) (typeName, format string, isArray bool) {
 	}
 }
 
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
 // GenerateOptions contains configuration options for OpenAPI spec generation.
 type GenerateOptions struct {
 	// OutputDir is the directory where the OpenAPI spec file will be written.
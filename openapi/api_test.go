@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -207,6 +208,20 @@ func TestMapGoField(t *testing.T) {
 			wantFormat:  "",
 			wantIsArray: true,
 		},
+		{
+			name:        "maps time.Time to string with date-time format",
+			goType:      reflect.TypeOf(time.Time{}),
+			wantType:    "string",
+			wantFormat:  "date-time",
+			wantIsArray: false,
+		},
+		{
+			name:        "maps []byte to string with byte format",
+			goType:      reflect.TypeOf([]byte{}),
+			wantType:    "string",
+			wantFormat:  "byte",
+			wantIsArray: false,
+		},
 		{
 			name:        "handles unsupported type",
 			goType:      reflect.TypeOf(make(chan int)),
@@ -395,3 +410,100 @@ func structField(t *testing.T, sample any, fieldName string) *reflect.StructFiel
 	fieldCopy := field
 	return &fieldCopy
 }
+
+// TestSearchRequest asks for the users matching a query.
+type TestSearchRequest struct {
+	Schema[*TestService1, *TestService1]
+	// Query is matched against user names.
+	Query string `openapi:"query"`
+	// Filter is the user's filter, with "quotes" and 'apostrophes'.
+	//
+	// It spans paragraphs.
+	Filter string
+	Limit  int64 // Limit caps the number of users returned.
+	Page   int64
+}
+
+func TestOnRequest_descriptions(t *testing.T) {
+	spec := NewServiceSpec(&TestService1{})
+	state := &TestIdleState{}
+	spec.DefineStates(state).SetInitialState(state)
+	OnRequest(spec, state, HTTPMethodPost, "/users/search",
+		func(ctx context.Context, event *TestSearchRequest, sm *TestService1) Response[*TestResponse1] {
+			return SendTo(ctx, sm, &TestResponse1{})
+		},
+		WithDescription("Search finds users: sorted by name."))
+
+	definitions, err := newSchemaAnalyzer().analyzeSpecs(spec)
+	if err != nil {
+		t.Fatalf("analyzeSpecs() error = %v", err)
+	}
+	got := (&specWriter{title: "Test API", version: "1.0.0"}).generateFileContent(definitions)
+	want := `openapi: 3.0.0
+info:
+  title: Test API
+  version: 1.0.0
+
+paths:
+  /users/search:
+    post:
+      description: 'Search finds users: sorted by name.'
+      parameters:
+        - name: query
+          in: query
+          description: 'Query is matched against user names.'
+          required: false
+          schema:
+            type: string
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/TestSearchRequest'
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/TestResponse1'
+
+components:
+  schemas:
+    TestResponse1:
+      type: object
+      properties:
+        result:
+          type: string
+      required:
+        - result
+    TestSearchRequest:
+      type: object
+      description: 'TestSearchRequest asks for the users matching a query.'
+      properties:
+        filter:
+          type: string
+          description: 'Filter is the user''s filter, with "quotes" and ''apostrophes''. It spans paragraphs.'
+        limit:
+          type: integer
+          format: int64
+          description: 'Limit caps the number of users returned.'
+        page:
+          type: integer
+          format: int64
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("generateFileContent() mismatch (-want +got):\n%s", diff)
+	}
+
+	parsed, err := parseYAML(got)
+	if err != nil {
+		t.Fatalf("parseYAML() error = %v", err)
+	}
+	schema := yamlMap(yamlMap(yamlMap(parsed)["components"])["schemas"])["TestSearchRequest"]
+	filter := yamlMap(yamlMap(yamlMap(schema)["properties"])["filter"])
+	if want := `Filter is the user's filter, with "quotes" and 'apostrophes'. It spans paragraphs.`; filter["description"] != want {
+		t.Errorf("parsed description = %q, want %q", filter["description"], want)
+	}
+}
@@ -0,0 +1,293 @@
+package openapi
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// BreakingChange is a difference between a previously generated OpenAPI file
+// and the current specs that can break clients built against the file.
+type BreakingChange struct {
+	// Location names what changed, such as "GET /users/{userId}" or
+	// "CreateUserRequest.email".
+	Location string
+	// Description says how it changed.
+	Description string
+}
+
+func (c BreakingChange) String() string {
+	return c.Location + ": " + c.Description
+}
+
+// CheckCompatibility compares an OpenAPI file generated earlier with the one
+// Generate would write for specs now, and reports the changes that break
+// existing clients: removed endpoints, new required parameters, request
+// bodies or request properties, responses whose schema changed, response
+// properties that were removed or are no longer required, and parameters
+// and properties whose type changed.
+//
+// Parameters:
+//   - oldFile: Path of the previously generated OpenAPI YAML file
+//   - specs: The service specifications to generate now
+//
+// Returns the breaking changes, none when the specs are compatible, and an
+// error if the file cannot be read or parsed.
+//
+// Example:
+//
+//	changes, err := openapi.CheckCompatibility("openapi/openapi.yaml", spec)
+//	for _, c := range changes {
+//	    log.Printf("breaking change: %s", c)
+//	}
+func CheckCompatibility(oldFile string, specs ...AbstractServiceSpec) ([]BreakingChange, error) {
+	return CheckCompatibilityWithOptions(oldFile, &GenerateOptions{}, specs...)
+}
+
+// CheckCompatibilityWithOptions is CheckCompatibility for the file Generate
+// writes with opts.
+//
+// Example:
+//
+//	changes, err := openapi.CheckCompatibilityWithOptions("openapi/openapi.yaml", opts, spec)
+func CheckCompatibilityWithOptions(oldFile string, opts *GenerateOptions, specs ...AbstractServiceSpec) ([]BreakingChange, error) {
+	// #nosec G304 - oldFile is chosen by the caller
+	content, err := os.ReadFile(oldFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", oldFile, err)
+	}
+	old, err := parseYAML(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", oldFile, err)
+	}
+
+	// Compare with the file as it would be generated, so both sides have the
+	// same shape.
+	definitions, err := newSchemaAnalyzer().analyzeSpecs(specs...)
+	if err != nil {
+		return nil, err
+	}
+	writer := newSpecWriter(opts.OutputDir, opts.Title, opts.Version)
+	current, err := parseYAML(writer.generateFileContent(definitions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the generated file: %w", err)
+	}
+	return compareDocuments(yamlMap(old), yamlMap(current)), nil
+}
+
+// compareDocuments returns the changes from old to current that break
+// clients of old.
+func compareDocuments(old, current map[string]any) []BreakingChange {
+	c := &compatChecker{}
+	requestSchemas, responseSchemas := c.comparePaths(yamlMap(old["paths"]), yamlMap(current["paths"]))
+	c.compareSchemas(
+		yamlMap(yamlMap(old["components"])["schemas"]),
+		yamlMap(yamlMap(current["components"])["schemas"]),
+		requestSchemas, responseSchemas)
+	return c.changes
+}
+
+type compatChecker struct {
+	changes []BreakingChange
+}
+
+func (c *compatChecker) report(location, format string, args ...any) {
+	c.changes = append(c.changes, BreakingChange{Location: location, Description: fmt.Sprintf(format, args...)})
+}
+
+// comparePaths compares the operations of old and current, and returns the
+// names of the schemas old uses for requests and for responses.
+func (c *compatChecker) comparePaths(old, current map[string]any) (requests, responses map[string]bool) {
+	requests = make(map[string]bool)
+	responses = make(map[string]bool)
+	for _, path := range sortedKeys(old) {
+		oldOps := yamlMap(old[path])
+		ops := yamlMap(current[path])
+		for _, method := range sortedKeys(oldOps) {
+			location := strings.ToUpper(method) + " " + path
+			oldOp := yamlMap(oldOps[method])
+			if ref := schemaRef(requestBodySchema(oldOp)); ref != "" {
+				requests[ref] = true
+			}
+			for _, response := range yamlMap(oldOp["responses"]) {
+				if ref := schemaRef(responseSchema(response)); ref != "" {
+					responses[ref] = true
+				}
+			}
+
+			op, ok := ops[method]
+			if !ok {
+				c.report(location, "endpoint was removed")
+				continue
+			}
+			c.compareOperation(location, oldOp, yamlMap(op))
+		}
+	}
+	return requests, responses
+}
+
+func (c *compatChecker) compareOperation(location string, old, current map[string]any) {
+	oldParams := parametersByKey(old)
+	params := parametersByKey(current)
+	for _, key := range sortedKeys(params) {
+		param := params[key]
+		oldParam, existed := oldParams[key]
+		if param["required"] == "true" && (!existed || oldParam["required"] != "true") {
+			c.report(location, "%s parameter %s is now required", param["in"], param["name"])
+		}
+		if existed {
+			if before, after := schemaType(yamlMap(oldParam["schema"])), schemaType(yamlMap(param["schema"])); before != after {
+				c.report(location, "type of %s parameter %s changed from %s to %s", param["in"], param["name"], before, after)
+			}
+		}
+	}
+
+	oldBody := yamlMap(old["requestBody"])
+	body := yamlMap(current["requestBody"])
+	if body["required"] == "true" && oldBody["required"] != "true" {
+		c.report(location, "request body is now required")
+	}
+	if before, after := schemaRef(requestBodySchema(old)), schemaRef(requestBodySchema(current)); before != "" && after != "" && before != after {
+		c.report(location, "request body changed from %s to %s", before, after)
+	}
+
+	oldResponses := yamlMap(old["responses"])
+	responses := yamlMap(current["responses"])
+	for _, status := range sortedKeys(oldResponses) {
+		response, ok := responses[status]
+		if !ok {
+			continue
+		}
+		before, after := schemaRef(responseSchema(oldResponses[status])), schemaRef(responseSchema(response))
+		if before != after {
+			c.report(location, "response %s changed from %s to %s", status, before, after)
+		}
+	}
+}
+
+func (c *compatChecker) compareSchemas(old, current map[string]any, requests, responses map[string]bool) {
+	for _, name := range sortedKeys(old) {
+		oldSchema := yamlMap(old[name])
+		schema, ok := current[name]
+		if !ok {
+			c.report(name, "schema was removed")
+			continue
+		}
+		c.compareSchema(name, oldSchema, yamlMap(schema), requests[name], responses[name])
+	}
+}
+
+func (c *compatChecker) compareSchema(name string, old, current map[string]any, isRequest, isResponse bool) {
+	oldProps := yamlMap(old["properties"])
+	props := yamlMap(current["properties"])
+	oldRequired := yamlStrings(old["required"])
+	required := yamlStrings(current["required"])
+
+	for _, prop := range sortedKeys(oldProps) {
+		location := name + "." + prop
+		p, ok := props[prop]
+		if !ok {
+			if isResponse {
+				c.report(location, "property was removed")
+			}
+			continue
+		}
+		if before, after := schemaType(yamlMap(oldProps[prop])), schemaType(yamlMap(p)); before != after {
+			c.report(location, "type changed from %s to %s", before, after)
+		}
+		if isResponse && slices.Contains(oldRequired, prop) && !slices.Contains(required, prop) {
+			c.report(location, "property is no longer required")
+		}
+	}
+
+	if isRequest {
+		for _, prop := range required {
+			if !slices.Contains(oldRequired, prop) {
+				c.report(name+"."+prop, "property is now required")
+			}
+		}
+	}
+}
+
+// parametersByKey returns the parameters of an operation by "in name".
+func parametersByKey(op map[string]any) map[string]map[string]any {
+	params := make(map[string]map[string]any)
+	if list, ok := op["parameters"].([]any); ok {
+		for _, item := range list {
+			param := yamlMap(item)
+			params[fmt.Sprint(param["in"], " ", param["name"])] = param
+		}
+	}
+	return params
+}
+
+func requestBodySchema(op map[string]any) map[string]any {
+	return jsonSchema(yamlMap(op["requestBody"]))
+}
+
+func responseSchema(response any) map[string]any {
+	return jsonSchema(yamlMap(response))
+}
+
+// jsonSchema returns the application/json schema of a request body or
+// response.
+func jsonSchema(node map[string]any) map[string]any {
+	return yamlMap(yamlMap(yamlMap(node["content"])["application/json"])["schema"])
+}
+
+// schemaRef returns the name of the component a schema refers to, or "".
+func schemaRef(schema map[string]any) string {
+	ref, _ := schema["$ref"].(string)
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+// schemaType describes the type of a schema, such as "integer (int64)",
+// "array of string" or "map of Address".
+func schemaType(schema map[string]any) string {
+	if ref := schemaRef(schema); ref != "" {
+		return ref
+	}
+	// A nullable $ref is wrapped in allOf.
+	if allOf, ok := schema["allOf"].([]any); ok && len(allOf) == 1 {
+		return schemaType(yamlMap(allOf[0]))
+	}
+	t, _ := schema["type"].(string)
+	if t == "array" {
+		return "array of " + schemaType(yamlMap(schema["items"]))
+	}
+	if values, ok := schema["additionalProperties"]; ok {
+		return "map of " + schemaType(yamlMap(values))
+	}
+	if format, ok := schema["format"].(string); ok && format != "" {
+		return t + " (" + format + ")"
+	}
+	return t
+}
+
+// yamlMap returns node as a map, or an empty map when it is not one.
+func yamlMap(node any) map[string]any {
+	if m, ok := node.(map[string]any); ok {
+		return m
+	}
+	return map[string]any{}
+}
+
+func yamlStrings(node any) []string {
+	list, _ := node.([]any)
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		strs = append(strs, fmt.Sprint(item))
+	}
+	return strs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,263 @@
+package openapi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompareDocuments(t *testing.T) {
+	old := `paths:
+  /users:
+    post:
+      requestBody:
+        required: false
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/CreateUserRequest'
+      responses:
+        '201':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+  /users/{userId}:
+    get:
+      parameters:
+        - name: userId
+          in: path
+          required: true
+          schema:
+            type: string
+        - name: verbose
+          in: query
+          required: false
+          schema:
+            type: boolean
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+    delete:
+      responses:
+        '204':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+
+components:
+  schemas:
+    CreateUserRequest:
+      type: object
+      properties:
+        name:
+          type: string
+        nickname:
+          type: string
+      required:
+        - name
+    User:
+      type: object
+      properties:
+        id:
+          type: string
+        name:
+          type: string
+        age:
+          type: integer
+          format: int32
+        tags:
+          type: array
+          items:
+            type: string
+        address:
+          $ref: '#/components/schemas/Address'
+        scores:
+          type: object
+          additionalProperties:
+            type: integer
+      required:
+        - id
+        - name
+    Legacy:
+      type: object
+`
+	current := `paths:
+  /users:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/CreateUserRequest'
+      responses:
+        '201':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Profile'
+  /users/{userId}:
+    get:
+      parameters:
+        - name: userId
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+        - name: verbose
+          in: query
+          required: true
+          schema:
+            type: boolean
+        - name: X-Tenant
+          in: header
+          required: true
+          schema:
+            type: string
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+
+components:
+  schemas:
+    CreateUserRequest:
+      type: object
+      properties:
+        name:
+          type: string
+        nickname:
+          type: string
+        email:
+          type: string
+      required:
+        - name
+        - nickname
+        - email
+    User:
+      type: object
+      properties:
+        id:
+          type: string
+        age:
+          type: integer
+          format: int64
+        tags:
+          type: array
+          items:
+            type: integer
+        address:
+          allOf:
+            - $ref: '#/components/schemas/Address'
+          nullable: true
+        scores:
+          type: object
+          additionalProperties:
+            type: number
+      required:
+        - id
+        - name
+    Profile:
+      type: object
+`
+
+	oldDoc, err := parseYAML(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentDoc, err := parseYAML(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := compareDocuments(yamlMap(oldDoc), yamlMap(currentDoc))
+	want := []BreakingChange{
+		{Location: "POST /users", Description: "request body is now required"},
+		{Location: "POST /users", Description: "response 201 changed from User to Profile"},
+		{Location: "DELETE /users/{userId}", Description: "endpoint was removed"},
+		{Location: "GET /users/{userId}", Description: "header parameter X-Tenant is now required"},
+		{Location: "GET /users/{userId}", Description: "type of path parameter userId changed from string to integer (int64)"},
+		{Location: "GET /users/{userId}", Description: "query parameter verbose is now required"},
+		{Location: "CreateUserRequest.nickname", Description: "property is now required"},
+		{Location: "CreateUserRequest.email", Description: "property is now required"},
+		{Location: "Legacy", Description: "schema was removed"},
+		{Location: "User.age", Description: "type changed from integer (int32) to integer (int64)"},
+		{Location: "User.name", Description: "property was removed"},
+		{Location: "User.scores", Description: "type changed from map of integer to map of number"},
+		{Location: "User.tags", Description: "type changed from array of string to array of integer"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("compareDocuments() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	spec := NewServiceSpec(&TestService1{})
+	state := &TestIdleState{}
+	spec.DefineStates(state).SetInitialState(state)
+	OnRequest(spec, state, HTTPMethodPost, "/test",
+		func(ctx context.Context, event *TestRequest1, sm *TestService1) Response[*TestResponse1] {
+			return SendTo(ctx, sm, &TestResponse1{})
+		})
+
+	dir := t.TempDir()
+	opts := &GenerateOptions{OutputDir: dir, Title: "Test API"}
+	if err := Generate(opts, spec); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	changes, err := CheckCompatibility(filepath.Join(dir, "openapi.yaml"), spec)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("CheckCompatibility() = %v, want no changes", changes)
+	}
+	changes, err = CheckCompatibilityWithOptions(filepath.Join(dir, "openapi.yaml"), opts, spec)
+	if err != nil {
+		t.Fatalf("CheckCompatibilityWithOptions() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("CheckCompatibilityWithOptions() = %v, want no changes", changes)
+	}
+
+	old := `paths:
+  /test:
+    post:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/TestResponse1'
+  /removed:
+    get:
+      responses: {}
+`
+	oldPath := filepath.Join(dir, "old.yaml")
+	if err := os.WriteFile(oldPath, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = CheckCompatibility(oldPath, spec)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	want := []BreakingChange{
+		{Location: "GET /removed", Description: "endpoint was removed"},
+		{Location: "POST /test", Description: "request body is now required"},
+	}
+	if diff := cmp.Diff(want, changes); diff != "" {
+		t.Errorf("CheckCompatibility() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,55 @@
+package openapi
+
+import (
+	"reflect"
+
+	"github.com/goatx/goat/internal/enums"
+)
+
+type enumDefinition struct {
+	Type   string
+	Format string
+	Values []string
+}
+
+// EnumType is the set of Go types that can be registered with RegisterEnum.
+type EnumType = enums.Type
+
+// RegisterEnum declares the values of a named string or integer type, so that
+// schema fields of that type refer to a component schema listing them as an
+// enum instead of being a plain string or integer. Go has no way to list the
+// constants of a type at run time, which is why they must be passed here.
+//
+// The values are shared with protobuf.RegisterEnum, so a type registered
+// with either is an enum for both generators. RegisterEnum must be called
+// before OnRequest for the schemas using the type.
+//
+// Parameters:
+//   - values: Every value of the type
+//
+// Example:
+//
+//	type Role string
+//
+//	const (
+//	    RoleAdmin  Role = "admin"
+//	    RoleMember Role = "member"
+//	)
+//
+//	openapi.RegisterEnum(RoleAdmin, RoleMember)
+func RegisterEnum[E EnumType](values ...E) {
+	enums.Register(values...)
+}
+
+// lookupEnum returns the values registered for t, or nil.
+func lookupEnum(t reflect.Type) []string {
+	registered := enums.Lookup(t)
+	if registered == nil {
+		return nil
+	}
+	values := make([]string, 0, len(registered))
+	for _, v := range registered {
+		values = append(values, v.Literal)
+	}
+	return values
+}
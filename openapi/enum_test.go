@@ -0,0 +1,118 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type testRole string
+
+const (
+	testRoleAdmin  testRole = "admin"
+	testRoleMember testRole = "member"
+)
+
+type testPriority int32
+
+const (
+	testPriorityLow testPriority = iota + 1
+	testPriorityHigh
+)
+
+func (p testPriority) String() string {
+	return [...]string{"", "low", "high"}[p]
+}
+
+type testAddress struct {
+	City string
+}
+
+type TestTimestamps struct {
+	CreatedAt time.Time
+}
+
+type testNode struct {
+	Children []*testNode
+}
+
+type testProfileRequest struct {
+	Schema[*TestService1, *TestService1]
+	TestTimestamps
+	Role      testRole
+	Priority  testPriority
+	Address   testAddress
+	Previous  *testAddress
+	Homes     []testAddress
+	Scores    map[string]int64
+	ByCity    map[string][]*testAddress
+	Nickname  *string
+	Tree      testNode
+	Metadata  struct{ Source string }
+	Avatar    []byte
+	Unmatched chan int
+	Grid      [][]int64
+}
+
+func TestRegisterEnum(t *testing.T) {
+	RegisterEnum(testPriorityLow, testPriorityHigh)
+
+	got := lookupEnum(reflect.TypeFor[testPriority]())
+	if diff := cmp.Diff([]string{"1", "2"}, got); diff != "" {
+		t.Errorf("lookupEnum() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestComponentAnalyzer(t *testing.T) {
+	RegisterEnum(testRoleAdmin, testRoleMember)
+	RegisterEnum(testPriorityLow, testPriorityHigh)
+
+	analyzer := newComponentAnalyzer()
+	analyzer.analyzeStruct(reflect.TypeFor[testProfileRequest](), "")
+
+	want := map[string]*schemaDefinition{
+		"testProfileRequest": {
+			Name: "testProfileRequest",
+			Fields: []schemaField{
+				{Name: "createdAt", Type: "string", Format: "date-time"},
+				{Name: "role", Ref: "testRole"},
+				{Name: "priority", Ref: "testPriority"},
+				{Name: "address", Ref: "testAddress"},
+				{Name: "previous", Ref: "testAddress", Nullable: true},
+				{Name: "homes", Ref: "testAddress", IsArray: true},
+				{Name: "scores", Type: "object", Value: &schemaField{Type: "integer", Format: "int64"}},
+				{Name: "byCity", Type: "object", Value: &schemaField{Ref: "testAddress", IsArray: true}},
+				{Name: "nickname", Type: "string", Nullable: true},
+				{Name: "tree", Ref: "testNode"},
+				{Name: "metadata", Ref: "testProfileRequestMetadata"},
+				{Name: "avatar", Type: "string", Format: "byte"},
+			},
+		},
+		"testRole": {
+			Name: "testRole",
+			Enum: &enumDefinition{Type: "string", Values: []string{"admin", "member"}},
+		},
+		"testPriority": {
+			Name: "testPriority",
+			Enum: &enumDefinition{Type: "integer", Format: "int32", Values: []string{"1", "2"}},
+		},
+		"testAddress": {
+			Name:   "testAddress",
+			Fields: []schemaField{{Name: "city", Type: "string"}},
+		},
+		"testNode": {
+			Name:   "testNode",
+			Fields: []schemaField{{Name: "children", Ref: "testNode", IsArray: true}},
+		},
+		"testProfileRequestMetadata": {
+			Name:   "testProfileRequestMetadata",
+			Fields: []schemaField{{Name: "source", Type: "string"}},
+		},
+	}
+	if diff := cmp.Diff(want, analyzer.schemas, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("analyzer.schemas mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/goatx/goat"
 	"github.com/goatx/goat/openapi"
@@ -16,11 +17,25 @@ type Cliennt struct {
 	goat.StateMachine
 }
 
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+)
+
+type Address struct {
+	City    string `openapi:"required"`
+	Country string
+}
+
 type CreateUserRequest struct {
 	openapi.Schema[*Cliennt, *UserService]
 	Username string   `openapi:"required"`
 	Email    string   `openapi:"required"`
 	Tags     []string `openapi:"required"`
+	Role     Role
+	Address  *Address
 }
 
 type CreateUserResponse struct {
@@ -38,9 +53,13 @@ type GetUserRequest struct {
 
 type GetUserResponse struct {
 	openapi.Schema[*UserService, *Cliennt]
-	Username string `openapi:"required"`
-	Email    string `openapi:"required"`
-	Found    bool   `openapi:"required"`
+	Username  string `openapi:"required"`
+	Email     string `openapi:"required"`
+	Found     bool   `openapi:"required"`
+	Role      Role
+	Addresses []Address
+	Labels    map[string]string
+	CreatedAt time.Time
 }
 
 type GetUserNotFoundResponse struct {
@@ -61,6 +80,8 @@ type UserServiceState struct {
 }
 
 func createUserServiceModel() *openapi.ServiceSpec[*UserService] {
+	openapi.RegisterEnum(RoleAdmin, RoleMember)
+
 	spec := openapi.NewServiceSpec(&UserService{})
 	idleState := &UserServiceState{StateType: UserServiceIdle}
 	processingState := &UserServiceState{StateType: UserServiceProcessing}
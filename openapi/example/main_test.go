@@ -46,3 +46,13 @@ func TestUserServiceOpenAPIGeneration(t *testing.T) {
 		t.Errorf("Generated OpenAPI content mismatch.\nGot:\n%s\nWant:\n%s", got, want)
 	}
 }
+
+func TestUserServiceOpenAPICompatibility(t *testing.T) {
+	changes, err := openapi.CheckCompatibility(filepath.Join("openapi", "user_service.yaml.golden"), createUserServiceModel())
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("CheckCompatibility() = %v, want no breaking changes", changes)
+	}
+}
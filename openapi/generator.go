@@ -15,6 +15,9 @@ func newGenerator(opts GenerateOptions) *generator {
 }
 
 func (g *generator) generateFromSpecs(specs ...AbstractServiceSpec) error {
-	definitions := g.analyzer.analyzeSpecs(specs...)
+	definitions, err := g.analyzer.analyzeSpecs(specs...)
+	if err != nil {
+		return err
+	}
 	return g.writer.writeOpenAPIFile(g.opts.Filename, definitions)
 }
@@ -1,7 +1,13 @@
 package openapi
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/goatx/goat"
+	"github.com/goatx/goat/internal/godoc"
+	"github.com/goatx/goat/internal/typeutil"
+	"github.com/goatx/goat/internal/validate"
 )
 
 type StatusCode int
@@ -55,16 +61,43 @@ func (*Schema[Sender, Recipient]) isSchema() bool {
 	return true
 }
 
+func isSchemaType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.PkgPath() != "github.com/goatx/goat/openapi" {
+		return false
+	}
+
+	name := t.Name()
+	return strings.HasPrefix(name, "Schema[")
+}
+
 type AbstractServiceSpec interface {
 	isServiceSpec() bool
 	getEndpoints() []endpointMetadata
 	getSchemas() map[string]*schemaDefinition
+	getSchemaTypes() map[string]reflect.Type
+	getNameConflicts() []error
+	getFieldSources() map[string]map[string]fieldSource
+	getDocs() *godoc.Docs
 }
 
 type ServiceSpec[T goat.AbstractStateMachine] struct {
 	*goat.StateMachineSpec[T]
 	endpoints []endpointMetadata
 	schemas   map[string]*schemaDefinition
+	// schemaTypes maps schema names to the Go types analyzed as them.
+	schemaTypes map[string]reflect.Type
+	// nameConflicts lists the distinct Go types given the same schema name,
+	// reported by Generate.
+	nameConflicts []error
+	// fieldSources maps schema and field names to the Go fields they stand
+	// for.
+	fieldSources map[string]map[string]fieldSource
+	// docs looks up the doc comments Generate writes, from the packages
+	// registering endpoints.
+	docs godoc.Docs
 }
 
 func (*ServiceSpec[T]) isServiceSpec() bool {
@@ -79,6 +112,22 @@ func (os *ServiceSpec[T]) getSchemas() map[string]*schemaDefinition {
 	return os.schemas
 }
 
+func (os *ServiceSpec[T]) getSchemaTypes() map[string]reflect.Type {
+	return os.schemaTypes
+}
+
+func (os *ServiceSpec[T]) getNameConflicts() []error {
+	return os.nameConflicts
+}
+
+func (os *ServiceSpec[T]) getFieldSources() map[string]map[string]fieldSource {
+	return os.fieldSources
+}
+
+func (os *ServiceSpec[T]) getDocs() *godoc.Docs {
+	return &os.docs
+}
+
 func (os *ServiceSpec[T]) addEndpoint(metadata *endpointMetadata) {
 	os.endpoints = append(os.endpoints, *metadata)
 }
@@ -90,6 +139,28 @@ func (os *ServiceSpec[T]) addSchema(schema *schemaDefinition) {
 	os.schemas[schema.Name] = schema
 }
 
+// addAnalysis adds the schemas found by a componentAnalyzer.
+func (os *ServiceSpec[T]) addAnalysis(a *componentAnalyzer) {
+	for _, schema := range a.schemas {
+		os.addSchema(schema)
+	}
+	if os.schemaTypes == nil {
+		os.schemaTypes = make(map[string]reflect.Type)
+	}
+	for t, name := range a.names {
+		if other, ok := os.schemaTypes[name]; ok && other != t {
+			os.nameConflicts = append(os.nameConflicts, typeutil.NameConflict(name, other, t))
+		}
+		os.schemaTypes[name] = t
+	}
+	if os.fieldSources == nil {
+		os.fieldSources = make(map[string]map[string]fieldSource)
+	}
+	for name, sources := range a.fieldSources {
+		os.fieldSources[name] = sources
+	}
+}
+
 type endpointMetadata struct {
 	Path           string
 	Method         HTTPMethod
@@ -98,20 +169,47 @@ type endpointMetadata struct {
 	ResponseType   string
 	StatusCode     StatusCode
 	IsBodyOptional bool
+	Description    string
+}
+
+// fieldSource is the Go field a schema field stands for, whose doc comment
+// Generate writes as the description of the schema field.
+type fieldSource struct {
+	// owner is the struct type declaring the field, which is that of an
+	// embedded struct for a promoted field.
+	owner reflect.Type
+	name  string
 }
 
 type schemaDefinition struct {
 	Name   string
 	Fields []schemaField
+	// Description is the doc comment of the Go type.
+	Description string
+	// Enum holds the values of the schema of a registered enum, which has no
+	// fields.
+	Enum *enumDefinition
 }
 
+// schemaField is a property or parameter of a schema. Type, Format, Ref and
+// Value describe its items when it is an array.
 type schemaField struct {
-	Name      string
-	Type      string
-	Format    string
-	IsArray   bool
+	Name    string
+	Type    string
+	Format  string
+	IsArray bool
+	// Ref is the name of the component schema the field refers to, in place
+	// of Type.
+	Ref string
+	// Value describes the values of a map, whose Type is "object".
+	Value     *schemaField
+	Nullable  bool
 	Required  bool
 	ParamType parameterType
+	// Description is the doc comment of the Go field.
+	Description string
+	// Constraints are the constraints of the validate struct tag, if any.
+	Constraints *validate.Constraints
 }
 
 type parameterType string
@@ -0,0 +1,81 @@
+package openapi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/goatx/goat/internal/validate"
+)
+
+// validSchemasRule is the rule SendTo reports a violation of when a handler
+// sends a schema breaking its constraints.
+const validSchemasRule = "valid schemas"
+
+// writeConstraints writes the JSON Schema keywords standing for the
+// constraints of a field. The constraints of an array field are split between
+// the array, for its length, and its items, for the others.
+func writeConstraints(builder *strings.Builder, indent, itemIndent string, field schemaField) {
+	c := field.Constraints
+	if c == nil {
+		return
+	}
+	if field.IsArray {
+		writeItemConstraints(builder, itemIndent, c, field.Type)
+		writeLengthConstraints(builder, indent, c, "Items")
+		return
+	}
+	writeLengthConstraints(builder, indent, c, "Length")
+	writeItemConstraints(builder, indent, c, field.Type)
+}
+
+func writeLengthConstraints(builder *strings.Builder, indent string, c *validate.Constraints, unit string) {
+	if c.MinLen != nil {
+		writeKeyword(builder, indent, "min"+unit, strconv.Itoa(*c.MinLen))
+	}
+	if c.MaxLen != nil {
+		writeKeyword(builder, indent, "max"+unit, strconv.Itoa(*c.MaxLen))
+	}
+}
+
+func writeItemConstraints(builder *strings.Builder, indent string, c *validate.Constraints, typeName string) {
+	if c.Min != nil {
+		writeKeyword(builder, indent, "minimum", strconv.FormatFloat(*c.Min, 'f', -1, 64))
+	}
+	if c.Max != nil {
+		writeKeyword(builder, indent, "maximum", strconv.FormatFloat(*c.Max, 'f', -1, 64))
+	}
+	if c.Pattern != "" {
+		writeKeyword(builder, indent, "pattern", quoteYAML(c.Pattern))
+	}
+	if len(c.In) > 0 {
+		writeEnum(builder, indent, c.In, typeName)
+	}
+}
+
+// writeEnum writes an enum keyword, quoting the values of string types.
+func writeEnum(builder *strings.Builder, indent string, values []string, typeName string) {
+	builder.WriteString(indent)
+	builder.WriteString("enum:\n")
+	for _, v := range values {
+		if typeName == "string" {
+			v = quoteYAML(v)
+		}
+		builder.WriteString(indent)
+		builder.WriteString("  - ")
+		builder.WriteString(v)
+		builder.WriteString("\n")
+	}
+}
+
+func writeKeyword(builder *strings.Builder, indent, keyword, value string) {
+	builder.WriteString(indent)
+	builder.WriteString(keyword)
+	builder.WriteString(": ")
+	builder.WriteString(value)
+	builder.WriteString("\n")
+}
+
+// quoteYAML returns s as a single-quoted YAML string.
+func quoteYAML(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,161 @@
+package openapi
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/goatx/goat"
+	"github.com/goatx/goat/internal/validate"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type testJoinClient struct {
+	goat.StateMachine
+	Server *testJoinService
+}
+
+type testJoinService struct {
+	goat.StateMachine
+}
+
+type testJoinRequest struct {
+	Schema[*testJoinClient, *testJoinService]
+	RoomID int64    `openapi:"path=room_id" validate:"min=1"`
+	Name   string   `validate:"required,maxlen=20,pattern=^[a-z']+$"`
+	Kind   string   `validate:"in=public|private"`
+	Scores []int32  `validate:"maxlen=3,min=0,max=100"`
+	Labels []string `validate:"minlen=1,in=a|b"`
+}
+
+type testJoinResponse struct {
+	Schema[*testJoinService, *testJoinClient]
+	Seat int32 `validate:"min=1,max=10"`
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestAnalyzeSchema_validation(t *testing.T) {
+	got := analyzeSchema(&testJoinRequest{})
+
+	want := &schemaDefinition{
+		Name: "testJoinRequest",
+		Fields: []schemaField{
+			{Name: "room_id", Type: "integer", Format: "int64", Required: true, ParamType: parameterTypePath, Constraints: &validate.Constraints{Min: ptr(1.0)}},
+			{Name: "name", Type: "string", Required: true, Constraints: &validate.Constraints{Required: true, MaxLen: ptr(20), Pattern: "^[a-z']+$"}},
+			{Name: "kind", Type: "string", Constraints: &validate.Constraints{In: []string{"public", "private"}}},
+			{Name: "scores", Type: "integer", Format: "int32", IsArray: true, Constraints: &validate.Constraints{MaxLen: ptr(3), Min: ptr(0.0), Max: ptr(100.0)}},
+			{Name: "labels", Type: "string", IsArray: true, Constraints: &validate.Constraints{MinLen: ptr(1), In: []string{"a", "b"}}},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(validate.Constraints{})); diff != "" {
+		t.Errorf("analyzeSchema() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSpecWriter_validation(t *testing.T) {
+	schema := analyzeSchema(&testJoinRequest{})
+
+	var parameters, properties strings.Builder
+	w := &specWriter{}
+	w.writeParameters(&parameters, schema)
+	w.writeSchema(&properties, schema)
+
+	want := `      parameters:
+        - name: room_id
+          in: path
+          required: true
+          schema:
+            type: integer
+            format: int64
+            minimum: 1
+`
+	if diff := cmp.Diff(want, parameters.String()); diff != "" {
+		t.Errorf("writeParameters() mismatch (-want +got):\n%s", diff)
+	}
+
+	want = `    testJoinRequest:
+      type: object
+      properties:
+        name:
+          type: string
+          maxLength: 20
+          pattern: '^[a-z'']+$'
+        kind:
+          type: string
+          enum:
+            - 'public'
+            - 'private'
+        scores:
+          type: array
+          items:
+            type: integer
+            format: int32
+            minimum: 0
+            maximum: 100
+          maxItems: 3
+        labels:
+          type: array
+          items:
+            type: string
+            enum:
+              - 'a'
+              - 'b'
+          minItems: 1
+      required:
+        - name
+`
+	if diff := cmp.Diff(want, properties.String()); diff != "" {
+		t.Errorf("writeSchema() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := parseYAML(properties.String()); err != nil {
+		t.Errorf("parseYAML() error = %v", err)
+	}
+}
+
+func TestSendTo_validation(t *testing.T) {
+	serviceSpec := NewServiceSpec(&testJoinService{})
+	serviceState := &TestIdleState{}
+	serviceSpec.DefineStates(serviceState).SetInitialState(serviceState)
+	OnRequest(serviceSpec, serviceState, HTTPMethodPost, "/rooms/{room_id}/join",
+		func(ctx context.Context, req *testJoinRequest, s *testJoinService) Response[*testJoinResponse] {
+			// The service hands out a seat past the last one.
+			return SendTo(ctx, req.Sender(), &testJoinResponse{Seat: 11})
+		})
+
+	clientSpec := goat.NewStateMachineSpec(&testJoinClient{})
+	clientState := &TestIdleState{}
+	clientSpec.DefineStates(clientState).SetInitialState(clientState)
+	goat.OnEntry(clientSpec, clientState, func(ctx context.Context, c *testJoinClient) {
+		goat.SendTo(ctx, c.Server, &testJoinRequest{RoomID: 1, Name: "alice"})
+	})
+
+	service, err := serviceSpec.NewInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := clientSpec.NewInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Server = service
+
+	result, err := goat.Test(
+		goat.WithStateMachines(client, service),
+		goat.WithReporter(goat.NewQuietReporter()),
+	)
+	if err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+
+	var violated []string
+	for _, v := range result.Violations {
+		violated = append(violated, v.Rule)
+	}
+	if diff := cmp.Diff([]string{"Always valid schemas"}, violated); diff != "" {
+		t.Errorf("violated rules mismatch (-want +got):\n%s", diff)
+	}
+}
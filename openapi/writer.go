@@ -81,6 +81,8 @@ func (w *specWriter) writePath(builder *strings.Builder, path *pathDefinition) {
 			builder.WriteString("\n")
 		}
 
+		writeDescription(builder, "      ", operation.Description)
+
 		w.writeParameters(builder, operation.RequestSchema)
 
 		bodyFields := make([]schemaField, 0)
@@ -125,6 +127,11 @@ func (w *specWriter) writePath(builder *strings.Builder, path *pathDefinition) {
 }
 
 func (*specWriter) writeSchema(builder *strings.Builder, schema *schemaDefinition) {
+	if schema.Enum != nil {
+		writeEnumSchema(builder, schema)
+		return
+	}
+
 	bodyFields := make([]schemaField, 0)
 	for _, field := range schema.Fields {
 		if field.ParamType == parameterTypeNone {
@@ -140,33 +147,15 @@ func (*specWriter) writeSchema(builder *strings.Builder, schema *schemaDefinitio
 	builder.WriteString(schema.Name)
 	builder.WriteString(":\n")
 	builder.WriteString("      type: object\n")
+	writeDescription(builder, "      ", schema.Description)
 	builder.WriteString("      properties:\n")
 	for _, field := range bodyFields {
 		builder.WriteString("        ")
 		builder.WriteString(field.Name)
 		builder.WriteString(":\n")
-
-		if field.IsArray {
-			builder.WriteString("          type: array\n")
-			builder.WriteString("          items:\n")
-			builder.WriteString("            type: ")
-			builder.WriteString(field.Type)
-			builder.WriteString("\n")
-			if field.Format != "" {
-				builder.WriteString("            format: ")
-				builder.WriteString(field.Format)
-				builder.WriteString("\n")
-			}
-		} else {
-			builder.WriteString("          type: ")
-			builder.WriteString(field.Type)
-			builder.WriteString("\n")
-			if field.Format != "" {
-				builder.WriteString("          format: ")
-				builder.WriteString(field.Format)
-				builder.WriteString("\n")
-			}
-		}
+		writeFieldType(builder, "          ", field)
+		writeConstraints(builder, "          ", "            ", field)
+		writeDescription(builder, "          ", field.Description)
 	}
 
 	requiredFields := make([]string, 0)
@@ -213,6 +202,7 @@ func (*specWriter) writeParameters(builder *strings.Builder, requestSchema *sche
 		builder.WriteString("          in: ")
 		builder.WriteString(field.ParamType.String())
 		builder.WriteString("\n")
+		writeDescription(builder, "          ", field.Description)
 		builder.WriteString("          required: ")
 		if required {
 			builder.WriteString("true")
@@ -221,15 +211,76 @@ func (*specWriter) writeParameters(builder *strings.Builder, requestSchema *sche
 		}
 		builder.WriteString("\n")
 		builder.WriteString("          schema:\n")
-		builder.WriteString("            type: ")
-		builder.WriteString(field.Type)
-		builder.WriteString("\n")
+		writeFieldType(builder, "            ", field)
+		writeConstraints(builder, "            ", "            ", field)
+	}
+}
+
+func writeEnumSchema(builder *strings.Builder, schema *schemaDefinition) {
+	builder.WriteString("    ")
+	builder.WriteString(schema.Name)
+	builder.WriteString(":\n")
+	writeKeyword(builder, "      ", "type", schema.Enum.Type)
+	if schema.Enum.Format != "" {
+		writeKeyword(builder, "      ", "format", schema.Enum.Format)
+	}
+	writeDescription(builder, "      ", schema.Description)
+	writeEnum(builder, "      ", schema.Enum.Values, schema.Enum.Type)
+}
+
+// writeFieldType writes the type of a field: its type and format, a $ref to
+// the component schema it refers to, the items of an array and the
+// additionalProperties of a map. A nullable $ref is wrapped in allOf, as
+// OpenAPI 3.0 ignores the keywords next to $ref.
+func writeFieldType(builder *strings.Builder, indent string, field schemaField) {
+	if field.IsArray {
+		writeKeyword(builder, indent, "type", "array")
+		if field.Nullable {
+			writeKeyword(builder, indent, "nullable", "true")
+		}
+		builder.WriteString(indent)
+		builder.WriteString("items:\n")
+		item := field
+		item.IsArray, item.Nullable = false, false
+		writeFieldType(builder, indent+"  ", item)
+		return
+	}
+
+	ref := quoteYAML("#/components/schemas/" + field.Ref)
+	switch {
+	case field.Ref != "" && field.Nullable:
+		builder.WriteString(indent)
+		builder.WriteString("allOf:\n")
+		writeKeyword(builder, indent+"  - ", "$ref", ref)
+	case field.Ref != "":
+		writeKeyword(builder, indent, "$ref", ref)
+		return
+	default:
+		writeKeyword(builder, indent, "type", field.Type)
 		if field.Format != "" {
-			builder.WriteString("            format: ")
-			builder.WriteString(field.Format)
-			builder.WriteString("\n")
+			writeKeyword(builder, indent, "format", field.Format)
+		}
+		if field.Value != nil {
+			builder.WriteString(indent)
+			builder.WriteString("additionalProperties:\n")
+			writeFieldType(builder, indent+"  ", *field.Value)
 		}
 	}
+	if field.Nullable {
+		writeKeyword(builder, indent, "nullable", "true")
+	}
+}
+
+// writeDescription writes a description key, unless description is empty.
+// The description is written on one line, as a single-quoted string.
+func writeDescription(builder *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	builder.WriteString(indent)
+	builder.WriteString("description: ")
+	builder.WriteString(quoteYAML(strings.Join(strings.Fields(description), " ")))
+	builder.WriteString("\n")
 }
 
 func descriptionForStatus(code StatusCode) string {
@@ -242,6 +242,69 @@ func TestSpecWriter_writeSchema(t *testing.T) {
         traceID:
           type: string
           format: uuid
+`,
+		},
+		{
+			name: "writes references, maps and nullable fields",
+			schema: &schemaDefinition{
+				Name: "User",
+				Fields: []schemaField{
+					{Name: "address", Ref: "Address", Required: true},
+					{Name: "previous", Ref: "Address", Nullable: true},
+					{Name: "addresses", Ref: "Address", IsArray: true},
+					{Name: "scores", Type: "object", Value: &schemaField{Type: "integer", Format: "int64"}},
+					{Name: "homes", Type: "object", Value: &schemaField{Ref: "Address", IsArray: true}},
+					{Name: "nickname", Type: "string", Nullable: true},
+					{Name: "createdAt", Type: "string", Format: "date-time"},
+				},
+			},
+			want: `    User:
+      type: object
+      properties:
+        address:
+          $ref: '#/components/schemas/Address'
+        previous:
+          allOf:
+            - $ref: '#/components/schemas/Address'
+          nullable: true
+        addresses:
+          type: array
+          items:
+            $ref: '#/components/schemas/Address'
+        scores:
+          type: object
+          additionalProperties:
+            type: integer
+            format: int64
+        homes:
+          type: object
+          additionalProperties:
+            type: array
+            items:
+              $ref: '#/components/schemas/Address'
+        nickname:
+          type: string
+          nullable: true
+        createdAt:
+          type: string
+          format: date-time
+      required:
+        - address
+`,
+		},
+		{
+			name: "writes enums",
+			schema: &schemaDefinition{
+				Name:        "Role",
+				Description: "Role is what a user may do.",
+				Enum:        &enumDefinition{Type: "string", Values: []string{"admin", "member"}},
+			},
+			want: `    Role:
+      type: string
+      description: 'Role is what a user may do.'
+      enum:
+        - 'admin'
+        - 'member'
 `,
 		},
 		{
@@ -0,0 +1,162 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// yamlLine is a line of a YAML document without its indentation.
+type yamlLine struct {
+	indent int
+	text   string
+	number int
+}
+
+// parseYAML parses the block-style YAML written by Generate into maps of
+// type map[string]any, lists of type []any and string scalars. Flow style
+// is only supported for empty lists and maps, anchors and multi-line
+// scalars not at all.
+func parseYAML(content string) (any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(content, "\n") {
+		text := strings.TrimLeft(raw, " ")
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		lines = append(lines, yamlLine{indent: len(raw) - len(text), text: strings.TrimRight(text, " \t\r"), number: i + 1})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	node, err := p.parseNode(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].number)
+	}
+	return node, nil
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) parseNode(indent int) (any, error) {
+	if strings.HasPrefix(p.lines[p.pos].text, "- ") || p.lines[p.pos].text == "-" {
+		return p.parseList(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *yamlParser) parseList(indent int) ([]any, error) {
+	list := []any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && strings.HasPrefix(p.lines[p.pos].text, "-") {
+		line := p.lines[p.pos]
+		item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		switch {
+		case item == "":
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				node, err := p.parseNode(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, node)
+			} else {
+				list = append(list, nil)
+			}
+		case isYAMLMapEntry(item):
+			// A map starting on the line of the dash continues at the
+			// indentation of its first key.
+			itemIndent := indent + len(line.text) - len(item)
+			p.lines[p.pos] = yamlLine{indent: itemIndent, text: item, number: line.number}
+			node, err := p.parseMap(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, node)
+		default:
+			list = append(list, yamlScalar(item))
+			p.pos++
+		}
+	}
+	return list, nil
+}
+
+func (p *yamlParser) parseMap(indent int) (map[string]any, error) {
+	m := map[string]any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		if !isYAMLMapEntry(line.text) {
+			return nil, fmt.Errorf("line %d: expected a key, found %q", line.number, line.text)
+		}
+		key, value := splitYAMLMapEntry(line.text)
+		p.pos++
+		if value != "" {
+			m[key] = yamlScalar(value)
+			continue
+		}
+		if p.pos < len(p.lines) && (p.lines[p.pos].indent > indent ||
+			(p.lines[p.pos].indent == indent && strings.HasPrefix(p.lines[p.pos].text, "- "))) {
+			node, err := p.parseNode(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = node
+			continue
+		}
+		m[key] = nil
+	}
+	return m, nil
+}
+
+// isYAMLMapEntry reports whether text is a "key: value" or "key:" line.
+func isYAMLMapEntry(text string) bool {
+	key, _ := splitYAMLMapEntry(text)
+	return key != ""
+}
+
+func splitYAMLMapEntry(text string) (string, string) {
+	rest := text
+	offset := 0
+	// A quoted key may contain ": ".
+	if strings.HasPrefix(text, "'") || strings.HasPrefix(text, "\"") {
+		if end := strings.IndexByte(text[1:], text[0]); end >= 0 {
+			offset = end + 2
+			rest = text[offset:]
+		}
+	}
+	if strings.HasSuffix(rest, ":") && !strings.Contains(rest, ": ") {
+		return unquoteYAML(text[:len(text)-1]), ""
+	}
+	if i := strings.Index(rest, ": "); i >= 0 {
+		return unquoteYAML(text[:offset+i]), strings.TrimSpace(text[offset+i+2:])
+	}
+	return "", ""
+}
+
+func yamlScalar(value string) any {
+	switch value {
+	case "[]":
+		return []any{}
+	case "{}":
+		return map[string]any{}
+	}
+	return unquoteYAML(value)
+}
+
+func unquoteYAML(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		if s[0] == '\'' {
+			return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseYAML(t *testing.T) {
+	content := `openapi: 3.0.0
+info:
+  title: 'Test: API'
+  version: 1.0.0
+
+paths:
+  /users/{userId}:
+    get:
+      parameters:
+        - name: userId
+          in: path
+          schema:
+            type: string
+        - plain
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+# a comment
+components:
+  schemas:
+    User:
+      required:
+      - name
+      tags: []
+`
+
+	got, err := parseYAML(content)
+	if err != nil {
+		t.Fatalf("parseYAML() error = %v", err)
+	}
+
+	want := map[string]any{
+		"openapi": "3.0.0",
+		"info": map[string]any{
+			"title":   "Test: API",
+			"version": "1.0.0",
+		},
+		"paths": map[string]any{
+			"/users/{userId}": map[string]any{
+				"get": map[string]any{
+					"parameters": []any{
+						map[string]any{
+							"name":   "userId",
+							"in":     "path",
+							"schema": map[string]any{"type": "string"},
+						},
+						"plain",
+					},
+					"responses": map[string]any{
+						"200": map[string]any{
+							"content": map[string]any{
+								"application/json": map[string]any{
+									"schema": map[string]any{"$ref": "#/components/schemas/User"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"components": map[string]any{
+			"schemas": map[string]any{
+				"User": map[string]any{
+					"required": []any{"name"},
+					"tags":     []any{},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseYAML() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseYAML_errors(t *testing.T) {
+	_, err := parseYAML("paths:\n  /users:\n    get:\n  unexpected\n")
+	want := `line 4: expected a key, found "unexpected"`
+	if err == nil || err.Error() != want {
+		t.Errorf("parseYAML() error = %v, want %q", err, want)
+	}
+}
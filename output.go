@@ -174,17 +174,42 @@ func writeWorldSequence(sb *strings.Builder, snapshots []WorldSnapshot, annotate
 			sb.WriteString(", State: ")
 			sb.WriteString(sm.State)
 			sb.WriteString("\n")
+			for _, r := range sm.Regions {
+				sb.WriteString("      Region: ")
+				sb.WriteString(r.Name)
+				sb.WriteString(", State: ")
+				sb.WriteString(r.State)
+				sb.WriteString("\n")
+			}
 		}
 		sb.WriteString("  QueuedEvents:\n")
 		for _, ev := range snap.QueuedEvents {
 			sb.WriteString("    StateMachine: ")
 			sb.WriteString(ev.TargetMachine)
+			if ev.TargetRegion != "" {
+				sb.WriteString(", Region: ")
+				sb.WriteString(ev.TargetRegion)
+			}
 			sb.WriteString(", Event: ")
 			sb.WriteString(ev.EventName)
 			sb.WriteString(", Detail: ")
 			sb.WriteString(ev.Details)
+			if ev.Group != "" {
+				sb.WriteString(", Group: ")
+				sb.WriteString(ev.Group)
+			}
 			sb.WriteString("\n")
 		}
+		if len(snap.SharedVars) > 0 {
+			sb.WriteString("  SharedVars:\n")
+			for _, v := range snap.SharedVars {
+				sb.WriteString("    Name: ")
+				sb.WriteString(v.Name)
+				sb.WriteString(", Detail: ")
+				sb.WriteString(v.Details)
+				sb.WriteString("\n")
+			}
+		}
 	}
 }
 
@@ -193,15 +218,21 @@ type invariantViolationWitness struct {
 	condition ConditionName
 }
 
-func (m *model) collectInvariantViolations() []invariantViolationWitness {
-	var violations []invariantViolationWitness
-
+// failedInvariantNames returns the names of the invariants some world fails.
+func (m *model) failedInvariantNames() map[string]struct{} {
 	targets := make(map[string]struct{})
 	for _, world := range m.worlds {
 		for _, name := range world.failedInvariants {
 			targets[name.String()] = struct{}{}
 		}
 	}
+	return targets
+}
+
+func (m *model) collectInvariantViolations() []invariantViolationWitness {
+	var violations []invariantViolationWitness
+
+	targets := m.failedInvariantNames()
 
 	totalTargets := len(targets)
 	if totalTargets == 0 {
@@ -276,24 +307,40 @@ func (w world) label() string {
 	for _, name := range smIDs {
 		sm := w.env.machines[name]
 		strs = append(strs, fmt.Sprintf("%s = %s; State: %s", getStateMachineName(sm), getStateMachineDetails(sm), getStateDetails(sm.currentState())))
+		for _, r := range getInnerStateMachine(sm).regions {
+			strs = append(strs, fmt.Sprintf("%s#%s State: %s", getStateMachineName(sm), r.name, getStateDetails(r.state)))
+		}
 	}
 
 	strs = append(strs, "\nQueuedEvents:")
 	smIDs = make([]string, 0)
-	for smID := range w.env.queue {
+	for smID := range w.env.machines {
 		smIDs = append(smIDs, smID)
 	}
 	sort.Strings(smIDs)
 	for _, smID := range smIDs {
-		for _, e := range w.env.queue[smID] {
-			sm := w.env.machines[smID]
-			if getEventDetails(e) == noFieldsMessage {
-				strs = append(strs, fmt.Sprintf("%s << %s;", getStateMachineName(sm), getEventName(e)))
-			} else {
-				strs = append(strs, fmt.Sprintf("%s << %s; %s", getStateMachineName(sm), getEventName(e), getEventDetails(e)))
+		sm := w.env.machines[smID]
+		for _, region := range regionNames(sm) {
+			target := getStateMachineName(sm)
+			if region != "" {
+				target += "#" + region
+			}
+			for _, e := range w.env.queue[queueID(smID, region)] {
+				if getEventDetails(e) == noFieldsMessage {
+					strs = append(strs, fmt.Sprintf("%s << %s;", target, getEventName(e)))
+				} else {
+					strs = append(strs, fmt.Sprintf("%s << %s; %s", target, getEventName(e), getEventDetails(e)))
+				}
 			}
 		}
 	}
+
+	if len(w.env.vars) > 0 {
+		strs = append(strs, "\nSharedVars:")
+		for _, name := range sharedVarNames(w.env.vars) {
+			strs = append(strs, fmt.Sprintf("%s = %s", name, getSharedVarDetails(w.env.vars[name])))
+		}
+	}
 	return strings.Join(strs, "\n")
 }
 
@@ -301,19 +348,33 @@ type worldJSON struct {
 	InvariantViolation bool               `json:"invariant_violation"`
 	StateMachines      []stateMachineJSON `json:"state_machines"`
 	QueuedEvents       []eventJSON        `json:"queued_events"`
+	SharedVars         []sharedVarJSON    `json:"shared_vars,omitempty"`
 }
 
-type stateMachineJSON struct {
-	ID      string `json:"id"`
+type sharedVarJSON struct {
 	Name    string `json:"name"`
-	State   string `json:"state"`
 	Details string `json:"details"`
 }
 
+type stateMachineJSON struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	State   string       `json:"state"`
+	Details string       `json:"details"`
+	Regions []regionJSON `json:"regions,omitempty"`
+}
+
+type regionJSON struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
 type eventJSON struct {
 	TargetMachine string `json:"target_machine"`
+	TargetRegion  string `json:"target_region,omitempty"`
 	EventName     string `json:"event_name"`
 	Details       string `json:"details"`
+	Group         string `json:"group,omitempty"`
 }
 
 func (m *model) worldsToJSON() []worldJSON {
@@ -361,7 +422,16 @@ func compareWorlds(a, b worldJSON) bool {
 			return a.QueuedEvents[i].Details < b.QueuedEvents[i].Details
 		}
 	}
-	return len(a.QueuedEvents) < len(b.QueuedEvents)
+	if len(a.QueuedEvents) != len(b.QueuedEvents) {
+		return len(a.QueuedEvents) < len(b.QueuedEvents)
+	}
+
+	for i := 0; i < len(a.SharedVars) && i < len(b.SharedVars); i++ {
+		if a.SharedVars[i].Details != b.SharedVars[i].Details {
+			return a.SharedVars[i].Details < b.SharedVars[i].Details
+		}
+	}
+	return len(a.SharedVars) < len(b.SharedVars)
 }
 
 func (*model) worldToJSON(w world) worldJSON {
@@ -374,22 +444,32 @@ func (*model) worldToJSON(w world) worldJSON {
 	stateMachines := make([]stateMachineJSON, 0, len(smIDs))
 	for _, smID := range smIDs {
 		sm := w.env.machines[smID]
+		var regions []regionJSON
+		for _, r := range getInnerStateMachine(sm).regions {
+			regions = append(regions, regionJSON{
+				Name:  r.name,
+				State: getStateDetails(r.state),
+			})
+		}
 		stateMachines = append(stateMachines, stateMachineJSON{
 			ID:      smID,
 			Name:    getStateMachineName(sm),
 			State:   getStateDetails(sm.currentState()),
 			Details: getStateMachineDetails(sm),
+			Regions: regions,
 		})
 	}
 
 	queuedEvents := make([]eventJSON, 0)
 	for _, smID := range smIDs {
-		if events, ok := w.env.queue[smID]; ok {
-			for _, event := range events {
+		for _, region := range regionNames(w.env.machines[smID]) {
+			for _, event := range w.env.queue[queueID(smID, region)] {
 				queuedEvents = append(queuedEvents, eventJSON{
 					TargetMachine: getStateMachineName(w.env.machines[smID]),
+					TargetRegion:  region,
 					EventName:     getEventName(event),
 					Details:       getEventDetails(event),
+					Group:         event.groupName(),
 				})
 			}
 		}
@@ -399,16 +479,28 @@ func (*model) worldToJSON(w world) worldJSON {
 		if queuedEvents[i].TargetMachine != queuedEvents[j].TargetMachine {
 			return queuedEvents[i].TargetMachine < queuedEvents[j].TargetMachine
 		}
+		if queuedEvents[i].TargetRegion != queuedEvents[j].TargetRegion {
+			return queuedEvents[i].TargetRegion < queuedEvents[j].TargetRegion
+		}
 		if queuedEvents[i].EventName != queuedEvents[j].EventName {
 			return queuedEvents[i].EventName < queuedEvents[j].EventName
 		}
 		return queuedEvents[i].Details < queuedEvents[j].Details
 	})
 
+	var sharedVars []sharedVarJSON
+	for _, name := range sharedVarNames(w.env.vars) {
+		sharedVars = append(sharedVars, sharedVarJSON{
+			Name:    name,
+			Details: getSharedVarDetails(w.env.vars[name]),
+		})
+	}
+
 	return worldJSON{
 		InvariantViolation: len(w.failedInvariants) > 0,
 		StateMachines:      stateMachines,
 		QueuedEvents:       queuedEvents,
+		SharedVars:         sharedVars,
 	}
 }
 
@@ -424,6 +516,7 @@ var (
 	abstractStateMachineType = reflect.TypeOf((*AbstractStateMachine)(nil)).Elem()
 	abstractEventType        = reflect.TypeOf((*AbstractEvent)(nil)).Elem()
 	abstractStateType        = reflect.TypeOf((*AbstractState)(nil)).Elem()
+	machineGroupType         = reflect.TypeOf(MachineGroup{})
 )
 
 func isGoatInterface(t reflect.Type) bool {
@@ -455,7 +548,7 @@ func warnShallowPointerFields(w io.Writer, sms []AbstractStateMachine) {
 			}
 			switch f.Type.Kind() {
 			case reflect.Ptr:
-				if isGoatInterface(f.Type) || isGoatInterface(f.Type.Elem()) {
+				if isGoatInterface(f.Type) || isGoatInterface(f.Type.Elem()) || f.Type.Elem() == machineGroupType {
 					continue
 				}
 				_, _ = fmt.Fprintf(w, "WARNING: type %q has pointer field %q (%s) which will be shared between states during model checking, potentially causing incorrect results. Consider using a value type instead.\n",
@@ -743,6 +743,11 @@ type smWithDomainStructPointer struct {
 	Info *myDomainStruct
 }
 
+type smWithGroup struct {
+	StateMachine
+	Peers *MachineGroup
+}
+
 type innerWithPointer struct {
 	Ptr *int
 }
@@ -771,6 +776,19 @@ func TestWarnShallowPointerFields(t *testing.T) {
 			},
 			want: "",
 		},
+		{
+			name: "MachineGroup pointer field is allowed",
+			setup: func() []AbstractStateMachine {
+				spec := NewStateMachineSpec(&smWithGroup{})
+				spec.DefineStates(newTestState("s")).SetInitialState(newTestState("s"))
+				sm, err := spec.NewInstance()
+				if err != nil {
+					panic(err)
+				}
+				return []AbstractStateMachine{sm}
+			},
+			want: "",
+		},
 		{
 			name: "domain pointer field warns",
 			setup: func() []AbstractStateMachine {
@@ -0,0 +1,179 @@
+package goat
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+)
+
+// Progress describes how far a model checking run has got.
+type Progress struct {
+	// Phase is "explore" while worlds are explored and "temporal" while
+	// temporal rules are checked against them.
+	Phase string
+	// Worlds is the number of distinct worlds found so far.
+	Worlds int
+	// Frontier is the number of worlds found but not explored yet. It is
+	// only set in the explore phase.
+	Frontier int
+	// Depth is the number of steps from the initial world to the deepest
+	// world explored so far, along the path exploration found it by. It is
+	// only set in the explore phase.
+	Depth int
+	// Rules and RulesChecked are the number of temporal rules and how many
+	// of them have been checked. They are only set in the temporal phase.
+	Rules        int
+	RulesChecked int
+	// ProductNodes is the number of nodes of the product of the worlds with
+	// the automaton of the rule being checked found so far. It is only set
+	// in the periodic reports of the temporal phase.
+	ProductNodes int
+	// Violations is the number of violated rules found so far.
+	Violations int
+	// Rate is the number of worlds found per second in the explore phase.
+	Rate float64
+	// MemoryBytes estimates the memory in use, as the bytes allocated on
+	// the heap.
+	MemoryBytes uint64
+	// Elapsed is the time spent in the phase so far.
+	Elapsed time.Duration
+	// Done reports that the phase is finished.
+	Done bool
+}
+
+// Phases reported in Progress.Phase.
+const (
+	PhaseExplore  = "explore"
+	PhaseTemporal = "temporal"
+)
+
+// String returns a one-line summary of the progress, such as
+// "explore: 1200 worlds, frontier 35, depth 12, 0 violations, 34000 worlds/s, 12.3 MiB, 35ms".
+func (p Progress) String() string {
+	text := fmt.Sprintf("%s: %d worlds", p.Phase, p.Worlds)
+	if p.Phase == PhaseTemporal {
+		text += fmt.Sprintf(", %d/%d rules", p.RulesChecked, p.Rules)
+		if p.ProductNodes > 0 {
+			text += fmt.Sprintf(", %d product nodes", p.ProductNodes)
+		}
+	} else {
+		text += fmt.Sprintf(", frontier %d, depth %d", p.Frontier, p.Depth)
+	}
+	text += fmt.Sprintf(", %d violations", p.Violations)
+	if p.Phase == PhaseExplore {
+		text += fmt.Sprintf(", %.0f worlds/s", p.Rate)
+	}
+	text += fmt.Sprintf(", %.1f MiB, %s", float64(p.MemoryBytes)/(1<<20), p.Elapsed.Round(time.Millisecond))
+	if p.Done {
+		text += " (done)"
+	}
+	return text
+}
+
+// defaultProgressInterval is the time between two progress reports of a
+// phase.
+const defaultProgressInterval = 500 * time.Millisecond
+
+// WithProgress makes model checking call fn periodically while it explores
+// worlds and checks temporal rules, and once more at the end of each phase
+// with Done set. fn is called from the goroutine running model checking, in
+// addition to the Progress method of the Reporter.
+//
+// Parameters:
+//   - fn: Function receiving the progress, such as TerminalProgress(os.Stderr)
+//
+// Returns an Option that can be passed to Test(), Debug() or WriteDot().
+//
+// Example:
+//
+//	goat.WithProgress(func(p goat.Progress) {
+//	    log.Printf("%d worlds explored", p.Worlds)
+//	})
+func WithProgress(fn func(Progress)) Option {
+	return optionFunc(func(o *options) {
+		o.progress = fn
+	})
+}
+
+// WithProgressInterval sets the time between two periodic progress reports,
+// which is 500ms by default.
+//
+// Parameters:
+//   - interval: Time between two reports
+//
+// Returns an Option that can be passed to Test(), Debug() or WriteDot().
+//
+// Example:
+//
+//	goat.WithProgressInterval(5 * time.Second)
+func WithProgressInterval(interval time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.progressInterval = interval
+	})
+}
+
+// TerminalProgress returns a progress function for WithProgress that keeps
+// rewriting a single line of a terminal with the latest progress, and moves
+// to the next line when a phase is done.
+//
+// Parameters:
+//   - w: The terminal to write to, usually os.Stderr
+//
+// Returns the progress function.
+//
+// Example:
+//
+//	result, err := goat.Test(goat.WithStateMachines(sm), goat.WithProgress(goat.TerminalProgress(os.Stderr)))
+func TerminalProgress(w io.Writer) func(Progress) {
+	return func(p Progress) {
+		// "\r" returns to the start of the line and "\033[K" clears it.
+		_, _ = fmt.Fprintf(w, "\r\033[K%s", p)
+		if p.Done {
+			_, _ = fmt.Fprintln(w)
+		}
+	}
+}
+
+// progressTicker reports the progress of one phase of model checking.
+type progressTicker struct {
+	m     *model
+	phase string
+	start time.Time
+	last  time.Time
+}
+
+func (m *model) startProgress(phase string) *progressTicker {
+	now := time.Now()
+	return &progressTicker{m: m, phase: phase, start: now, last: now}
+}
+
+// due reports whether the progress interval has passed since the last
+// report.
+func (t *progressTicker) due() bool {
+	interval := t.m.progressInterval
+	if interval == 0 {
+		interval = defaultProgressInterval
+	}
+	return time.Since(t.last) >= interval
+}
+
+// report fills in the phase, timing and memory of p and reports it to the
+// reporter and the WithProgress function.
+func (t *progressTicker) report(p Progress, done bool) {
+	t.last = time.Now()
+	p.Phase = t.phase
+	p.Elapsed = t.last.Sub(t.start)
+	p.Done = done
+	if t.phase == PhaseExplore && p.Elapsed > 0 {
+		p.Rate = float64(p.Worlds) / p.Elapsed.Seconds()
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	p.MemoryBytes = stats.HeapAlloc
+
+	t.m.report().Progress(p)
+	if t.m.progress != nil {
+		t.m.progress(p)
+	}
+}
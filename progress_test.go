@@ -0,0 +1,143 @@
+package goat
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProgress_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress Progress
+		want     string
+	}{
+		{
+			name: "explore",
+			progress: Progress{
+				Phase:       PhaseExplore,
+				Worlds:      1200,
+				Frontier:    35,
+				Depth:       12,
+				Violations:  1,
+				Rate:        34000,
+				MemoryBytes: 12 << 20,
+				Elapsed:     35 * time.Millisecond,
+			},
+			want: "explore: 1200 worlds, frontier 35, depth 12, 1 violations, 34000 worlds/s, 12.0 MiB, 35ms",
+		},
+		{
+			name: "temporal done",
+			progress: Progress{
+				Phase:        PhaseTemporal,
+				Worlds:       1200,
+				Rules:        3,
+				RulesChecked: 3,
+				MemoryBytes:  1 << 19,
+				Elapsed:      1500 * time.Millisecond,
+				Done:         true,
+			},
+			want: "temporal: 1200 worlds, 3/3 rules, 0 violations, 0.5 MiB, 1.5s (done)",
+		},
+		{
+			name: "temporal product",
+			progress: Progress{
+				Phase:        PhaseTemporal,
+				Worlds:       1200,
+				Rules:        3,
+				RulesChecked: 1,
+				ProductNodes: 2400,
+				MemoryBytes:  1 << 19,
+				Elapsed:      time.Second,
+			},
+			want: "temporal: 1200 worlds, 1/3 rules, 2400 product nodes, 0 violations, 0.5 MiB, 1s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.progress.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithProgress(t *testing.T) {
+	var reports []Progress
+	m, err := newModel(append(newCoverageTestOptions(t),
+		WithRules(EventuallyAlways(BoolCondition("never", false)), Always(BoolCondition("ok", true))),
+		WithReporter(NewQuietReporter()),
+		WithProgress(func(p Progress) { reports = append(reports, p) }),
+		WithProgressInterval(time.Nanosecond),
+	)...)
+	if err != nil {
+		t.Fatalf("newModel() returned error: %v", err)
+	}
+
+	if err := m.Solve(); err != nil {
+		t.Fatalf("Solve() returned error: %v", err)
+	}
+	_ = m.checkLTL()
+
+	type summary struct {
+		Phase      string
+		Worlds     int
+		Depth      int
+		Rules      int
+		Violations int
+		Done       bool
+	}
+	var got []summary
+	productReports := 0
+	for _, p := range reports {
+		if p.Phase == PhaseTemporal && !p.Done {
+			// The product of a rule is explored before the rule counts as
+			// checked.
+			if p.ProductNodes == 0 || p.RulesChecked != 0 {
+				t.Errorf("unexpected periodic temporal report: %+v", p)
+			}
+			productReports++
+			continue
+		}
+		if p.Phase == PhaseExplore && !p.Done {
+			// Periodic reports depend on the exploration order, so only
+			// check that they stay within the final numbers.
+			if p.Worlds > len(m.worlds) || p.Frontier > len(m.worlds) {
+				t.Errorf("periodic report out of range: %+v", p)
+			}
+			continue
+		}
+		got = append(got, summary{Phase: p.Phase, Worlds: p.Worlds, Depth: p.Depth, Rules: p.Rules, Violations: p.Violations, Done: p.Done})
+	}
+
+	worlds := len(m.worlds)
+	want := []summary{
+		{Phase: PhaseExplore, Worlds: worlds, Depth: 7, Done: true},
+		{Phase: PhaseTemporal, Worlds: worlds, Rules: 1, Violations: 1, Done: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("progress mismatch (-want +got):\n%s", diff)
+	}
+	if productReports == 0 {
+		t.Error("expected periodic reports while exploring the product")
+	}
+	if len(reports) <= len(want) {
+		t.Errorf("expected periodic reports besides the final ones, got %d reports", len(reports))
+	}
+}
+
+func TestTerminalProgress(t *testing.T) {
+	var buf bytes.Buffer
+	report := TerminalProgress(&buf)
+	report(Progress{Phase: PhaseExplore, Worlds: 10, Elapsed: time.Millisecond})
+	report(Progress{Phase: PhaseExplore, Worlds: 20, Elapsed: 2 * time.Millisecond, Done: true})
+
+	want := "\r\033[Kexplore: 10 worlds, frontier 0, depth 0, 0 violations, 0 worlds/s, 0.0 MiB, 1ms" +
+		"\r\033[Kexplore: 20 worlds, frontier 0, depth 0, 0 violations, 0 worlds/s, 0.0 MiB, 2ms (done)\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -2,7 +2,10 @@ package protobuf
 
 import (
 	"reflect"
+	"slices"
 	"sort"
+
+	"github.com/goatx/goat/internal/typeutil"
 )
 
 type typeAnalyzer struct {
@@ -18,20 +21,34 @@ func newTypeAnalyzer() *typeAnalyzer {
 type service struct {
 	Name    string
 	Methods []method
+	Options []option
 }
 
 type method struct {
-	Name       string
-	InputType  string
-	OutputType string
+	Name            string
+	InputType       string
+	OutputType      string
+	ClientStreaming bool
+	ServerStreaming bool
+	Options         []option
+	Description     string
 }
 
 type definitions struct {
+	// Imports lists the files the definitions refer to, other than those of
+	// the well-known types.
+	Imports  []string
+	Enums    []*enum
 	Messages []*message
 	Services []*service
+	// Warnings lists the fields left out of the messages.
+	Warnings []string
 }
 
-func (a *typeAnalyzer) analyzeSpecs(specs ...AbstractServiceSpec) *definitions {
+// analyzeSpecs collects the definitions of specs. It returns an error when
+// distinct Go types are given the same message name, as only one of them
+// could be defined.
+func (a *typeAnalyzer) analyzeSpecs(specs ...AbstractServiceSpec) (*definitions, error) {
 	definitions := &definitions{
 		Messages: []*message{},
 		Services: []*service{},
@@ -39,22 +56,70 @@ func (a *typeAnalyzer) analyzeSpecs(specs ...AbstractServiceSpec) *definitions {
 
 	a.processedTypes = make(map[reflect.Type]bool)
 
+	// Messages and enums shared by several specs are only defined once.
+	messageTypes := make(map[string]reflect.Type)
+	seenEnums := make(map[string]bool)
 	for _, spec := range specs {
+		if conflicts := spec.getNameConflicts(); len(conflicts) > 0 {
+			return nil, conflicts[0]
+		}
 		service := a.analyzeServiceSpecInterface(spec)
 		definitions.Services = append(definitions.Services, service)
 
+		types := spec.getMessageTypes()
 		messages := make([]*message, 0, len(spec.getMessages()))
-		for _, message := range spec.getMessages() {
-			messages = append(messages, message)
+		for name, message := range spec.getMessages() {
+			if t, ok := messageTypes[name]; ok {
+				if t != types[name] {
+					return nil, typeutil.NameConflict(name, t, types[name])
+				}
+				continue
+			}
+			messageTypes[name] = types[name]
+			// Generate numbers the fields of the definitions, so they must
+			// not share them with the spec.
+			clone := *message
+			clone.Fields = slices.Clone(message.Fields)
+			describeMessage(&clone, spec)
+			messages = append(messages, &clone)
 		}
 		sort.Slice(messages, func(i, j int) bool {
 			return messages[i].Name < messages[j].Name
 		})
 
 		definitions.Messages = append(definitions.Messages, messages...)
+
+		for name, enum := range spec.getEnums() {
+			if !seenEnums[name] {
+				seenEnums[name] = true
+				definitions.Enums = append(definitions.Enums, enum)
+			}
+		}
+		for _, warning := range spec.getWarnings() {
+			if !slices.Contains(definitions.Warnings, warning) {
+				definitions.Warnings = append(definitions.Warnings, warning)
+			}
+		}
 	}
+	sort.Slice(definitions.Enums, func(i, j int) bool {
+		return definitions.Enums[i].Name < definitions.Enums[j].Name
+	})
 
-	return definitions
+	return definitions, nil
+}
+
+// describeMessage sets the descriptions of msg and of its fields to the doc
+// comments of the Go types and fields they stand for. The source is only
+// looked up here, so that registering methods does not run go list.
+func describeMessage(msg *message, spec AbstractServiceSpec) {
+	docs := spec.getDocs()
+	msg.Description = docs.Type(spec.getMessageTypes()[msg.Name])
+	sources := spec.getFieldSources()[msg.Name]
+	for i := range msg.Fields {
+		if source, ok := sources[msg.Fields[i].Name]; ok {
+			msg.Fields[i].Description = docs.Field(source.owner, source.name)
+		}
+	}
 }
 
 func (*typeAnalyzer) analyzeServiceSpecInterface(spec AbstractServiceSpec) *service {
@@ -68,6 +133,7 @@ func (*typeAnalyzer) analyzeServiceSpecInterface(spec AbstractServiceSpec) *serv
 	service := &service{
 		Name:    serviceName,
 		Methods: []method{},
+		Options: spec.getOptions(),
 	}
 
 	for _, metadata := range rpcMethods {
@@ -75,6 +141,11 @@ func (*typeAnalyzer) analyzeServiceSpecInterface(spec AbstractServiceSpec) *serv
 			Name:       metadata.MethodName,
 			InputType:  metadata.InputType,
 			OutputType: metadata.OutputType,
+
+			ClientStreaming: metadata.ClientStreaming,
+			ServerStreaming: metadata.ServerStreaming,
+			Options:         spec.getMethodOptions()[metadata.MethodName],
+			Description:     metadata.Description,
 		}
 		service.Methods = append(service.Methods, method)
 	}
@@ -148,7 +148,10 @@ func TestTypeAnalyzer_analyzeSpecs(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := newTypeAnalyzer()
-			got := a.analyzeSpecs(tt.specs...)
+			got, err := a.analyzeSpecs(tt.specs...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("analyzeSpecs() error = %v, wantErr %v", err, tt.wantErr)
+			}
 
 			if diff := cmp.Diff(tt.want, got); diff != "" {
 				t.Errorf("analyzeSpecs() mismatch (-want +got):\n%s", diff)
@@ -156,3 +159,110 @@ func TestTypeAnalyzer_analyzeSpecs(t *testing.T) {
 		})
 	}
 }
+
+func TestTypeAnalyzer_analyzeSpecs_sharedDefinitions(t *testing.T) {
+	RegisterEnum(TestStatusActive, TestStatusInProgress)
+	RegisterEnum(TestPriorityLow, TestPriorityHigh)
+
+	newSpec := func(methodName string) AbstractServiceSpec {
+		spec := NewServiceSpec(&TestService1{})
+		state := &TestIdleState{}
+		spec.DefineStates(state).SetInitialState(state)
+		OnMessage(spec, state, methodName,
+			func(ctx context.Context, event *TestProfile, sm *TestService1) Response[*TestResponse1] {
+				return SendTo(ctx, sm, &TestResponse1{})
+			})
+		return spec
+	}
+
+	got, err := newTypeAnalyzer().analyzeSpecs(newSpec("Method1"), newSpec("Method2"))
+	if err != nil {
+		t.Fatalf("analyzeSpecs() returned error: %v", err)
+	}
+
+	messageNames := make([]string, 0, len(got.Messages))
+	for _, m := range got.Messages {
+		messageNames = append(messageNames, m.Name)
+	}
+	wantMessages := []string{"TestAddress", "TestProfile", "TestProfileExtra", "TestResponse1"}
+	if diff := cmp.Diff(wantMessages, messageNames); diff != "" {
+		t.Errorf("messages mismatch (-want +got):\n%s", diff)
+	}
+
+	enumNames := make([]string, 0, len(got.Enums))
+	for _, e := range got.Enums {
+		enumNames = append(enumNames, e.Name)
+	}
+	if diff := cmp.Diff([]string{"TestPriority", "TestStatus"}, enumNames); diff != "" {
+		t.Errorf("enums mismatch (-want +got):\n%s", diff)
+	}
+
+	wantWarnings := []string{
+		"field TestProfile.Callback is left out: func() has no protobuf equivalent",
+		"field TestProfile.Grid is left out: [][]int64: a repeated field cannot hold lists or maps",
+	}
+	if diff := cmp.Diff(wantWarnings, got.Warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTypeAnalyzer_analyzeSpecs_nameConflict(t *testing.T) {
+	// Two distinct types, declared in different scopes, named alike.
+	type TestUser struct {
+		Message[*TestService1, *TestService1]
+		Name string
+	}
+	firstSpec := func() AbstractServiceSpec {
+		spec := NewServiceSpec(&TestService1{})
+		state := &TestIdleState{}
+		spec.DefineStates(state).SetInitialState(state)
+		OnMessage(spec, state, "First",
+			func(ctx context.Context, event *TestUser, sm *TestService1) Response[*TestResponse1] {
+				return SendTo(ctx, sm, &TestResponse1{})
+			})
+		return spec
+	}
+	secondSpec := func() AbstractServiceSpec {
+		type TestUser struct {
+			Message[*TestService1, *TestService1]
+			ID int64
+		}
+		spec := NewServiceSpec(&TestService1{})
+		state := &TestIdleState{}
+		spec.DefineStates(state).SetInitialState(state)
+		OnMessage(spec, state, "Second",
+			func(ctx context.Context, event *TestUser, sm *TestService1) Response[*TestResponse1] {
+				return SendTo(ctx, sm, &TestResponse1{})
+			})
+		return spec
+	}
+
+	tests := []struct {
+		name  string
+		specs []AbstractServiceSpec
+	}{
+		{name: "in different specs", specs: []AbstractServiceSpec{firstSpec(), secondSpec()}},
+		{name: "in one spec", specs: []AbstractServiceSpec{func() AbstractServiceSpec {
+			spec := firstSpec().(*ServiceSpec[*TestService1])
+			type TestUser struct {
+				Message[*TestService1, *TestService1]
+				Email string
+			}
+			OnMessage(spec, &TestIdleState{}, "Third",
+				func(ctx context.Context, event *TestUser, sm *TestService1) Response[*TestResponse1] {
+					return SendTo(ctx, sm, &TestResponse1{})
+				})
+			return spec
+		}()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTypeAnalyzer().analyzeSpecs(tt.specs...)
+			want := "github.com/goatx/goat/protobuf.TestUser and github.com/goatx/goat/protobuf.TestUser are both named TestUser"
+			if err == nil || err.Error() != want {
+				t.Errorf("analyzeSpecs() error = %v, want %q", err, want)
+			}
+		})
+	}
+}
@@ -3,21 +3,60 @@ package protobuf
 import (
 	"context"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
 
 	"github.com/goatx/goat"
 	"github.com/goatx/goat/internal/typeutil"
+	"github.com/goatx/goat/internal/validate"
 )
 
 type Response[O AbstractMessage] struct {
 	event O
+	// status is the error the method fails with instead, set by SendError.
+	status *Status
 }
 
 func (r Response[O]) getEvent() AbstractMessage {
 	return r.event
 }
 
+// SendTo sends the output message of a method to the target state machine.
+// The model checker reports a violation of the rule "valid messages" when
+// the message breaks the constraints declared on its fields with the
+// validate struct tag:
+//   - required: the value is not zero, nil or empty
+//   - min=N, max=N: the inclusive bounds of a number
+//   - minlen=N, maxlen=N: the inclusive bounds of the length of a string,
+//     in characters, of a []byte, in bytes, or of a list or map, in items
+//   - pattern=RE: a regular expression strings must match; it comes last,
+//     as it may contain commas
+//   - in=a|b|c: the values a string or integer may take; for a type
+//     registered with RegisterEnum, they are written as the numbers of the
+//     enum values
+//
+// The constraints other than required, minlen and maxlen apply to each item
+// of a list. Generate writes them as buf.validate field options, to be
+// enforced by protovalidate.
+//
+// Parameters:
+//   - ctx: The context of the handler
+//   - target: The state machine to send the message to
+//   - event: The message to send
+//
+// Returns the Response to return from the handler.
+//
+// Example:
+//
+//	type JoinResponse struct {
+//	    protobuf.Message[*Server, *Client]
+//	    Seat int32 `validate:"min=1,max=10"`
+//	}
+//
+//	return protobuf.SendTo(ctx, req.Sender(), &JoinResponse{Seat: seat})
 func SendTo[O AbstractMessage](ctx context.Context, target goat.AbstractStateMachine, event O) Response[O] {
+	validate.Report(ctx, validMessagesRule, event)
 	goat.SendTo(ctx, target, event)
 	return Response[O]{event: event}
 }
@@ -27,49 +66,97 @@ func NewServiceSpec[T goat.AbstractStateMachine](prototype T) *ServiceSpec[T] {
 		StateMachineSpec: goat.NewStateMachineSpec(prototype),
 		rpcMethods:       []rpcMethod{},
 		messages:         make(map[string]*message),
+		enums:            make(map[string]*enum),
 		handlers:         make(map[string]handlerFunc),
 	}
 }
 
+// MethodOption configures a method registered with OnMessage,
+// OnServerStream, OnClientStream or OnBidiStream.
+type MethodOption func(*methodConfig)
+
+type methodConfig struct {
+	description string
+}
+
+// WithDescription documents a method. Generate writes the description as a
+// comment above the rpc. Messages and their fields are documented with
+// their Go doc comments instead.
+func WithDescription(description string) MethodOption {
+	return func(c *methodConfig) {
+		c.description = description
+	}
+}
+
 func OnMessage[T goat.AbstractStateMachine, I AbstractMessage, O AbstractMessage](
 	spec *ServiceSpec[T],
 	state goat.AbstractState,
 	methodName string,
 	handler func(context.Context, I, T) Response[O],
+	opts ...MethodOption,
 ) {
-	inputEvent := newMessagePrototype[I]()
-	outputEvent := newMessagePrototype[O]()
+	spec.docs.RegisterCaller(1)
+	addMethod[T, I, O](spec, methodName, false, false, opts)
 
-	serviceTypeName := spec.getServiceName()
-	inputTypeName := getEventTypeName(inputEvent)
-	outputTypeName := getEventTypeName(outputEvent)
-
-	metadata := rpcMethod{
-		ServiceType: serviceTypeName,
-		MethodName:  methodName,
-		InputType:   inputTypeName,
-		OutputType:  outputTypeName,
+	respond := func(ctx context.Context, event I, sm T) Response[O] {
+		response := handler(ctx, event, sm)
+		if response.status != nil && checkCode(response.status.Code) == nil {
+			spec.addError(methodName, event, response.status)
+		}
+		return response
 	}
 
-	spec.addRPCMethod(metadata)
-
-	inputMsg := analyzeMessage(inputEvent)
-	outputMsg := analyzeMessage(outputEvent)
-	spec.addMessage(inputMsg)
-	spec.addMessage(outputMsg)
-
-	spec.handlers[methodName] = func(ctx context.Context, input AbstractMessage, sm goat.AbstractStateMachine) AbstractMessage {
-		return handler(ctx, input.(I), sm.(T)).getEvent()
+	spec.handlers[methodName] = func(ctx context.Context, input AbstractMessage, sm goat.AbstractStateMachine) (AbstractMessage, *Status) {
+		response := respond(ctx, input.(I), sm.(T))
+		if response.status != nil {
+			return nil, response.status
+		}
+		return response.getEvent(), nil
 	}
 
 	wrappedHandler := func(ctx context.Context, event I, sm T) {
-		response := handler(ctx, event, sm)
-		_ = response
+		respond(ctx, event, sm)
 	}
 
 	goat.OnEvent(spec.StateMachineSpec, state, wrappedHandler)
 }
 
+// addMethod records a method of the service and analyzes its input and
+// output messages, whose doc comments Generate looks up from the package
+// calling the On function.
+func addMethod[T goat.AbstractStateMachine, I AbstractMessage, O AbstractMessage](
+	spec *ServiceSpec[T],
+	methodName string,
+	clientStreaming, serverStreaming bool,
+	opts []MethodOption,
+) {
+	config := &methodConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	inputEvent := newMessagePrototype[I]()
+	outputEvent := newMessagePrototype[O]()
+
+	spec.addRPCMethod(rpcMethod{
+		ServiceType:     spec.getServiceName(),
+		MethodName:      methodName,
+		InputType:       messageTypeName(inputEvent),
+		OutputType:      messageTypeName(outputEvent),
+		ClientStreaming: clientStreaming,
+		ServerStreaming: serverStreaming,
+		Description:     config.description,
+	})
+
+	analyzer := newMessageAnalyzer()
+	for _, t := range []reflect.Type{reflect.TypeOf(inputEvent), reflect.TypeOf(outputEvent)} {
+		if !isEmptyType(t) {
+			analyzer.analyzeStruct(derefType(t), "")
+		}
+	}
+	spec.addAnalysis(analyzer)
+}
+
 func newMessagePrototype[T AbstractMessage]() T {
 	var zero T
 	msgType := reflect.TypeOf(zero)
@@ -104,46 +191,201 @@ func newMessagePrototype[T AbstractMessage]() T {
 }
 
 func analyzeMessage[M AbstractMessage](instance M) *message {
-	msgType := reflect.TypeOf(instance)
-	if msgType.Kind() == reflect.Ptr {
-		msgType = msgType.Elem()
+	return newMessageAnalyzer().analyzeStruct(derefType(reflect.TypeOf(instance)), "")
+}
+
+// messageAnalyzer collects a message type together with the messages and
+// enums its fields refer to, and warnings about the fields that cannot be
+// represented in proto3.
+type messageAnalyzer struct {
+	messages map[string]*message
+	enums    map[string]*enum
+	warnings []string
+	// names holds the message name given to each struct type analyzed.
+	names map[reflect.Type]string
+	// fieldSources maps message and field names to the Go fields they
+	// stand for.
+	fieldSources map[string]map[string]fieldSource
+}
+
+func newMessageAnalyzer() *messageAnalyzer {
+	return &messageAnalyzer{
+		messages:     make(map[string]*message),
+		enums:        make(map[string]*enum),
+		names:        make(map[reflect.Type]string),
+		fieldSources: make(map[string]map[string]fieldSource),
 	}
+}
 
-	var fields []field
-	fieldNum := 1
+// analyzeStruct analyzes a struct type as a message, named after the type,
+// or name when the type has no name.
+func (a *messageAnalyzer) analyzeStruct(t reflect.Type, name string) *message {
+	if name, ok := a.names[t]; ok {
+		return a.messages[name]
+	}
+	if t.Name() != "" {
+		name = t.Name()
+	}
+	msg := &message{Name: name}
+	// Register the message before its fields so recursive types refer to it.
+	a.names[t] = name
+	a.messages[name] = msg
 
-	for i := 0; i < msgType.NumField(); i++ {
-		f := msgType.Field(i)
+	a.addFields(msg, t)
+	numberFields(msg)
+	return msg
+}
 
-		if !f.IsExported() || f.Name == "_" {
+// addFields adds the fields of t to msg, numbered with their proto tag or
+// left at 0 to be numbered by numberFields.
+func (a *messageAnalyzer) addFields(msg *message, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("proto")
+
+		if f.Name == "_" && hasTag {
+			numbers, names, err := parseReservedTag(tag)
+			if err != nil {
+				panic(fmt.Sprintf("invalid proto tag on %s: %v", msg.Name, err))
+			}
+			msg.ReservedNumbers = append(msg.ReservedNumbers, numbers...)
+			msg.ReservedNames = append(msg.ReservedNames, names...)
+			continue
+		}
+		if !f.IsExported() || f.Name == "_" || tag == "-" {
 			continue
 		}
 		if typeutil.IsEventType(f.Type) || isMessageType(f.Type) {
 			continue
 		}
+		// The fields of embedded structs are promoted, as in encoding/json.
+		if f.Anonymous && derefType(f.Type).Kind() == reflect.Struct {
+			a.addFields(msg, derefType(f.Type))
+			continue
+		}
 
-		protoType, isRepeated := mapGoField(f.Type)
-		if protoType == "" {
+		number := 0
+		if hasTag {
+			var err error
+			if number, err = parseFieldNumber(tag); err != nil {
+				panic(fmt.Sprintf("invalid proto tag on %s.%s: %v", msg.Name, f.Name, err))
+			}
+		}
+
+		protoType, isRepeated, err := a.protoType(f.Type, msg.Name+f.Name)
+		if err != nil {
+			a.warnings = append(a.warnings, fmt.Sprintf("field %s.%s is left out: %v", msg.Name, f.Name, err))
 			continue
 		}
 
-		fields = append(fields, field{
+		var options []option
+		constraints, err := validate.Field(f)
+		if err == nil && constraints != nil {
+			options, err = validationOptions(constraints, protoType, isRepeated, enumItemType(f.Type))
+		}
+		if err != nil {
+			panic(fmt.Sprintf("invalid validate tag on %s.%s: %v", msg.Name, f.Name, err))
+		}
+
+		msg.Fields = append(msg.Fields, field{
 			Name:       f.Name,
 			Type:       protoType,
-			Number:     fieldNum,
+			Number:     number,
 			IsRepeated: isRepeated,
+			Tagged:     hasTag,
+			Options:    options,
 		})
-		fieldNum++
+		if a.fieldSources[msg.Name] == nil {
+			a.fieldSources[msg.Name] = make(map[string]fieldSource)
+		}
+		a.fieldSources[msg.Name][f.Name] = fieldSource{owner: t, name: f.Name}
+	}
+}
+
+// protoType returns the proto type of a Go type, and whether it is repeated.
+// Structs are analyzed as messages; nameHint names them when they are
+// anonymous.
+func (a *messageAnalyzer) protoType(t reflect.Type, nameHint string) (string, bool, error) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return a.protoType(t.Elem(), nameHint)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes", false, nil
+		}
+		elem, elemRepeated, err := a.protoType(t.Elem(), nameHint)
+		if err != nil {
+			return "", false, err
+		}
+		if elemRepeated || isMapType(elem) {
+			return "", false, fmt.Errorf("%s: a repeated field cannot hold lists or maps", t)
+		}
+		return elem, true, nil
+	case reflect.Map:
+		key, keyRepeated, err := a.protoType(t.Key(), nameHint)
+		if err != nil {
+			return "", false, err
+		}
+		if keyRepeated || !isMapKeyType(key) {
+			return "", false, fmt.Errorf("%s: map keys must be integers, bools or strings", t)
+		}
+		value, valueRepeated, err := a.protoType(t.Elem(), nameHint)
+		if err != nil {
+			return "", false, err
+		}
+		if valueRepeated || isMapType(value) {
+			return "", false, fmt.Errorf("%s: map values cannot be lists or maps", t)
+		}
+		return fmt.Sprintf("map<%s, %s>", key, value), false, nil
+	}
+
+	if wellKnown := wellKnownType(t); wellKnown != "" {
+		return wellKnown, false, nil
+	}
+
+	if e := lookupEnum(t); e != nil {
+		a.enums[e.Name] = e
+		return e.Name, false, nil
+	}
+
+	if t.Kind() == reflect.Struct {
+		if typeutil.IsEventType(t) || isMessageType(t) {
+			return "", false, fmt.Errorf("%s cannot be a field", t)
+		}
+		return a.analyzeStruct(t, nameHint).Name, false, nil
 	}
 
-	return &message{
-		Name:   msgType.Name(),
-		Fields: fields,
+	if scalar, _ := mapGoField(t); scalar != "" {
+		return scalar, false, nil
+	}
+	return "", false, fmt.Errorf("%s has no protobuf equivalent", t)
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+func isMapType(protoType string) bool {
+	return strings.HasPrefix(protoType, "map<")
+}
+
+func isMapKeyType(protoType string) bool {
+	switch protoType {
+	case "string", "bool", "int32", "int64", "uint32", "uint64":
+		return true
+	default:
+		return false
 	}
 }
 
 func mapGoField(goType reflect.Type) (string, bool) {
 	if goType.Kind() == reflect.Slice {
+		if goType.Elem().Kind() == reflect.Uint8 {
+			return "bytes", false
+		}
 		elemType, _ := mapGoField(goType.Elem())
 		return elemType, true
 	}
@@ -153,10 +395,14 @@ func mapGoField(goType reflect.Type) (string, bool) {
 		return "string", false
 	case reflect.Bool:
 		return "bool", false
-	case reflect.Int32:
+	case reflect.Int8, reflect.Int16, reflect.Int32:
 		return "int32", false
 	case reflect.Int64, reflect.Int:
 		return "int64", false
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "uint32", false
+	case reflect.Uint64, reflect.Uint:
+		return "uint64", false
 	case reflect.Float32:
 		return "float", false
 	case reflect.Float64:
@@ -171,16 +417,41 @@ type GenerateOptions struct {
 	PackageName string
 	GoPackage   string
 	Filename    string
+	// Warnings receives a line for each message field that has no protobuf
+	// equivalent and is left out. It defaults to os.Stderr.
+	Warnings io.Writer
+	// LockFile is the path of a JSON file recording the number of every
+	// field generated. When set, Generate reads it so fields keep their
+	// numbers and the numbers and names of removed fields are reserved, and
+	// writes it back updated. The file is created if it does not exist.
+	LockFile string
+	// Options are further file options, such as "java_package", written as
+	// `option name = value;` in name order. Values are written as is, so
+	// string values need their quotes.
+	Options map[string]string
+	// Files splits the output into several files, each defining the
+	// services listed in it. Messages and enums used by the services of
+	// several files go to a common file described by Filename, PackageName,
+	// GoPackage and Options, which defaults to "common.proto" and is only
+	// written when it has definitions. Every spec passed to Generate must be
+	// in one of the files.
+	Files []ProtoFile
 }
 
+// Generate writes the messages and services of specs to a .proto file.
+//
+// Fields are numbered with their proto struct tag, such as `proto:"3"`, or
+// else with the lowest number not used by another field, in declaration
+// order. A blank field tagged `proto:"reserved=4,old_name"` reserves numbers
+// and names. Set LockFile to keep the numbers stable as fields are added,
+// removed and reordered: Generate then fails with an error for each change
+// that breaks wire compatibility, such as a field changing its number or
+// type. A field tagged `proto:"-"` is left out.
+//
+// Fields of type time.Time and time.Duration are generated as
+// google.protobuf.Timestamp and google.protobuf.Duration, and Empty as
+// google.protobuf.Empty, importing the files defining them.
 func Generate(opts GenerateOptions, specs ...AbstractServiceSpec) error {
-	if opts.OutputDir == "" {
-		opts.OutputDir = "./proto"
-	}
-	if opts.Filename == "" {
-		opts.Filename = "generated.proto"
-	}
-
 	generator := newGenerator(opts)
 	return generator.generateFromSpecs(specs...)
 }
@@ -138,6 +138,18 @@ func TestMapGoField(t *testing.T) {
 			wantProtoType: "string",
 			wantRepeated:  true,
 		},
+		{
+			name:          "maps []byte to bytes",
+			goType:        reflect.TypeOf([]byte{}),
+			wantProtoType: "bytes",
+			wantRepeated:  false,
+		},
+		{
+			name:          "maps uint32 to uint32",
+			goType:        reflect.TypeOf(uint32(0)),
+			wantProtoType: "uint32",
+			wantRepeated:  false,
+		},
 		{
 			name:          "handles unsupported type",
 			goType:        reflect.TypeOf(make(chan int)),
@@ -158,3 +170,156 @@ func TestMapGoField(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageAnalyzer(t *testing.T) {
+	RegisterEnum(TestStatusActive, TestStatusInProgress)
+	RegisterEnum(TestPriorityLow, TestPriorityHigh)
+
+	a := newMessageAnalyzer()
+	got := a.analyzeStruct(reflect.TypeOf(TestProfile{}), "")
+
+	want := &message{
+		Name: "TestProfile",
+		Fields: []field{
+			{Name: "CreatedAt", Type: "int64", Number: 1},
+			{Name: "UpdatedAt", Type: "google.protobuf.Timestamp", Number: 2},
+			{Name: "Retention", Type: "google.protobuf.Duration", Number: 3},
+			{Name: "Address", Type: "TestAddress", Number: 4},
+			{Name: "Previous", Type: "TestAddress", Number: 5, IsRepeated: true},
+			{Name: "Scores", Type: "map<string, int64>", Number: 6},
+			{Name: "Homes", Type: "map<int32, TestAddress>", Number: 7},
+			{Name: "Status", Type: "TestStatus", Number: 8},
+			{Name: "Priority", Type: "TestPriority", Number: 9},
+			{Name: "Avatar", Type: "bytes", Number: 10},
+			{Name: "Extra", Type: "TestProfileExtra", Number: 11},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("analyzeStruct() mismatch (-want +got):\n%s", diff)
+	}
+
+	wantMessages := map[string]*message{
+		"TestProfile": want,
+		"TestAddress": {
+			Name: "TestAddress",
+			Fields: []field{
+				{Name: "City", Type: "string", Number: 1},
+				{Name: "Lines", Type: "string", Number: 2, IsRepeated: true},
+			},
+		},
+		"TestProfileExtra": {
+			Name: "TestProfileExtra",
+			Fields: []field{
+				{Name: "Note", Type: "string", Number: 1},
+			},
+		},
+	}
+	if diff := cmp.Diff(wantMessages, a.messages); diff != "" {
+		t.Errorf("messages mismatch (-want +got):\n%s", diff)
+	}
+
+	wantEnums := map[string]*enum{
+		"TestStatus": {
+			Name: "TestStatus",
+			Values: []enumValue{
+				{Name: "TEST_STATUS_UNSPECIFIED", Number: 0},
+				{Name: "TEST_STATUS_ACTIVE", Number: 1},
+				{Name: "TEST_STATUS_IN_PROGRESS", Number: 2},
+			},
+		},
+		"TestPriority": {
+			Name: "TestPriority",
+			Values: []enumValue{
+				{Name: "TEST_PRIORITY_UNSPECIFIED", Number: 0},
+				{Name: "TEST_PRIORITY_LOW", Number: 1},
+				{Name: "TEST_PRIORITY_HIGH", Number: 2},
+			},
+		},
+	}
+	if diff := cmp.Diff(wantEnums, a.enums); diff != "" {
+		t.Errorf("enums mismatch (-want +got):\n%s", diff)
+	}
+
+	wantWarnings := []string{
+		"field TestProfile.Callback is left out: func() has no protobuf equivalent",
+		"field TestProfile.Grid is left out: [][]int64: a repeated field cannot hold lists or maps",
+	}
+	if diff := cmp.Diff(wantWarnings, a.warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type testNode struct {
+	Value    string
+	Children []testNode
+}
+
+func TestMessageAnalyzer_recursiveType(t *testing.T) {
+	a := newMessageAnalyzer()
+	got := a.analyzeStruct(reflect.TypeOf(testNode{}), "")
+
+	want := &message{
+		Name: "testNode",
+		Fields: []field{
+			{Name: "Value", Type: "string", Number: 1},
+			{Name: "Children", Type: "testNode", Number: 2, IsRepeated: true},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("analyzeStruct() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestSearchRequest asks for the users matching a query.
+type TestSearchRequest struct {
+	Message[*TestService1, *TestService1]
+	// Query is matched against user names.
+	//
+	// It is case insensitive.
+	Query string
+	Limit int64 // Limit caps the number of users returned.
+	Page  int64
+}
+
+func TestOnMessage_descriptions(t *testing.T) {
+	spec := NewServiceSpec(&TestService1{})
+	state := &TestIdleState{}
+	spec.DefineStates(state).SetInitialState(state)
+	OnMessage(spec, state, "Search",
+		func(ctx context.Context, event *TestSearchRequest, sm *TestService1) Response[*TestResponse1] {
+			return SendTo(ctx, sm, &TestResponse1{})
+		},
+		WithDescription("Search finds users.\nResults are sorted by name."))
+
+	definitions, err := newTypeAnalyzer().analyzeSpecs(spec)
+	if err != nil {
+		t.Fatalf("analyzeSpecs() returned error: %v", err)
+	}
+	got := (&fileWriter{}).generateFileContent(definitions)
+	want := `syntax = "proto3";
+
+message TestResponse1 {
+  string result = 1;
+}
+
+// TestSearchRequest asks for the users matching a query.
+message TestSearchRequest {
+  // Query is matched against user names.
+  //
+  // It is case insensitive.
+  string query = 1;
+  // Limit caps the number of users returned.
+  int64 limit = 2;
+  int64 page = 3;
+}
+
+service TestService1 {
+  // Search finds users.
+  // Results are sorted by name.
+  rpc Search(TestSearchRequest) returns (TestResponse1);
+}
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("generateFileContent() mismatch (-want +got):\n%s", diff)
+	}
+}
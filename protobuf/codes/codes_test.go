@@ -0,0 +1,28 @@
+package codes
+
+import "testing"
+
+func TestCode_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code Code
+		want string
+	}{
+		{OK, "OK"},
+		{NotFound, "NotFound"},
+		{Unavailable, "Unavailable"},
+		{Unauthenticated, "Unauthenticated"},
+		{Code(17), "Code(17)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.code.String(); got != tt.want {
+				t.Fatalf("Code(%d).String() = %q, want %q", uint32(tt.code), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package protobuf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// BreakingChange is a difference between a previously generated file and the
+// current specs that can break clients built against the file.
+type BreakingChange struct {
+	// Location names what changed, such as "UserService.GetUser" or
+	// "CreateUserRequest.email".
+	Location string
+	// Description says how it changed.
+	Description string
+}
+
+func (c BreakingChange) String() string {
+	return c.Location + ": " + c.Description
+}
+
+// CheckCompatibility compares a .proto file generated earlier with the one
+// Generate would write for specs now, and reports the changes that break
+// existing clients: removed services, methods, messages, enums and enum
+// values, methods whose input, output or streaming changed, fields that
+// changed their type or name, or were removed without reserving their
+// number, and fields newly required by a buf.validate rule.
+//
+// Parameters:
+//   - oldFile: Path of the previously generated .proto file
+//   - specs: The service specifications to generate now
+//
+// Returns the breaking changes, none when the specs are compatible, and an
+// error if the file cannot be read or parsed.
+//
+// Example:
+//
+//	changes, err := protobuf.CheckCompatibility("proto/user_service.proto", spec)
+//	for _, c := range changes {
+//	    log.Printf("breaking change: %s", c)
+//	}
+func CheckCompatibility(oldFile string, specs ...AbstractServiceSpec) ([]BreakingChange, error) {
+	return CheckCompatibilityWithOptions(oldFile, GenerateOptions{}, specs...)
+}
+
+// CheckCompatibilityWithOptions is CheckCompatibility for the file Generate
+// writes with opts. Fields are numbered as Generate numbers them: when
+// opts.LockFile is set, the numbers it records are kept. The lock file is
+// only read. When opts.Files is set, oldFile is compared with the file of
+// the split output that has its name, so types defined in the other files
+// keep their package qualifiers.
+//
+// Example:
+//
+//	changes, err := protobuf.CheckCompatibilityWithOptions("proto/user_service.proto", opts, spec)
+func CheckCompatibilityWithOptions(oldFile string, opts GenerateOptions, specs ...AbstractServiceSpec) ([]BreakingChange, error) {
+	// #nosec G304 - oldFile is chosen by the caller
+	content, err := os.ReadFile(oldFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", oldFile, err)
+	}
+	old, err := parseProtoFile(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", oldFile, err)
+	}
+
+	// Compare with the file as it would be generated, so both sides use proto
+	// names.
+	g := newGenerator(opts)
+	current, err := g.analyzeSpecs(specs...)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := g.readLock()
+	if err != nil {
+		return nil, err
+	}
+	if err := lock.apply(current); err != nil {
+		return nil, err
+	}
+	files, err := g.files(current)
+	if err != nil {
+		return nil, err
+	}
+	file, err := matchProtoFile(oldFile, files)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseProtoFile(g.fileWriter(file.ProtoFile).generateFileContent(file.definitions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the generated file: %w", err)
+	}
+	return compareDefinitions(old, parsed), nil
+}
+
+// matchProtoFile returns the file among files that path is a copy of: the
+// only one, or the one whose Filename path ends with.
+func matchProtoFile(path string, files []*protoFile) (*protoFile, error) {
+	if len(files) == 1 {
+		return files[0], nil
+	}
+	path = filepath.ToSlash(path)
+	for _, file := range files {
+		name := filepath.ToSlash(file.Filename)
+		if path == name || strings.HasSuffix(path, "/"+name) {
+			return file, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is none of the files of GenerateOptions.Files", path)
+}
+
+// compareDefinitions returns the changes from old to current that break
+// clients of old.
+func compareDefinitions(old, current *definitions) []BreakingChange {
+	var changes []BreakingChange
+	report := func(location, format string, args ...any) {
+		changes = append(changes, BreakingChange{Location: location, Description: fmt.Sprintf(format, args...)})
+	}
+
+	services := make(map[string]*service)
+	for _, s := range current.Services {
+		services[s.Name] = s
+	}
+	for _, oldService := range old.Services {
+		s, ok := services[oldService.Name]
+		if !ok {
+			report(oldService.Name, "service was removed")
+			continue
+		}
+		for _, oldMethod := range oldService.Methods {
+			location := oldService.Name + "." + oldMethod.Name
+			i := slices.IndexFunc(s.Methods, func(m method) bool { return m.Name == oldMethod.Name })
+			if i < 0 {
+				report(location, "method was removed")
+				continue
+			}
+			m := s.Methods[i]
+			if m.InputType != oldMethod.InputType {
+				report(location, "input changed from %s to %s", oldMethod.InputType, m.InputType)
+			}
+			if m.OutputType != oldMethod.OutputType {
+				report(location, "output changed from %s to %s", oldMethod.OutputType, m.OutputType)
+			}
+			if m.ClientStreaming != oldMethod.ClientStreaming {
+				report(location, "input streaming changed from %t to %t", oldMethod.ClientStreaming, m.ClientStreaming)
+			}
+			if m.ServerStreaming != oldMethod.ServerStreaming {
+				report(location, "output streaming changed from %t to %t", oldMethod.ServerStreaming, m.ServerStreaming)
+			}
+		}
+	}
+
+	messages := make(map[string]*message)
+	for _, m := range current.Messages {
+		messages[m.Name] = m
+	}
+	for _, oldMessage := range old.Messages {
+		m, ok := messages[oldMessage.Name]
+		if !ok {
+			report(oldMessage.Name, "message was removed")
+			continue
+		}
+		for _, oldField := range oldMessage.Fields {
+			location := oldMessage.Name + "." + oldField.Name
+			i := slices.IndexFunc(m.Fields, func(f field) bool { return f.Number == oldField.Number })
+			if i < 0 {
+				if j := slices.IndexFunc(m.Fields, func(f field) bool { return f.Name == oldField.Name }); j >= 0 {
+					report(location, "number changed from %d to %d", oldField.Number, m.Fields[j].Number)
+				} else if !slices.Contains(m.ReservedNumbers, oldField.Number) {
+					report(location, "field was removed without reserving its number %d", oldField.Number)
+				}
+				continue
+			}
+			f := m.Fields[i]
+			if lockType(f) != lockType(oldField) {
+				report(location, "type of field %d changed from %s to %s", oldField.Number, lockType(oldField), lockType(f))
+			} else if f.Name != oldField.Name {
+				report(location, "field %d was renamed to %s, which breaks JSON clients", oldField.Number, f.Name)
+			}
+			if isRequired(f) && !isRequired(oldField) {
+				report(location, "field %d became required", oldField.Number)
+			}
+		}
+		// Clients of old never set the fields it does not have.
+		for _, f := range m.Fields {
+			if isRequired(f) && !slices.ContainsFunc(oldMessage.Fields, func(old field) bool { return old.Number == f.Number }) {
+				report(oldMessage.Name+"."+f.Name, "new field %d is required", f.Number)
+			}
+		}
+	}
+
+	enums := make(map[string]*enum)
+	for _, e := range current.Enums {
+		enums[e.Name] = e
+	}
+	for _, oldEnum := range old.Enums {
+		e, ok := enums[oldEnum.Name]
+		if !ok {
+			report(oldEnum.Name, "enum was removed")
+			continue
+		}
+		for _, oldValue := range oldEnum.Values {
+			if !slices.ContainsFunc(e.Values, func(v enumValue) bool { return v.Number == oldValue.Number }) {
+				report(oldEnum.Name+"."+oldValue.Name, "enum value %d was removed", oldValue.Number)
+			}
+		}
+	}
+
+	return changes
+}
+
+// isRequired reports whether f has the buf.validate rule requiring it.
+func isRequired(f field) bool {
+	return slices.Contains(f.Options, option{Name: validateOption + ".required", Value: "true"})
+}
@@ -0,0 +1,232 @@
+package protobuf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompareDefinitions(t *testing.T) {
+	old := `
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_ADMIN = 1;
+  ROLE_GUEST = 2;
+}
+
+enum Color {
+  COLOR_UNSPECIFIED = 0;
+}
+
+message User {
+  string name = 1;
+  string email = 2;
+  int64 age = 3;
+  string phone = 4;
+  repeated string tags = 5;
+  string nickname = 6;
+}
+
+message Legacy {
+  string id = 1;
+}
+
+message CreateUserRequest {
+  string name = 1;
+  string email = 2 [(buf.validate.field).required = true];
+}
+
+service UserService {
+  rpc GetUser(User) returns (User);
+  rpc DeleteUser(User) returns (User);
+  rpc Watch(User) returns (stream User);
+}
+
+service LegacyService {
+  rpc Get(Legacy) returns (Legacy);
+}
+`
+	current := `
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_ADMIN = 1;
+}
+
+message User {
+  reserved 4;
+  string display_name = 1;
+  int64 email = 2;
+  string age = 7;
+  string tags = 5;
+  string nickname = 6;
+  string address = 8;
+}
+
+message Profile {
+  string id = 1;
+}
+
+message CreateUserRequest {
+  string name = 1 [(buf.validate.field).required = true];
+  string email = 2 [(buf.validate.field).required = true];
+  string phone = 3 [(buf.validate.field).required = true];
+  string note = 4;
+}
+
+service UserService {
+  rpc GetUser(User) returns (Profile);
+  rpc CreateUser(User) returns (User);
+  rpc Watch(stream User) returns (User);
+}
+`
+
+	oldDefs, err := parseProtoFile(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentDefs, err := parseProtoFile(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := compareDefinitions(oldDefs, currentDefs)
+	want := []BreakingChange{
+		{Location: "UserService.GetUser", Description: "output changed from User to Profile"},
+		{Location: "UserService.DeleteUser", Description: "method was removed"},
+		{Location: "UserService.Watch", Description: "input streaming changed from false to true"},
+		{Location: "UserService.Watch", Description: "output streaming changed from true to false"},
+		{Location: "LegacyService", Description: "service was removed"},
+		{Location: "User.name", Description: "field 1 was renamed to display_name, which breaks JSON clients"},
+		{Location: "User.email", Description: "type of field 2 changed from string to int64"},
+		{Location: "User.age", Description: "number changed from 3 to 7"},
+		{Location: "User.tags", Description: "type of field 5 changed from repeated string to string"},
+		{Location: "Legacy", Description: "message was removed"},
+		{Location: "CreateUserRequest.name", Description: "field 1 became required"},
+		{Location: "CreateUserRequest.phone", Description: "new field 3 is required"},
+		{Location: "Role.ROLE_GUEST", Description: "enum value 2 was removed"},
+		{Location: "Color", Description: "enum was removed"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("compareDefinitions() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	newSpec := func() AbstractServiceSpec {
+		spec := NewServiceSpec(&TestService1{})
+		state := &TestIdleState{}
+		spec.DefineStates(state).SetInitialState(state)
+		OnMessage(spec, state, "TestMethod",
+			func(ctx context.Context, event *TestRequest1, sm *TestService1) Response[*TestResponse1] {
+				return SendTo(ctx, sm, &TestResponse1{})
+			})
+		return spec
+	}
+
+	dir := t.TempDir()
+	if err := Generate(GenerateOptions{OutputDir: dir}, newSpec()); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	path := filepath.Join(dir, "generated.proto")
+
+	t.Run("unchanged specs are compatible", func(t *testing.T) {
+		changes, err := CheckCompatibility(path, newSpec())
+		if err != nil {
+			t.Fatalf("CheckCompatibility() error = %v", err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("CheckCompatibility() = %v, want no changes", changes)
+		}
+	})
+
+	t.Run("reports changes against the generated file", func(t *testing.T) {
+		old := `syntax = "proto3";
+
+message TestRequest1 {
+  int64 data = 1;
+}
+
+service TestService1 {
+  rpc TestMethod(TestRequest1) returns (TestResponse1);
+  rpc OtherMethod(TestRequest1) returns (TestResponse1);
+}
+`
+		oldPath := filepath.Join(dir, "old.proto")
+		if err := os.WriteFile(oldPath, []byte(old), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		changes, err := CheckCompatibility(oldPath, newSpec())
+		if err != nil {
+			t.Fatalf("CheckCompatibility() error = %v", err)
+		}
+		want := []BreakingChange{
+			{Location: "TestService1.OtherMethod", Description: "method was removed"},
+			{Location: "TestRequest1.data", Description: "type of field 1 changed from int64 to string"},
+		}
+		if diff := cmp.Diff(want, changes); diff != "" {
+			t.Errorf("CheckCompatibility() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("fails on a missing file", func(t *testing.T) {
+		if _, err := CheckCompatibility(filepath.Join(dir, "missing.proto"), newSpec()); err == nil {
+			t.Error("CheckCompatibility() error = nil, want an error")
+		}
+	})
+}
+
+type testLockedRequest struct {
+	Message[*TestService1, *TestService1]
+	A string
+	B string
+}
+
+func TestCheckCompatibilityWithOptions_lockFile(t *testing.T) {
+	spec := NewServiceSpec(&TestService1{})
+	state := &TestIdleState{}
+	spec.DefineStates(state).SetInitialState(state)
+	OnMessage(spec, state, "TestMethod",
+		func(ctx context.Context, event *testLockedRequest, sm *TestService1) Response[*TestResponse1] {
+			return SendTo(ctx, sm, &TestResponse1{})
+		})
+
+	// The lock numbers the fields against their declaration order.
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "proto.lock.json")
+	lock := `{"messages": {"testLockedRequest": {"fields": {
+  "b": {"number": 1, "type": "string"},
+  "a": {"number": 2, "type": "string"}
+}}}}`
+	if err := os.WriteFile(lockPath, []byte(lock), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := GenerateOptions{OutputDir: dir, LockFile: lockPath}
+	if err := Generate(opts, spec); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	path := filepath.Join(dir, "generated.proto")
+
+	changes, err := CheckCompatibilityWithOptions(path, opts, spec)
+	if err != nil {
+		t.Fatalf("CheckCompatibilityWithOptions() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("CheckCompatibilityWithOptions() = %v, want no changes", changes)
+	}
+
+	changes, err = CheckCompatibility(path, spec)
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	want := []BreakingChange{
+		{Location: "testLockedRequest.a", Description: "field 2 was renamed to b, which breaks JSON clients"},
+		{Location: "testLockedRequest.b", Description: "field 1 was renamed to a, which breaks JSON clients"},
+	}
+	if diff := cmp.Diff(want, changes); diff != "" {
+		t.Errorf("CheckCompatibility() without the lock file mismatch (-want +got):\n%s", diff)
+	}
+}
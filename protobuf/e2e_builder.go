@@ -3,6 +3,7 @@ package protobuf
 import (
 	"fmt"
 	"reflect"
+	"slices"
 
 	"github.com/goatx/goat"
 	"github.com/goatx/goat/internal/e2egen"
@@ -25,26 +26,31 @@ func buildTestSuite(opts E2ETestOptions) (e2egen.TestSuite, error) {
 
 		for _, method := range svc.Methods {
 			cases := make([]e2egen.TestCase, 0, len(method.TestInputs))
+			inputs := make([]map[string]any, 0, len(method.TestInputs))
 
 			for ii, input := range method.TestInputs {
-				output, err := executeHandler(svc.Spec, method.MethodName, input)
+				output, status, err := executeHandler(svc.Spec, method.MethodName, input)
 				if err != nil {
 					return e2egen.TestSuite{}, fmt.Errorf("service (%s) method (%s) input (%d): failed to execute handler: %w",
 						serviceName, method.MethodName, ii, err)
 				}
-
-				inputData := serializeMessage(input)
-				outputData := serializeMessage(output)
+				inputs = append(inputs, serializeMessage(input))
+				// The inputs the handler fails for are among the errors of
+				// the method, added below.
+				if status != nil {
+					continue
+				}
 
 				cases = append(cases, e2egen.TestCase{
 					Name:       fmt.Sprintf("case_%d", ii),
 					InputType:  typeutil.Name(input),
-					Input:      inputData,
+					Input:      inputs[ii],
 					OutputType: typeutil.Name(output),
-					Output:     outputData,
+					Output:     serializeMessage(output),
 				})
 			}
 
+			cases = append(cases, errorCases(svc.Spec, method.MethodName, inputs)...)
 			methods = append(methods, e2egen.Operation{
 				Name:      method.MethodName,
 				TestCases: cases,
@@ -61,20 +67,66 @@ func buildTestSuite(opts E2ETestOptions) (e2egen.TestSuite, error) {
 	return suite, nil
 }
 
-func executeHandler(spec AbstractServiceSpec, methodName string, input AbstractMessage) (AbstractMessage, error) {
+// errorCases returns the test cases expecting the errors recorded for a
+// method: those of the test inputs, whose serialized fields are inputs, named
+// after their position, and those the model checker ran into.
+func errorCases(spec AbstractServiceSpec, methodName string, inputs []map[string]any) []e2egen.TestCase {
+	var cases []e2egen.TestCase
+	for _, m := range spec.getRPCMethods() {
+		if m.MethodName != methodName {
+			continue
+		}
+		for i, e := range m.Errors {
+			name := fmt.Sprintf("error_%d", i)
+			if j := slices.IndexFunc(inputs, func(input map[string]any) bool { return reflect.DeepEqual(input, e.Input) }); j >= 0 {
+				name = fmt.Sprintf("case_%d", j)
+			}
+			cases = append(cases, e2egen.TestCase{
+				Name:         name,
+				InputType:    e.InputType,
+				Input:        e.Input,
+				OutputType:   m.OutputType,
+				ErrorCode:    e.Code.String(),
+				ErrorMessage: e.Message,
+			})
+		}
+	}
+	return cases
+}
+
+// executeHandler runs the handler of a unary method with input, returning
+// its output or the Status it fails with.
+func executeHandler(spec AbstractServiceSpec, methodName string, input AbstractMessage) (AbstractMessage, *Status, error) {
+	for _, m := range spec.getRPCMethods() {
+		if m.MethodName == methodName && (m.InputType == emptyType || m.OutputType == emptyType) {
+			return nil, nil, fmt.Errorf("method %s uses %s, which E2E tests do not cover", methodName, emptyType)
+		}
+	}
+
 	handlers := spec.getHandlers()
 	handler, ok := handlers[methodName]
 	if !ok {
-		return nil, fmt.Errorf("no handler found for method %s", methodName)
+		for _, m := range spec.getRPCMethods() {
+			if m.MethodName == methodName && (m.ClientStreaming || m.ServerStreaming) {
+				return nil, nil, fmt.Errorf("method %s is a streaming method, E2E tests only cover unary methods", methodName)
+			}
+		}
+		return nil, nil, fmt.Errorf("no handler found for method %s", methodName)
 	}
 
 	instance, err := spec.newInstance()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create state machine instance: %w", err)
+		return nil, nil, fmt.Errorf("failed to create state machine instance: %w", err)
 	}
 
 	ctx := goat.NewHandlerContext(instance)
-	return handler(ctx, input, instance), nil
+	output, status := handler(ctx, input, instance)
+	if status != nil {
+		if err := checkCode(status.Code); err != nil {
+			return nil, nil, err
+		}
+	}
+	return output, status, nil
 }
 
 func serializeMessage(msg AbstractMessage) map[string]any {
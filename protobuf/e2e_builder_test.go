@@ -6,6 +6,7 @@ import (
 
 	"github.com/goatx/goat"
 	"github.com/goatx/goat/internal/e2egen"
+	"github.com/goatx/goat/protobuf/codes"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
@@ -57,6 +58,7 @@ func TestBuildTestSuite(t *testing.T) {
 							MethodName: "Do",
 							TestInputs: []AbstractMessage{
 								&TestRequest1{Data: "foo"},
+								&TestRequest1{},
 							},
 						},
 					},
@@ -86,6 +88,14 @@ func TestBuildTestSuite(t *testing.T) {
 									OutputType: "TestResponse1",
 									Output:     map[string]any{"Result": "foo_out"},
 								},
+								{
+									Name:         "case_1",
+									InputType:    "TestRequest1",
+									Input:        map[string]any{"Data": ""},
+									OutputType:   "TestResponse1",
+									ErrorCode:    "InvalidArgument",
+									ErrorMessage: "data is required",
+								},
 							},
 						},
 					},
@@ -99,14 +109,60 @@ func TestBuildTestSuite(t *testing.T) {
 	})
 }
 
+func TestBuildTestSuite_recordedErrors(t *testing.T) {
+	spec := newTestSpec()
+	// The handler failed for an input before, as in model checking.
+	if _, _, err := executeHandler(spec, "Do", &TestRequest1{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := buildTestSuite(E2ETestOptions{
+		ServiceSchemaPackage: "mypkg",
+		Services: []ServiceTestCase{
+			{
+				Spec:        spec,
+				TestPackage: "example/pkg",
+				Methods: []MethodTestCase{
+					{MethodName: "Do", TestInputs: []AbstractMessage{&TestRequest1{Data: "foo"}}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("buildTestSuite() error = %v", err)
+	}
+
+	want := []e2egen.TestCase{
+		{
+			Name:       "case_0",
+			InputType:  "TestRequest1",
+			Input:      map[string]any{"Data": "foo"},
+			OutputType: "TestResponse1",
+			Output:     map[string]any{"Result": "foo_out"},
+		},
+		{
+			Name:         "error_0",
+			InputType:    "TestRequest1",
+			Input:        map[string]any{"Data": ""},
+			OutputType:   "TestResponse1",
+			ErrorCode:    "InvalidArgument",
+			ErrorMessage: "data is required",
+		},
+	}
+	if diff := cmp.Diff(want, got.Groups[0].Operations[0].TestCases); diff != "" {
+		t.Errorf("buildTestSuite() test cases mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestExecuteHandler(t *testing.T) {
 	tests := []struct {
-		name    string
-		spec    AbstractServiceSpec
-		method  string
-		input   AbstractMessage
-		want    AbstractMessage
-		wantErr bool
+		name       string
+		spec       AbstractServiceSpec
+		method     string
+		input      AbstractMessage
+		want       AbstractMessage
+		wantStatus *Status
+		wantErr    bool
 	}{
 		{
 			name:   "success",
@@ -115,6 +171,13 @@ func TestExecuteHandler(t *testing.T) {
 			input:  &TestRequest1{Data: "in"},
 			want:   &TestResponse1{Result: "in_out"},
 		},
+		{
+			name:       "error",
+			spec:       newTestSpec(),
+			method:     "Do",
+			input:      &TestRequest1{},
+			wantStatus: &Status{Code: codes.InvalidArgument, Message: "data is required"},
+		},
 		{
 			name:    "missing handler",
 			spec:    newTestSpec(),
@@ -122,11 +185,18 @@ func TestExecuteHandler(t *testing.T) {
 			input:   &TestRequest1{},
 			wantErr: true,
 		},
+		{
+			name:    "method using Empty",
+			spec:    newTestUserSpec(),
+			method:  "Ping",
+			input:   &Empty[*TestService1, *TestService1]{},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := executeHandler(tt.spec, tt.method, tt.input)
+			got, gotStatus, err := executeHandler(tt.spec, tt.method, tt.input)
 			if tt.wantErr {
 				if err == nil {
 					t.Fatal("executeHandler() expected error, got nil")
@@ -145,6 +215,13 @@ func TestExecuteHandler(t *testing.T) {
 			); diff != "" {
 				t.Fatalf("executeHandler() mismatch (-want +got):\n%s", diff)
 			}
+			if diff := cmp.Diff(tt.wantStatus, gotStatus,
+				cmpopts.IgnoreUnexported(
+					goat.Event[goat.AbstractStateMachine, goat.AbstractStateMachine]{},
+				),
+			); diff != "" {
+				t.Fatalf("executeHandler() status mismatch (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
@@ -156,6 +233,9 @@ func newTestSpec() *ServiceSpec[*TestService1] {
 
 	OnMessage(spec, idle, "Do",
 		func(ctx context.Context, req *TestRequest1, sm *TestService1) Response[*TestResponse1] {
+			if req.Data == "" {
+				return SendError[*TestResponse1](ctx, sm, codes.InvalidArgument, "data is required")
+			}
 			return SendTo(ctx, sm, &TestResponse1{Result: req.Data + "_out"})
 		})
 
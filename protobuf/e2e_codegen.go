@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/format"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -119,6 +120,9 @@ func (s *testSuite) generateServiceTest(group e2egen.TestGroup) (string, error)
 	b.WriteString("package ")
 	b.WriteString(s.suite.TestPackage)
 	b.WriteString("\n\nimport (\n\t\"testing\"\n\n\t\"github.com/google/go-cmp/cmp\"\n")
+	if slices.ContainsFunc(group.Operations, hasErrorCases) {
+		b.WriteString("\t\"google.golang.org/grpc/codes\"\n\t\"google.golang.org/grpc/status\"\n")
+	}
 	b.WriteString("\tpb")
 	b.WriteString(snake)
 	b.WriteString(" \"")
@@ -161,6 +165,10 @@ func (*testSuite) writeMethodTest(b *strings.Builder, group e2egen.TestGroup, op
 	b.WriteString(alias)
 	b.WriteString(".")
 	b.WriteString(first.OutputType)
+	errorCases := hasErrorCases(op)
+	if errorCases {
+		b.WriteString("\n\t\tcode     codes.Code\n\t\tmessage  string")
+	}
 	b.WriteString("\n\t}{\n")
 
 	for _, tc := range op.TestCases {
@@ -171,9 +179,18 @@ func (*testSuite) writeMethodTest(b *strings.Builder, group e2egen.TestGroup, op
 		b.WriteString("\t\t\tinput: ")
 		b.WriteString(formatStructLiteral(alias, tc.InputType, tc.Input))
 		b.WriteString(",\n")
-		b.WriteString("\t\t\texpected: ")
-		b.WriteString(formatStructLiteral(alias, tc.OutputType, tc.Output))
-		b.WriteString(",\n")
+		if tc.ErrorCode != "" {
+			b.WriteString("\t\t\tcode: codes.")
+			b.WriteString(tc.ErrorCode)
+			b.WriteString(",\n")
+			b.WriteString("\t\t\tmessage: ")
+			b.WriteString(strconv.Quote(tc.ErrorMessage))
+			b.WriteString(",\n")
+		} else {
+			b.WriteString("\t\t\texpected: ")
+			b.WriteString(formatStructLiteral(alias, tc.OutputType, tc.Output))
+			b.WriteString(",\n")
+		}
 		b.WriteString("\t\t},\n")
 	}
 
@@ -185,6 +202,14 @@ func (*testSuite) writeMethodTest(b *strings.Builder, group e2egen.TestGroup, op
 	b.WriteString(op.Name)
 	b.WriteString("(t.Context(), tt.input)\n")
 
+	if errorCases {
+		b.WriteString("\t\t\tif tt.code != codes.OK {\n")
+		b.WriteString("\t\t\t\tif st := status.Convert(err); st.Code() != tt.code || st.Message() != tt.message {\n")
+		b.WriteString("\t\t\t\t\tt.Fatalf(\"")
+		b.WriteString(op.Name)
+		b.WriteString(" error = %v, want %v: %s\", err, tt.code, tt.message)\n")
+		b.WriteString("\t\t\t\t}\n\t\t\t\treturn\n\t\t\t}\n")
+	}
 	b.WriteString("\t\t\tif err != nil {\n\t\t\t\tt.Fatalf(\"RPC call failed: %v\", err)\n\t\t\t}\n")
 
 	b.WriteString("\t\t\tif diff := cmp.Diff(tt.expected, actual); diff != \"\" {\n\t\t\t\tt.Errorf(\"")
@@ -196,6 +221,13 @@ func (*testSuite) writeMethodTest(b *strings.Builder, group e2egen.TestGroup, op
 	return nil
 }
 
+// hasErrorCases reports whether some test case of op expects an error.
+func hasErrorCases(op e2egen.Operation) bool {
+	return slices.ContainsFunc(op.TestCases, func(tc e2egen.TestCase) bool {
+		return tc.ErrorCode != ""
+	})
+}
+
 func formatStructLiteral(pkgAlias, typeName string, data map[string]any) string {
 	if len(data) == 0 {
 		return "&" + pkgAlias + "." + typeName + "{}"
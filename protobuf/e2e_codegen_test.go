@@ -221,3 +221,103 @@ func TestCreateUser(t *testing.T) {
 		t.Fatalf("generateServiceTest() mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestGenerateServiceTest_errorCases(t *testing.T) {
+	t.Parallel()
+
+	ts := &testSuite{suite: e2egen.TestSuite{TestPackage: "testpkg"}}
+
+	group := e2egen.TestGroup{
+		Name:          "User",
+		SchemaPackage: "github.com/example/user",
+		Operations: []e2egen.Operation{
+			{
+				Name: "GetUser",
+				TestCases: []e2egen.TestCase{
+					{
+						Name:       "case_0",
+						InputType:  "GetRequest",
+						Input:      map[string]any{"Id": "1"},
+						OutputType: "GetResponse",
+						Output:     map[string]any{"Name": "alice"},
+					},
+					{
+						Name:         "case_1",
+						InputType:    "GetRequest",
+						Input:        map[string]any{"Id": "2"},
+						OutputType:   "GetResponse",
+						ErrorCode:    "NotFound",
+						ErrorMessage: "user 2 not found",
+					},
+				},
+			},
+		},
+	}
+
+	got, err := ts.generateServiceTest(group)
+	if err != nil {
+		t.Fatalf("generateServiceTest() error = %v", err)
+	}
+
+	want := `package testpkg
+
+import (
+	"testing"
+
+	pbuser "github.com/example/user"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    *pbuser.GetRequest
+		expected *pbuser.GetResponse
+		code     codes.Code
+		message  string
+	}{
+		{
+			name: "case_0",
+			input: &pbuser.GetRequest{
+				Id: "1",
+			},
+			expected: &pbuser.GetResponse{
+				Name: "alice",
+			},
+		},
+		{
+			name: "case_1",
+			input: &pbuser.GetRequest{
+				Id: "2",
+			},
+			code:    codes.NotFound,
+			message: "user 2 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := userClient.GetUser(t.Context(), tt.input)
+			if tt.code != codes.OK {
+				if st := status.Convert(err); st.Code() != tt.code || st.Message() != tt.message {
+					t.Fatalf("GetUser error = %v, want %v: %s", err, tt.code, tt.message)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RPC call failed: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("GetUser mismatch (-expected +actual):\n%s", diff)
+			}
+		})
+	}
+}
+`
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("generateServiceTest() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -13,6 +13,9 @@ const (
 	defaultServiceSchemaPackage = "main"
 )
 
+// MethodTestCase lists the inputs a method is tested with. The generated
+// tests also expect the errors the method failed with through SendError in
+// model checking run before, for the inputs it failed for.
 type MethodTestCase struct {
 	MethodName string
 	TestInputs []AbstractMessage
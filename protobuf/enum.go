@@ -0,0 +1,153 @@
+package protobuf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goatx/goat/internal/enums"
+	"github.com/goatx/goat/internal/strcase"
+)
+
+type enum struct {
+	Name   string
+	Values []enumValue
+}
+
+type enumValue struct {
+	Name   string
+	Number int
+}
+
+// EnumType is the set of Go types that can be registered with RegisterEnum.
+type EnumType = enums.Type
+
+// RegisterEnum declares the values of a named string or integer type, so that
+// message fields of that type are generated as a proto enum instead of a
+// plain string or integer. Go has no way to list the constants of a type at
+// run time, which is why they must be passed here.
+//
+// The enum and its values are prefixed with the type name, in upper snake
+// case, as proto enum values share the scope of the package. A string type
+// gets TYPE_UNSPECIFIED = 0 followed by its values numbered from 1 in the
+// order given; the empty string is TYPE_UNSPECIFIED. An integer type keeps
+// the numbers of its values and names them with their String method when
+// they have one; TYPE_UNSPECIFIED = 0 is added when no value is 0, as proto3
+// requires.
+//
+// The values are shared with openapi.RegisterEnum: a type registered with
+// either one is an enum in the schemas of both. RegisterEnum must be called
+// before OnMessage for the messages using the type.
+//
+// Parameters:
+//   - values: Every value of the type
+//
+// Example:
+//
+//	type Role string
+//
+//	const (
+//	    RoleAdmin  Role = "admin"
+//	    RoleMember Role = "member"
+//	)
+//
+//	protobuf.RegisterEnum(RoleAdmin, RoleMember)
+func RegisterEnum[E EnumType](values ...E) {
+	enums.Register(values...)
+}
+
+// lookupEnum returns the enum registered for t, or nil.
+func lookupEnum(t reflect.Type) *enum {
+	e, _ := protoEnum(t)
+	return e
+}
+
+// lookupEnumGoValues returns the Go values of a registered string enum by
+// number, which GenerateServer converts from and to.
+func lookupEnumGoValues(t reflect.Type) map[int]string {
+	_, goValues := protoEnum(t)
+	return goValues
+}
+
+// protoEnum numbers and names the values registered for t, returning nil
+// when there are none, along with the Go values of a string enum by number.
+func protoEnum(t reflect.Type) (*enum, map[int]string) {
+	values := enums.Lookup(t)
+	if values == nil {
+		return nil, nil
+	}
+	e := &enum{Name: t.Name()}
+	prefix := strings.ToUpper(strcase.ToSnakeCase(t.Name())) + "_"
+
+	goValues := make(map[int]string)
+	if t.Kind() == reflect.String {
+		e.Values = append(e.Values, enumValue{Name: prefix + "UNSPECIFIED", Number: 0})
+		for _, v := range values {
+			if v.Literal == "" {
+				continue
+			}
+			goValues[len(e.Values)] = v.Literal
+			e.Values = append(e.Values, enumValue{Name: prefix + enumValueName(v.Name), Number: len(e.Values)})
+		}
+	} else {
+		hasZero := false
+		for _, v := range values {
+			number, _ := strconv.ParseInt(v.Literal, 10, 64)
+			hasZero = hasZero || number == 0
+			e.Values = append(e.Values, enumValue{Name: prefix + enumValueName(v.Name), Number: int(number)})
+		}
+		if !hasZero {
+			e.Values = append(e.Values, enumValue{Name: prefix + "UNSPECIFIED", Number: 0})
+		}
+		sort.SliceStable(e.Values, func(i, j int) bool {
+			return e.Values[i].Number < e.Values[j].Number
+		})
+	}
+	return e, goValues
+}
+
+// enumItemType returns the registered enum type t is, or holds the items of
+// as a list, or nil when there is none.
+func enumItemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8 {
+		t = t.Elem()
+	}
+	if lookupEnum(t) == nil {
+		return nil
+	}
+	return t
+}
+
+// enumNumber returns the number of a value of the registered enum t, given
+// as the Go value of a string enum or the number of an integer enum.
+func enumNumber(t reflect.Type, value string) (int, error) {
+	if t.Kind() == reflect.String {
+		for number, goValue := range lookupEnumGoValues(t) {
+			if goValue == value {
+				return number, nil
+			}
+		}
+	} else if number, err := strconv.Atoi(value); err == nil {
+		for _, v := range lookupEnum(t).Values {
+			if v.Number == number {
+				return number, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%q is not a registered value of %s", value, t.Name())
+}
+
+// enumValueName turns a Go value such as "in-progress" or "InProgress" into
+// the suffix of a proto enum value name, IN_PROGRESS.
+func enumValueName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, strcase.ToSnakeCase(s))
+	return strings.ToUpper(name)
+}
@@ -0,0 +1,70 @@
+package protobuf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type testLevel uint8
+
+type testColor string
+
+func TestRegisterEnum(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func()
+		goType   reflect.Type
+		want     *enum
+	}{
+		{
+			name:     "string values are numbered from 1 in order",
+			register: func() { RegisterEnum[testColor]("", "dark-red", "LightBlue") },
+			goType:   reflect.TypeOf(testColor("")),
+			want: &enum{
+				Name: "testColor",
+				Values: []enumValue{
+					{Name: "TEST_COLOR_UNSPECIFIED", Number: 0},
+					{Name: "TEST_COLOR_DARK_RED", Number: 1},
+					{Name: "TEST_COLOR_LIGHT_BLUE", Number: 2},
+				},
+			},
+		},
+		{
+			name:     "integer values keep their numbers and are sorted",
+			register: func() { RegisterEnum[testLevel](5, 0, 2) },
+			goType:   reflect.TypeOf(testLevel(0)),
+			want: &enum{
+				Name: "testLevel",
+				Values: []enumValue{
+					{Name: "TEST_LEVEL_0", Number: 0},
+					{Name: "TEST_LEVEL_2", Number: 2},
+					{Name: "TEST_LEVEL_5", Number: 5},
+				},
+			},
+		},
+		{
+			name:     "integer values are named with String",
+			register: func() { RegisterEnum(TestPriorityHigh, TestPriorityLow) },
+			goType:   reflect.TypeOf(TestPriority(0)),
+			want: &enum{
+				Name: "TestPriority",
+				Values: []enumValue{
+					{Name: "TEST_PRIORITY_UNSPECIFIED", Number: 0},
+					{Name: "TEST_PRIORITY_LOW", Number: 1},
+					{Name: "TEST_PRIORITY_HIGH", Number: 2},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.register()
+			if diff := cmp.Diff(tt.want, lookupEnum(tt.goType)); diff != "" {
+				t.Errorf("RegisterEnum() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/goatx/goat"
 	"github.com/goatx/goat/protobuf"
+	"github.com/goatx/goat/protobuf/codes"
 )
 
 type UserService struct {
@@ -70,6 +71,9 @@ func createUserServiceModel() *protobuf.ServiceSpec[*UserService] {
 
 	protobuf.OnMessage(spec, idleState, "GetUser",
 		func(ctx context.Context, event *GetUserRequest, service *UserService) protobuf.Response[*GetUserResponse] {
+			if event.UserID != "user_123" {
+				return protobuf.SendError[*GetUserResponse](ctx, service, codes.NotFound, "user not found")
+			}
 			response := &GetUserResponse{
 				Username: "testuser",
 				Email:    "test@example.com",
@@ -114,6 +118,7 @@ func main() {
 						MethodName: "GetUser",
 						TestInputs: []protobuf.AbstractMessage{
 							&GetUserRequest{UserID: "user_123"},
+							&GetUserRequest{UserID: "user_999"},
 						},
 					},
 				},
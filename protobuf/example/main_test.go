@@ -71,6 +71,7 @@ func TestUserServiceE2EGeneration(t *testing.T) {
 						MethodName: "GetUser",
 						TestInputs: []protobuf.AbstractMessage{
 							&GetUserRequest{UserID: "user_123"},
+							&GetUserRequest{UserID: "user_999"},
 						},
 					},
 				},
@@ -111,3 +112,13 @@ func TestUserServiceE2EGeneration(t *testing.T) {
 		}
 	}
 }
+
+func TestUserServiceProtobufCompatibility(t *testing.T) {
+	changes, err := protobuf.CheckCompatibility(filepath.Join("proto", "user_service.proto.golden"), createUserServiceModel())
+	if err != nil {
+		t.Fatalf("CheckCompatibility() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("CheckCompatibility() = %v, want no breaking changes", changes)
+	}
+}
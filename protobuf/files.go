@@ -0,0 +1,181 @@
+package protobuf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProtoFile describes one of the files Generate writes when
+// GenerateOptions.Files is set.
+type ProtoFile struct {
+	// Filename is the path of the file, relative to the output directory.
+	// Other files import it by this path.
+	Filename    string
+	PackageName string
+	GoPackage   string
+	// Options are further file options, such as "java_package", written as
+	// `option name = value;` in name order. Values are written as is, so
+	// string values need their quotes.
+	Options map[string]string
+	// Services are the services the file defines. The messages and enums
+	// only they use are defined in the file too.
+	Services []AbstractServiceSpec
+}
+
+// protoFile is a file of the output with its definitions.
+type protoFile struct {
+	ProtoFile
+	definitions *definitions
+}
+
+// splitDefinitions places definitions in files: each service goes to the
+// file listing it, and each message and enum to the file of the services
+// using it, or to common when services of several files use it. Types
+// defined in another file are imported and, when the package differs,
+// qualified with the package name.
+//
+// Returns the files with definitions, common last, and an error if a
+// service is in no file or in several.
+func splitDefinitions(defs *definitions, files []ProtoFile, common ProtoFile) ([]*protoFile, error) {
+	all := append(append([]ProtoFile{}, files...), common)
+	commonIndex := len(files)
+
+	serviceFile := make(map[string]int)
+	for i, file := range files {
+		for _, spec := range file.Services {
+			name := spec.getServiceName()
+			if other, ok := serviceFile[name]; ok && other != i {
+				return nil, fmt.Errorf("service %s is in both %s and %s", name, files[other].Filename, file.Filename)
+			}
+			serviceFile[name] = i
+		}
+	}
+
+	// users records the files whose services use each message and enum,
+	// directly or through the fields of other messages.
+	users := make(map[string]map[int]bool)
+	use := func(typeName string, file int) bool {
+		if users[typeName] == nil {
+			users[typeName] = make(map[int]bool)
+		}
+		if users[typeName][file] {
+			return false
+		}
+		users[typeName][file] = true
+		return true
+	}
+	for _, s := range defs.Services {
+		file, ok := serviceFile[s.Name]
+		if !ok {
+			return nil, fmt.Errorf("service %s is not in any of GenerateOptions.Files", s.Name)
+		}
+		for _, m := range s.Methods {
+			use(m.InputType, file)
+			use(m.OutputType, file)
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, msg := range defs.Messages {
+			for file := range users[msg.Name] {
+				for _, f := range msg.Fields {
+					for _, typeName := range referencedTypes(f.Type) {
+						if use(typeName, file) {
+							changed = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	fileOf := func(typeName string) int {
+		if len(users[typeName]) != 1 {
+			return commonIndex
+		}
+		for file := range users[typeName] {
+			return file
+		}
+		return commonIndex
+	}
+	defined := make(map[string]int)
+	for _, msg := range defs.Messages {
+		defined[msg.Name] = fileOf(msg.Name)
+	}
+	for _, e := range defs.Enums {
+		defined[e.Name] = fileOf(e.Name)
+	}
+
+	result := make([]*protoFile, len(all))
+	imports := make([]map[string]bool, len(all))
+	for i, file := range all {
+		result[i] = &protoFile{
+			ProtoFile:   file,
+			definitions: &definitions{Messages: []*message{}, Services: []*service{}},
+		}
+		imports[i] = make(map[string]bool)
+	}
+	// resolve returns how file i refers to typeName, importing the file
+	// defining it.
+	resolve := func(i int, typeName string) string {
+		file, ok := defined[typeName]
+		if !ok || file == i {
+			return typeName
+		}
+		imports[i][all[file].Filename] = true
+		if pkg := all[file].PackageName; pkg != "" && pkg != all[i].PackageName {
+			return pkg + "." + typeName
+		}
+		return typeName
+	}
+
+	for _, e := range defs.Enums {
+		d := result[defined[e.Name]].definitions
+		d.Enums = append(d.Enums, e)
+	}
+	for _, msg := range defs.Messages {
+		i := defined[msg.Name]
+		clone := *msg
+		clone.Fields = make([]field, len(msg.Fields))
+		for j, f := range msg.Fields {
+			if types := referencedTypes(f.Type); len(types) == 2 {
+				f.Type = fmt.Sprintf("map<%s, %s>", resolve(i, types[0]), resolve(i, types[1]))
+			} else {
+				f.Type = resolve(i, f.Type)
+			}
+			clone.Fields[j] = f
+		}
+		result[i].definitions.Messages = append(result[i].definitions.Messages, &clone)
+	}
+	for _, s := range defs.Services {
+		i := serviceFile[s.Name]
+		clone := *s
+		clone.Methods = make([]method, len(s.Methods))
+		for j, m := range s.Methods {
+			m.InputType = resolve(i, m.InputType)
+			m.OutputType = resolve(i, m.OutputType)
+			clone.Methods[j] = m
+		}
+		result[i].definitions.Services = append(result[i].definitions.Services, &clone)
+	}
+
+	var written []*protoFile
+	for i, file := range result {
+		d := file.definitions
+		if i == commonIndex && len(d.Messages) == 0 && len(d.Enums) == 0 {
+			continue
+		}
+		d.Imports = sortedKeys(imports[i])
+		written = append(written, file)
+	}
+	return written, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,269 @@
+package protobuf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type TestUser struct {
+	Message[*TestService1, *TestService1]
+	Name      string
+	Home      TestAddress
+	CreatedAt time.Time
+}
+
+type TestAddressBook struct {
+	Message[*TestService2, *TestService2]
+	Addresses []TestAddress
+}
+
+func newTestUserSpec() *ServiceSpec[*TestService1] {
+	spec := NewServiceSpec(&TestService1{})
+	state := &TestIdleState{}
+	spec.DefineStates(state).SetInitialState(state)
+	OnMessage(spec, state, "GetUser",
+		func(ctx context.Context, event *TestRequest1, sm *TestService1) Response[*TestUser] {
+			return SendTo(ctx, sm, &TestUser{Name: event.Data})
+		})
+	OnMessage(spec, state, "Ping",
+		func(ctx context.Context, event *Empty[*TestService1, *TestService1], sm *TestService1) Response[*Empty[*TestService1, *TestService1]] {
+			return SendTo(ctx, sm, &Empty[*TestService1, *TestService1]{})
+		})
+	spec.SetOption("deprecated", "true")
+	spec.SetMethodOption("Ping", "idempotency_level", "NO_SIDE_EFFECTS")
+	return spec
+}
+
+func newTestAddressBookSpec() *ServiceSpec[*TestService2] {
+	spec := NewServiceSpec(&TestService2{})
+	state := &TestIdleState{}
+	spec.DefineStates(state).SetInitialState(state)
+	OnMessage(spec, state, "ListAddresses",
+		func(ctx context.Context, event *TestRequest2, sm *TestService2) Response[*TestAddressBook] {
+			return SendTo(ctx, sm, &TestAddressBook{})
+		})
+	return spec
+}
+
+func TestGenerate_files(t *testing.T) {
+	dir := t.TempDir()
+	userSpec := newTestUserSpec()
+	addressBookSpec := newTestAddressBookSpec()
+
+	opts := GenerateOptions{
+		OutputDir:   dir,
+		PackageName: "shared.v1",
+		Options:     map[string]string{"java_multiple_files": "true"},
+		Files: []ProtoFile{
+			{
+				Filename:    "user/v1/user.proto",
+				PackageName: "user.v1",
+				GoPackage:   "example.com/gen/userpb",
+				Services:    []AbstractServiceSpec{userSpec},
+			},
+			{
+				Filename:    "address_book.proto",
+				PackageName: "address.v1",
+				Options:     map[string]string{"java_package": "\"com.example.address\""},
+				Services:    []AbstractServiceSpec{addressBookSpec},
+			},
+		},
+		Warnings: &strings.Builder{},
+	}
+	if err := Generate(opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	want := map[string]string{
+		"common.proto": `syntax = "proto3";
+
+package shared.v1;
+
+option java_multiple_files = true;
+
+message TestAddress {
+  string city = 1;
+  repeated string lines = 2;
+}
+`,
+		"user/v1/user.proto": `syntax = "proto3";
+
+package user.v1;
+
+import "common.proto";
+import "google/protobuf/empty.proto";
+import "google/protobuf/timestamp.proto";
+
+option go_package = "example.com/gen/userpb";
+
+message TestRequest1 {
+  string data = 1;
+}
+
+message TestUser {
+  string name = 1;
+  shared.v1.TestAddress home = 2;
+  google.protobuf.Timestamp created_at = 3;
+}
+
+service TestService1 {
+  option deprecated = true;
+
+  rpc GetUser(TestRequest1) returns (TestUser);
+  rpc Ping(google.protobuf.Empty) returns (google.protobuf.Empty) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+  }
+}
+`,
+		"address_book.proto": `syntax = "proto3";
+
+package address.v1;
+
+import "common.proto";
+
+option java_package = "com.example.address";
+
+message TestAddressBook {
+  repeated shared.v1.TestAddress addresses = 1;
+}
+
+message TestRequest2 {
+  string info = 1;
+}
+
+service TestService2 {
+  rpc ListAddresses(TestRequest2) returns (TestAddressBook);
+}
+`,
+	}
+	for filename, wantContent := range want {
+		// #nosec G304 - the file is in t.TempDir()
+		content, err := os.ReadFile(filepath.Join(dir, filename))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", filename, err)
+		}
+		if diff := cmp.Diff(wantContent, string(content)); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", filename, diff)
+		}
+	}
+}
+
+func TestGenerate_filesWithoutCommonDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	opts := GenerateOptions{
+		OutputDir: dir,
+		Files: []ProtoFile{
+			{Filename: "user.proto", Services: []AbstractServiceSpec{newTestUserSpec()}},
+		},
+	}
+	if err := Generate(opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "common.proto")); !os.IsNotExist(err) {
+		t.Errorf("common.proto was written, want no file as no definitions are shared")
+	}
+	// #nosec G304 - the file is in t.TempDir()
+	content, err := os.ReadFile(filepath.Join(dir, "user.proto"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(content), "  TestAddress home = 2;\n") {
+		t.Errorf("user.proto does not define TestAddress in place:\n%s", content)
+	}
+}
+
+func TestGenerate_filesErrors(t *testing.T) {
+	userSpec := newTestUserSpec()
+	addressBookSpec := newTestAddressBookSpec()
+
+	tests := []struct {
+		name    string
+		files   []ProtoFile
+		specs   []AbstractServiceSpec
+		wantErr string
+	}{
+		{
+			name: "service in no file",
+			files: []ProtoFile{
+				{Filename: "user.proto", Services: []AbstractServiceSpec{userSpec}},
+			},
+			specs:   []AbstractServiceSpec{addressBookSpec},
+			wantErr: "service TestService2 is not in any of GenerateOptions.Files",
+		},
+		{
+			name: "service in two files",
+			files: []ProtoFile{
+				{Filename: "user.proto", Services: []AbstractServiceSpec{userSpec}},
+				{Filename: "other.proto", Services: []AbstractServiceSpec{userSpec}},
+			},
+			wantErr: "service TestService1 is in both user.proto and other.proto",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := GenerateOptions{OutputDir: t.TempDir(), Files: tt.files}
+			err := Generate(opts, tt.specs...)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Generate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckCompatibilityWithOptions_files(t *testing.T) {
+	dir := t.TempDir()
+	opts := GenerateOptions{
+		OutputDir:   dir,
+		PackageName: "shared.v1",
+		Files: []ProtoFile{
+			{Filename: "user/v1/user.proto", PackageName: "user.v1", Services: []AbstractServiceSpec{newTestUserSpec()}},
+			{Filename: "address_book.proto", PackageName: "address.v1", Services: []AbstractServiceSpec{newTestAddressBookSpec()}},
+		},
+		Warnings: &strings.Builder{},
+	}
+	if err := Generate(opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for _, name := range []string{"common.proto", "user/v1/user.proto", "address_book.proto"} {
+		changes, err := CheckCompatibilityWithOptions(filepath.Join(dir, name), opts)
+		if err != nil {
+			t.Fatalf("CheckCompatibilityWithOptions(%s) error = %v", name, err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("CheckCompatibilityWithOptions(%s) = %v, want no changes", name, changes)
+		}
+	}
+
+	userPath := filepath.Join(dir, "user", "v1", "user.proto")
+	content, err := os.ReadFile(userPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := strings.Replace(string(content), "shared.v1.TestAddress home", "repeated shared.v1.TestAddress home", 1)
+	if err := os.WriteFile(userPath, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := CheckCompatibilityWithOptions(userPath, opts)
+	if err != nil {
+		t.Fatalf("CheckCompatibilityWithOptions() error = %v", err)
+	}
+	want := []BreakingChange{
+		{Location: "TestUser.home", Description: "type of field 2 changed from repeated shared.v1.TestAddress to shared.v1.TestAddress"},
+	}
+	if diff := cmp.Diff(want, changes); diff != "" {
+		t.Errorf("CheckCompatibilityWithOptions() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := CheckCompatibilityWithOptions(filepath.Join(dir, "other.proto"), opts); err == nil {
+		t.Error("CheckCompatibilityWithOptions() error = nil for a file not in Files, want an error")
+	}
+}
@@ -1,20 +1,116 @@
 package protobuf
 
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
 type generator struct {
 	analyzer *typeAnalyzer
-	writer   *fileWriter
 	opts     GenerateOptions
 }
 
 func newGenerator(opts GenerateOptions) *generator {
+	if opts.OutputDir == "" {
+		opts.OutputDir = "./proto"
+	}
+	if opts.Filename == "" && len(opts.Files) > 0 {
+		opts.Filename = "common.proto"
+	}
+	if opts.Filename == "" {
+		opts.Filename = "generated.proto"
+	}
 	return &generator{
 		analyzer: newTypeAnalyzer(),
-		writer:   newFileWriter(opts.OutputDir, opts.PackageName, opts.GoPackage),
 		opts:     opts,
 	}
 }
 
 func (g *generator) generateFromSpecs(specs ...AbstractServiceSpec) error {
-	definitions := g.analyzer.analyzeSpecs(specs...)
-	return g.writer.writeProtoFile(g.opts.Filename, definitions)
+	definitions, err := g.analyzeSpecs(specs...)
+	if err != nil {
+		return err
+	}
+	warnings := g.opts.Warnings
+	if warnings == nil {
+		warnings = os.Stderr
+	}
+	for _, warning := range definitions.Warnings {
+		_, _ = fmt.Fprintf(warnings, "WARNING: %s\n", warning)
+	}
+
+	lock, err := g.readLock()
+	if err != nil {
+		return err
+	}
+	if err := lock.apply(definitions); err != nil {
+		return err
+	}
+
+	if err := g.writeFiles(definitions); err != nil {
+		return err
+	}
+	if g.opts.LockFile != "" {
+		return lock.write(g.opts.LockFile)
+	}
+	return nil
+}
+
+// analyzeSpecs analyzes specs along with the services of Files, which are
+// generated even when not passed to Generate.
+func (g *generator) analyzeSpecs(specs ...AbstractServiceSpec) (*definitions, error) {
+	for _, file := range g.opts.Files {
+		for _, spec := range file.Services {
+			if !slices.ContainsFunc(specs, func(s AbstractServiceSpec) bool {
+				return s.getServiceName() == spec.getServiceName()
+			}) {
+				specs = append(specs, spec)
+			}
+		}
+	}
+	return g.analyzer.analyzeSpecs(specs...)
+}
+
+// readLock reads the lock file of the options, or returns an empty lock
+// when there is none.
+func (g *generator) readLock() (*lockFile, error) {
+	if g.opts.LockFile == "" {
+		return &lockFile{Messages: make(map[string]*lockedMessage)}, nil
+	}
+	return readLockFile(g.opts.LockFile)
+}
+
+func (g *generator) writeFiles(definitions *definitions) error {
+	files, err := g.files(definitions)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := g.fileWriter(file.ProtoFile).writeProtoFile(file.Filename, file.definitions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// files returns the files to write definitions to: the file described by
+// the options, or the files of Files and the common one.
+func (g *generator) files(definitions *definitions) ([]*protoFile, error) {
+	common := ProtoFile{
+		Filename:    g.opts.Filename,
+		PackageName: g.opts.PackageName,
+		GoPackage:   g.opts.GoPackage,
+		Options:     g.opts.Options,
+	}
+	if len(g.opts.Files) == 0 {
+		return []*protoFile{{ProtoFile: common, definitions: definitions}}, nil
+	}
+	return splitDefinitions(definitions, g.opts.Files, common)
+}
+
+func (g *generator) fileWriter(file ProtoFile) *fileWriter {
+	writer := newFileWriter(g.opts.OutputDir, file.PackageName, file.GoPackage)
+	writer.options = file.Options
+	return writer
 }
@@ -0,0 +1,258 @@
+package protobuf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goatx/goat/internal/strcase"
+)
+
+// Field numbers 19000 to 19999 are reserved for the protobuf implementation.
+const (
+	firstImplementationReserved = 19000
+	lastImplementationReserved  = 19999
+	maxFieldNumber              = 1<<29 - 1
+)
+
+// parseFieldNumber parses the proto tag of a field, such as `proto:"3"`.
+func parseFieldNumber(tag string) (int, error) {
+	number, err := strconv.Atoi(tag)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a field number", tag)
+	}
+	if number < 1 || number > maxFieldNumber {
+		return 0, fmt.Errorf("field number %d is out of range", number)
+	}
+	if number >= firstImplementationReserved && number <= lastImplementationReserved {
+		return 0, fmt.Errorf("field number %d is reserved for the protobuf implementation", number)
+	}
+	return number, nil
+}
+
+// parseReservedTag parses the proto tag of a blank field listing reserved
+// numbers and names, such as `proto:"reserved=4,5,old_name"`.
+func parseReservedTag(tag string) ([]int, []string, error) {
+	list, ok := strings.CutPrefix(tag, "reserved=")
+	if !ok {
+		return nil, nil, fmt.Errorf("%q does not start with reserved=", tag)
+	}
+	var numbers []int
+	var names []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if number, err := strconv.Atoi(item); err == nil {
+			numbers = append(numbers, number)
+		} else {
+			names = append(names, item)
+		}
+	}
+	return numbers, names, nil
+}
+
+// numberFields gives the fields of msg that have no number yet the lowest
+// numbers that are neither used nor reserved, in declaration order.
+func numberFields(msg *message) {
+	used := make(map[int]bool)
+	for _, f := range msg.Fields {
+		used[f.Number] = true
+	}
+	for _, number := range msg.ReservedNumbers {
+		used[number] = true
+	}
+
+	next := 1
+	for i := range msg.Fields {
+		if msg.Fields[i].Number != 0 {
+			continue
+		}
+		for used[next] || (next >= firstImplementationReserved && next <= lastImplementationReserved) {
+			next++
+		}
+		msg.Fields[i].Number = next
+		used[next] = true
+	}
+}
+
+// lockFile records the field numbers generated for each message, so that
+// later generations keep them and never reuse the numbers of removed fields.
+type lockFile struct {
+	Messages map[string]*lockedMessage `json:"messages"`
+}
+
+type lockedMessage struct {
+	// Fields maps proto field names to their number and type.
+	Fields          map[string]lockedField `json:"fields"`
+	ReservedNumbers []int                  `json:"reserved_numbers,omitempty"`
+	ReservedNames   []string               `json:"reserved_names,omitempty"`
+}
+
+type lockedField struct {
+	Number int    `json:"number"`
+	Type   string `json:"type"`
+}
+
+// readLockFile reads the lock file at path, or returns an empty one when the
+// file does not exist yet.
+func readLockFile(path string) (*lockFile, error) {
+	lock := &lockFile{Messages: make(map[string]*lockedMessage)}
+	// #nosec G304 - path is the lock file chosen by the caller of Generate
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", path, err)
+	}
+	if lock.Messages == nil {
+		lock.Messages = make(map[string]*lockedMessage)
+	}
+	return lock, nil
+}
+
+func (l *lockFile) write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create lock file directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return nil
+}
+
+// apply numbers the fields of the messages in definitions: fields in the
+// lock keep their number, and new fields without a proto tag get numbers
+// that no field of the message ever had. Fields removed since the lock was
+// written become reserved. The lock is updated to match the definitions.
+//
+// Returns an error for each change that breaks wire compatibility, such as a
+// field changing its number or type, or reusing a reserved number or name.
+func (l *lockFile) apply(definitions *definitions) error {
+	var errs []error
+	for _, msg := range definitions.Messages {
+		locked := l.Messages[msg.Name]
+		if locked == nil {
+			locked = &lockedMessage{}
+		}
+		updated, err := locked.apply(msg)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		l.Messages[msg.Name] = updated
+	}
+	return errors.Join(errs...)
+}
+
+func (locked *lockedMessage) apply(msg *message) (*lockedMessage, error) {
+	var errs []error
+	fail := func(f field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("incompatible change to %s.%s: %s", msg.Name, f.Name, fmt.Sprintf(format, args...)))
+	}
+
+	present := make(map[string]bool)
+	for _, f := range msg.Fields {
+		present[strcase.ToSnakeCase(f.Name)] = true
+	}
+	tagged := make(map[int]bool)
+	for _, f := range msg.Fields {
+		if f.Tagged {
+			tagged[f.Number] = true
+		}
+	}
+
+	// Fields removed since the lock was written become reserved, unless a
+	// tagged field took over their number, which renames the field.
+	reservedNumbers := slices.Concat(msg.ReservedNumbers, locked.ReservedNumbers)
+	reservedNames := slices.Concat(msg.ReservedNames, locked.ReservedNames)
+	renamed := make(map[int]lockedField)
+	for name, lf := range locked.Fields {
+		if present[name] {
+			continue
+		}
+		if tagged[lf.Number] {
+			renamed[lf.Number] = lf
+			continue
+		}
+		reservedNumbers = append(reservedNumbers, lf.Number)
+		reservedNames = append(reservedNames, name)
+	}
+	msg.ReservedNumbers = sortedUnique(reservedNumbers)
+	msg.ReservedNames = sortedUnique(reservedNames)
+
+	used := make(map[int]string)
+	for i := range msg.Fields {
+		f := &msg.Fields[i]
+		name := strcase.ToSnakeCase(f.Name)
+		lf, inLock := locked.Fields[name]
+		if !inLock {
+			lf, inLock = renamed[f.Number]
+		}
+
+		switch {
+		case inLock && f.Tagged && f.Number != lf.Number:
+			fail(*f, "its tag sets number %d but it was generated with number %d", f.Number, lf.Number)
+		case inLock:
+			f.Number = lf.Number
+		case slices.Contains(msg.ReservedNames, name):
+			fail(*f, "the name %s is reserved", name)
+			continue
+		case !f.Tagged:
+			// Numbered below, once the numbers in use are known.
+			f.Number = 0
+			continue
+		}
+		if inLock && lf.Type != lockType(*f) {
+			fail(*f, "its type changed from %s to %s; add a new field instead", lf.Type, lockType(*f))
+		}
+		if slices.Contains(msg.ReservedNumbers, f.Number) {
+			fail(*f, "number %d is reserved", f.Number)
+		}
+		if other, ok := used[f.Number]; ok {
+			fail(*f, "number %d is also used by %s", f.Number, other)
+		}
+		used[f.Number] = f.Name
+	}
+	numberFields(msg)
+
+	updated := &lockedMessage{
+		Fields:          make(map[string]lockedField, len(msg.Fields)),
+		ReservedNumbers: msg.ReservedNumbers,
+		ReservedNames:   msg.ReservedNames,
+	}
+	for _, f := range msg.Fields {
+		updated.Fields[strcase.ToSnakeCase(f.Name)] = lockedField{Number: f.Number, Type: lockType(f)}
+	}
+	return updated, errors.Join(errs...)
+}
+
+// lockType is the type of a field as recorded in the lock file.
+func lockType(f field) string {
+	if f.IsRepeated {
+		return "repeated " + f.Type
+	}
+	return f.Type
+}
+
+func sortedUnique[E int | string](values []E) []E {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
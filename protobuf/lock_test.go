@@ -0,0 +1,259 @@
+package protobuf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type testTaggedMessage struct {
+	Message[*TestService1, *TestService1]
+	_       struct{} `proto:"reserved=2,legacy"`
+	Name    string   `proto:"5"`
+	Email   string
+	Phone   string
+	Comment string `proto:"-"`
+}
+
+func TestAnalyzeMessage_tags(t *testing.T) {
+	got := analyzeMessage(&testTaggedMessage{})
+
+	want := &message{
+		Name: "testTaggedMessage",
+		Fields: []field{
+			{Name: "Name", Type: "string", Number: 5, Tagged: true},
+			{Name: "Email", Type: "string", Number: 1},
+			{Name: "Phone", Type: "string", Number: 3},
+		},
+		ReservedNumbers: []int{2},
+		ReservedNames:   []string{"legacy"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("analyzeMessage() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseFieldNumber(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    int
+		wantErr bool
+	}{
+		{tag: "3", want: 3},
+		{tag: "three", wantErr: true},
+		{tag: "0", wantErr: true},
+		{tag: "19500", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := parseFieldNumber(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFieldNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseFieldNumber() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockFile_apply(t *testing.T) {
+	lockWithUser := func() *lockFile {
+		return &lockFile{Messages: map[string]*lockedMessage{
+			"User": {Fields: map[string]lockedField{
+				"name":  {Number: 1, Type: "string"},
+				"email": {Number: 2, Type: "string"},
+			}},
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		lock        *lockFile
+		message     *message
+		wantMessage *message
+		wantLock    *lockedMessage
+		wantErrs    []string
+	}{
+		{
+			name: "records numbers in an empty lock",
+			lock: &lockFile{Messages: map[string]*lockedMessage{}},
+			message: &message{Name: "User", Fields: []field{
+				{Name: "Name", Type: "string", Number: 1},
+				{Name: "Tags", Type: "string", Number: 2, IsRepeated: true},
+			}},
+			wantMessage: &message{Name: "User", Fields: []field{
+				{Name: "Name", Type: "string", Number: 1},
+				{Name: "Tags", Type: "string", Number: 2, IsRepeated: true},
+			}},
+			wantLock: &lockedMessage{Fields: map[string]lockedField{
+				"name": {Number: 1, Type: "string"},
+				"tags": {Number: 2, Type: "repeated string"},
+			}},
+		},
+		{
+			name: "keeps numbers of reordered fields",
+			lock: lockWithUser(),
+			message: &message{Name: "User", Fields: []field{
+				{Name: "Email", Type: "string", Number: 1},
+				{Name: "Name", Type: "string", Number: 2},
+			}},
+			wantMessage: &message{Name: "User", Fields: []field{
+				{Name: "Email", Type: "string", Number: 2},
+				{Name: "Name", Type: "string", Number: 1},
+			}},
+			wantLock: lockWithUser().Messages["User"],
+		},
+		{
+			name: "reserves removed fields and never reuses their numbers",
+			lock: lockWithUser(),
+			message: &message{Name: "User", Fields: []field{
+				{Name: "Email", Type: "string", Number: 1},
+				{Name: "Phone", Type: "string", Number: 2},
+			}},
+			wantMessage: &message{
+				Name: "User",
+				Fields: []field{
+					{Name: "Email", Type: "string", Number: 2},
+					{Name: "Phone", Type: "string", Number: 3},
+				},
+				ReservedNumbers: []int{1},
+				ReservedNames:   []string{"name"},
+			},
+			wantLock: &lockedMessage{
+				Fields: map[string]lockedField{
+					"email": {Number: 2, Type: "string"},
+					"phone": {Number: 3, Type: "string"},
+				},
+				ReservedNumbers: []int{1},
+				ReservedNames:   []string{"name"},
+			},
+		},
+		{
+			name: "renames a field tagged with its number",
+			lock: lockWithUser(),
+			message: &message{Name: "User", Fields: []field{
+				{Name: "FullName", Type: "string", Number: 1, Tagged: true},
+				{Name: "Email", Type: "string", Number: 2},
+			}},
+			wantMessage: &message{Name: "User", Fields: []field{
+				{Name: "FullName", Type: "string", Number: 1, Tagged: true},
+				{Name: "Email", Type: "string", Number: 2},
+			}},
+			wantLock: &lockedMessage{Fields: map[string]lockedField{
+				"full_name": {Number: 1, Type: "string"},
+				"email":     {Number: 2, Type: "string"},
+			}},
+		},
+		{
+			name: "reports incompatible changes",
+			lock: &lockFile{Messages: map[string]*lockedMessage{
+				"User": {
+					Fields: map[string]lockedField{
+						"name":  {Number: 1, Type: "string"},
+						"email": {Number: 2, Type: "string"},
+					},
+					ReservedNumbers: []int{3},
+					ReservedNames:   []string{"phone"},
+				},
+			}},
+			message: &message{Name: "User", Fields: []field{
+				{Name: "Name", Type: "int64", Number: 1},
+				{Name: "Email", Type: "string", Number: 4, Tagged: true},
+				{Name: "Phone", Type: "string", Number: 5},
+				{Name: "Age", Type: "int64", Number: 3, Tagged: true},
+			}},
+			wantErrs: []string{
+				"incompatible change to User.Name: its type changed from string to int64; add a new field instead",
+				"incompatible change to User.Email: its tag sets number 4 but it was generated with number 2",
+				"incompatible change to User.Phone: the name phone is reserved",
+				"incompatible change to User.Age: number 3 is reserved",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.lock.apply(&definitions{Messages: []*message{tt.message}})
+			if tt.wantErrs != nil {
+				if err == nil {
+					t.Fatal("apply() error = nil, want errors")
+				}
+				if diff := cmp.Diff(tt.wantErrs, strings.Split(err.Error(), "\n")); diff != "" {
+					t.Errorf("apply() errors mismatch (-want +got):\n%s", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantMessage, tt.message); diff != "" {
+				t.Errorf("message mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantLock, tt.lock.Messages["User"]); diff != "" {
+				t.Errorf("lock mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerate_lockFile(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "generated.lock.json")
+	if err := os.WriteFile(lockPath, []byte(`{"messages": {"TestRequest1": {"fields": {"old": {"number": 1, "type": "string"}}}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := NewServiceSpec(&TestService1{})
+	state := &TestIdleState{}
+	spec.DefineStates(state).SetInitialState(state)
+	OnMessage(spec, state, "TestMethod",
+		func(ctx context.Context, event *TestRequest1, sm *TestService1) Response[*TestResponse1] {
+			return SendTo(ctx, sm, &TestResponse1{})
+		})
+
+	opts := GenerateOptions{OutputDir: dir, LockFile: lockPath}
+	if err := Generate(opts, spec); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// #nosec G304 - path is within t.TempDir()
+	content, err := os.ReadFile(filepath.Join(dir, "generated.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMessage := `message TestRequest1 {
+  reserved 1;
+  reserved "old";
+  string data = 2;
+}`
+	if !strings.Contains(string(content), wantMessage) {
+		t.Errorf("generated proto does not contain:\n%s\ngot:\n%s", wantMessage, content)
+	}
+
+	lock, err := readLockFile(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &lockedMessage{
+		Fields:          map[string]lockedField{"data": {Number: 2, Type: "string"}},
+		ReservedNumbers: []int{1},
+		ReservedNames:   []string{"old"},
+	}
+	if diff := cmp.Diff(want, lock.Messages["TestRequest1"]); diff != "" {
+		t.Errorf("lock mismatch (-want +got):\n%s", diff)
+	}
+
+	// The spec itself is left untouched, so a second generation is the same.
+	if got := spec.getMessages()["TestRequest1"].Fields[0].Number; got != 1 {
+		t.Errorf("spec field number = %d, want 1", got)
+	}
+	if err := Generate(opts, spec); err != nil {
+		t.Fatalf("second Generate() error = %v", err)
+	}
+}
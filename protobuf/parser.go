@@ -0,0 +1,451 @@
+package protobuf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// parseProtoFile parses the messages, enums and services of a .proto file,
+// such as one written by Generate. Imports, comments and all options but
+// those of fields are skipped. Field names are kept as written in the file,
+// in snake case for generated files.
+func parseProtoFile(content string) (*definitions, error) {
+	p := &protoParser{tokens: tokenizeProto(content)}
+	defs := &definitions{
+		Messages: []*message{},
+		Services: []*service{},
+	}
+	for !p.done() {
+		if err := p.parseTopLevel(defs); err != nil {
+			return nil, err
+		}
+	}
+	return defs, nil
+}
+
+type protoToken struct {
+	text string
+	line int
+}
+
+type protoParser struct {
+	tokens []protoToken
+	pos    int
+}
+
+// tokenizeProto splits content into identifiers, numbers, strings and
+// punctuation, dropping comments.
+func tokenizeProto(content string) []protoToken {
+	var tokens []protoToken
+	line := 1
+	runes := []rune(content)
+	isWord := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+	}
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && (runes[i] != '*' || runes[i+1] != '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			tokens = append(tokens, protoToken{text: string(runes[start:min(i, len(runes))]), line: line})
+		case isWord(r):
+			start := i
+			for i < len(runes) && isWord(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line})
+		default:
+			tokens = append(tokens, protoToken{text: string(r), line: line})
+			i++
+		}
+	}
+	return tokens
+}
+
+func (p *protoParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *protoParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *protoParser) next() string {
+	text := p.peek()
+	p.pos++
+	return text
+}
+
+// errorf returns an error at the line of the last token read.
+func (p *protoParser) errorf(format string, args ...any) error {
+	line := 0
+	if len(p.tokens) > 0 {
+		line = p.tokens[min(max(p.pos-1, 0), len(p.tokens)-1)].line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *protoParser) expect(text string) error {
+	if got := p.next(); got != text {
+		return p.errorf("expected %q, found %q", text, got)
+	}
+	return nil
+}
+
+// skipStatement skips tokens up to the end of the statement, including a
+// block in braces.
+func (p *protoParser) skipStatement() {
+	depth := 0
+	for !p.done() {
+		switch p.next() {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth <= 0 {
+				return
+			}
+		case ";":
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+func (p *protoParser) parseTopLevel(defs *definitions) error {
+	switch p.peek() {
+	case "message":
+		return p.parseMessage(defs)
+	case "enum":
+		e, err := p.parseEnum()
+		if err != nil {
+			return err
+		}
+		defs.Enums = append(defs.Enums, e)
+		return nil
+	case "service":
+		s, err := p.parseService()
+		if err != nil {
+			return err
+		}
+		defs.Services = append(defs.Services, s)
+		return nil
+	case ";":
+		p.next()
+		return nil
+	default:
+		// syntax, package, import and option statements.
+		p.skipStatement()
+		return nil
+	}
+}
+
+// parseMessage parses a message, adding it and the messages and enums nested
+// in it to defs.
+func (p *protoParser) parseMessage(defs *definitions) error {
+	p.next()
+	msg := &message{Name: p.next()}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	defs.Messages = append(defs.Messages, msg)
+
+	for p.peek() != "}" {
+		if p.done() {
+			return p.errorf("message %s is not closed", msg.Name)
+		}
+		switch p.peek() {
+		case "message":
+			if err := p.parseMessage(defs); err != nil {
+				return err
+			}
+		case "enum":
+			e, err := p.parseEnum()
+			if err != nil {
+				return err
+			}
+			defs.Enums = append(defs.Enums, e)
+		case "reserved":
+			p.next()
+			if err := p.parseReserved(msg); err != nil {
+				return err
+			}
+		case "oneof":
+			if err := p.parseOneof(msg); err != nil {
+				return err
+			}
+		case "option", "extensions":
+			p.skipStatement()
+		case ";":
+			p.next()
+		default:
+			f, err := p.parseField()
+			if err != nil {
+				return err
+			}
+			msg.Fields = append(msg.Fields, f)
+		}
+	}
+	p.next()
+	return nil
+}
+
+// parseOneof parses a oneof, adding its fields to msg.
+func (p *protoParser) parseOneof(msg *message) error {
+	p.next()
+	p.next()
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for p.peek() != "}" {
+		if p.done() {
+			return p.errorf("oneof in %s is not closed", msg.Name)
+		}
+		if p.peek() == "option" {
+			p.skipStatement()
+			continue
+		}
+		f, err := p.parseField()
+		if err != nil {
+			return err
+		}
+		msg.Fields = append(msg.Fields, f)
+	}
+	p.next()
+	return nil
+}
+
+func (p *protoParser) parseReserved(msg *message) error {
+	for {
+		item := p.next()
+		switch {
+		case strings.HasPrefix(item, "\"") || strings.HasPrefix(item, "'"):
+			msg.ReservedNames = append(msg.ReservedNames, strings.Trim(item, "\"'"))
+		default:
+			number, err := strconv.Atoi(item)
+			if err != nil {
+				return p.errorf("invalid reserved field %q", item)
+			}
+			msg.ReservedNumbers = append(msg.ReservedNumbers, number)
+			if p.peek() == "to" {
+				p.next()
+				last, err := strconv.Atoi(p.next())
+				if err != nil {
+					return p.errorf("invalid reserved range")
+				}
+				for n := number + 1; n <= last; n++ {
+					msg.ReservedNumbers = append(msg.ReservedNumbers, n)
+				}
+			}
+		}
+		switch p.next() {
+		case ",":
+		case ";":
+			return nil
+		default:
+			return p.errorf("invalid reserved statement in %s", msg.Name)
+		}
+	}
+}
+
+func (p *protoParser) parseField() (field, error) {
+	var f field
+	switch p.peek() {
+	case "repeated":
+		p.next()
+		f.IsRepeated = true
+	case "optional", "required":
+		p.next()
+	}
+
+	f.Type = p.next()
+	if f.Type == "map" {
+		if err := p.expect("<"); err != nil {
+			return field{}, err
+		}
+		key := p.next()
+		if err := p.expect(","); err != nil {
+			return field{}, err
+		}
+		value := p.next()
+		if err := p.expect(">"); err != nil {
+			return field{}, err
+		}
+		f.Type = fmt.Sprintf("map<%s, %s>", key, value)
+	}
+
+	f.Name = p.next()
+	if err := p.expect("="); err != nil {
+		return field{}, err
+	}
+	number, err := strconv.Atoi(p.next())
+	if err != nil {
+		return field{}, p.errorf("invalid number for field %s", f.Name)
+	}
+	f.Number = number
+	// Field options, such as [deprecated = true].
+	if p.peek() == "[" {
+		p.next()
+		options, err := p.parseFieldOptions()
+		if err != nil {
+			return field{}, err
+		}
+		f.Options = options
+	}
+	if err := p.expect(";"); err != nil {
+		return field{}, err
+	}
+	return f, nil
+}
+
+// parseFieldOptions parses the options of a field up to the closing "]",
+// joining the tokens of each name and value.
+func (p *protoParser) parseFieldOptions() ([]option, error) {
+	var options []option
+	for {
+		var name, value strings.Builder
+		for !p.done() && p.peek() != "=" {
+			name.WriteString(p.next())
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		for !p.done() && p.peek() != "," && p.peek() != "]" {
+			value.WriteString(p.next())
+		}
+		options = append(options, option{Name: name.String(), Value: value.String()})
+		switch p.next() {
+		case ",":
+		case "]":
+			return options, nil
+		default:
+			return nil, p.errorf("field options are not closed")
+		}
+	}
+}
+
+func (p *protoParser) parseEnum() (*enum, error) {
+	p.next()
+	e := &enum{Name: p.next()}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for p.peek() != "}" {
+		if p.done() {
+			return nil, p.errorf("enum %s is not closed", e.Name)
+		}
+		switch p.peek() {
+		case "option", "reserved":
+			p.skipStatement()
+			continue
+		case ";":
+			p.next()
+			continue
+		}
+		name := p.next()
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		number, err := strconv.Atoi(p.next())
+		if err != nil {
+			return nil, p.errorf("invalid number for enum value %s", name)
+		}
+		if p.peek() == "[" {
+			for !p.done() && p.next() != "]" {
+			}
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+		e.Values = append(e.Values, enumValue{Name: name, Number: number})
+	}
+	p.next()
+	return e, nil
+}
+
+func (p *protoParser) parseService() (*service, error) {
+	p.next()
+	s := &service{Name: p.next(), Methods: []method{}}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for p.peek() != "}" {
+		if p.done() {
+			return nil, p.errorf("service %s is not closed", s.Name)
+		}
+		if p.peek() != "rpc" {
+			p.skipStatement()
+			continue
+		}
+		p.next()
+		m := method{Name: p.next()}
+		var err error
+		if m.InputType, m.ClientStreaming, err = p.parseRPCType(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("returns"); err != nil {
+			return nil, err
+		}
+		if m.OutputType, m.ServerStreaming, err = p.parseRPCType(); err != nil {
+			return nil, err
+		}
+		if p.peek() == "{" {
+			p.skipStatement()
+		} else if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+		s.Methods = append(s.Methods, m)
+	}
+	p.next()
+	return s, nil
+}
+
+// parseRPCType parses the input or output of an rpc, such as
+// "(stream Message)".
+func (p *protoParser) parseRPCType() (string, bool, error) {
+	if err := p.expect("("); err != nil {
+		return "", false, err
+	}
+	stream := false
+	if p.peek() == "stream" {
+		p.next()
+		stream = true
+	}
+	name := p.next()
+	if err := p.expect(")"); err != nil {
+		return "", false, err
+	}
+	return name, stream, nil
+}
@@ -0,0 +1,132 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseProtoFile(t *testing.T) {
+	content := `syntax = "proto3";
+
+package test.package;
+
+import "google/protobuf/empty.proto";
+
+option go_package = "github.com/test/proto";
+
+// Role of a user.
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_ADMIN = 1 [deprecated = true];
+}
+
+/* A user
+   of the service. */
+message User {
+  reserved 2, 5 to 7;
+  reserved "old_name";
+  option deprecated = true;
+  string user_id = 1 [(buf.validate.field).required = true, (buf.validate.field).string.min_len = 1];
+  repeated string tags = 3 [json_name = "labels"];
+  map<string, int64> scores = 4;
+  oneof contact {
+    string email = 8;
+    string phone = 9;
+  }
+  message Address {
+    string city = 1;
+  }
+}
+
+service UserService {
+  option deprecated = true;
+  rpc GetUser(User) returns (User);
+  rpc Watch(stream User) returns (stream User) {
+    option deprecated = true;
+  }
+}
+`
+
+	got, err := parseProtoFile(content)
+	if err != nil {
+		t.Fatalf("parseProtoFile() error = %v", err)
+	}
+
+	want := &definitions{
+		Enums: []*enum{
+			{
+				Name: "Role",
+				Values: []enumValue{
+					{Name: "ROLE_UNSPECIFIED", Number: 0},
+					{Name: "ROLE_ADMIN", Number: 1},
+				},
+			},
+		},
+		Messages: []*message{
+			{
+				Name: "User",
+				Fields: []field{
+					{Name: "user_id", Type: "string", Number: 1, Options: []option{
+						{Name: "(buf.validate.field).required", Value: "true"},
+						{Name: "(buf.validate.field).string.min_len", Value: "1"},
+					}},
+					{Name: "tags", Type: "string", Number: 3, IsRepeated: true, Options: []option{
+						{Name: "json_name", Value: `"labels"`},
+					}},
+					{Name: "scores", Type: "map<string, int64>", Number: 4},
+					{Name: "email", Type: "string", Number: 8},
+					{Name: "phone", Type: "string", Number: 9},
+				},
+				ReservedNumbers: []int{2, 5, 6, 7},
+				ReservedNames:   []string{"old_name"},
+			},
+			{
+				Name: "Address",
+				Fields: []field{
+					{Name: "city", Type: "string", Number: 1},
+				},
+			},
+		},
+		Services: []*service{
+			{
+				Name: "UserService",
+				Methods: []method{
+					{Name: "GetUser", InputType: "User", OutputType: "User"},
+					{Name: "Watch", InputType: "User", OutputType: "User", ClientStreaming: true, ServerStreaming: true},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseProtoFile() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseProtoFile_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "unclosed message",
+			content: "message User {\n  string name = 1;\n",
+			want:    "line 2: message User is not closed",
+		},
+		{
+			name:    "missing field number",
+			content: "message User {\n  string name;\n}\n",
+			want:    `line 2: expected "=", found ";"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseProtoFile(tt.content)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("parseProtoFile() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
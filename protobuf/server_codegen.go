@@ -0,0 +1,619 @@
+package protobuf
+
+import (
+	"fmt"
+	"go/format"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goatx/goat/internal/strcase"
+)
+
+const (
+	grpcPackage       = "google.golang.org/grpc"
+	timestampPackage  = "google.golang.org/protobuf/types/known/timestamppb"
+	durationPackage   = "google.golang.org/protobuf/types/known/durationpb"
+	emptyPackage      = "google.golang.org/protobuf/types/known/emptypb"
+	protoPackageAlias = "pb"
+)
+
+// scalarGoTypes maps proto scalar types to the Go types protoc-gen-go
+// generates for them.
+var scalarGoTypes = map[string]reflect.Type{
+	"double": reflect.TypeFor[float64](),
+	"float":  reflect.TypeFor[float32](),
+	"int32":  reflect.TypeFor[int32](),
+	"int64":  reflect.TypeFor[int64](),
+	"uint32": reflect.TypeFor[uint32](),
+	"uint64": reflect.TypeFor[uint64](),
+	"bool":   reflect.TypeFor[bool](),
+	"string": reflect.TypeFor[string](),
+	"bytes":  reflect.TypeFor[[]byte](),
+}
+
+type serverCodegen struct {
+	opts        ServerOptions
+	definitions *definitions
+	messages    map[string]*message
+	enums       map[string]*enum
+	// types maps message names to their Go types, and enumTypes enum names
+	// to theirs.
+	types     map[string]reflect.Type
+	enumTypes map[string]reflect.Type
+}
+
+func newServerCodegen(opts ServerOptions, specs ...AbstractServiceSpec) (*serverCodegen, error) {
+	definitions, err := newTypeAnalyzer().analyzeSpecs(specs...)
+	if err != nil {
+		return nil, err
+	}
+	g := &serverCodegen{
+		opts:        opts,
+		definitions: definitions,
+		messages:    make(map[string]*message),
+		enums:       make(map[string]*enum),
+		types:       make(map[string]reflect.Type),
+		enumTypes:   make(map[string]reflect.Type),
+	}
+	for _, spec := range specs {
+		for name, t := range spec.getMessageTypes() {
+			if t.PkgPath() == "main" {
+				return nil, fmt.Errorf("message %s is declared in package main, which cannot be imported", name)
+			}
+			g.types[name] = t
+		}
+	}
+	for _, msg := range g.definitions.Messages {
+		g.messages[msg.Name] = msg
+	}
+	for _, e := range g.definitions.Enums {
+		g.enums[e.Name] = e
+	}
+	for _, t := range g.types {
+		g.collectEnumTypes(t)
+	}
+	return g, nil
+}
+
+// collectEnumTypes records the Go types of the registered enums used by the
+// fields of the struct type t.
+func (g *serverCodegen) collectEnumTypes(t reflect.Type) {
+	var visit func(reflect.Type)
+	visit = func(t reflect.Type) {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			visit(t.Elem())
+		case reflect.Map:
+			visit(t.Key())
+			visit(t.Elem())
+		default:
+			if e := lookupEnum(t); e != nil {
+				g.enumTypes[e.Name] = t
+			}
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		visit(t.Field(i).Type)
+	}
+}
+
+// goFile collects the imports of a generated Go file.
+type goFile struct {
+	imports map[string]string
+	aliases map[string]bool
+}
+
+func newGoFile() *goFile {
+	return &goFile{imports: make(map[string]string), aliases: make(map[string]bool)}
+}
+
+// use imports path, preferably as alias, and returns the name to refer to
+// the package with.
+func (f *goFile) use(importPath, alias string) string {
+	if name, ok := f.imports[importPath]; ok {
+		return name
+	}
+	name := alias
+	for i := 2; f.aliases[name]; i++ {
+		name = alias + strconv.Itoa(i)
+	}
+	f.imports[importPath] = name
+	f.aliases[name] = true
+	return name
+}
+
+// source returns the formatted source of the file with body.
+func (f *goFile) source(header, pkg, body string) (string, error) {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("package ")
+	b.WriteString(pkg)
+	b.WriteString("\n\n")
+
+	paths := make([]string, 0, len(f.imports))
+	for p := range f.imports {
+		paths = append(paths, p)
+	}
+	// The standard library comes first, as goimports groups it.
+	sort.Slice(paths, func(i, j int) bool {
+		iStd, jStd := !strings.Contains(paths[i], "."), !strings.Contains(paths[j], ".")
+		if iStd != jStd {
+			return iStd
+		}
+		return paths[i] < paths[j]
+	})
+	b.WriteString("import (\n")
+	for i, p := range paths {
+		if i > 0 && !strings.Contains(paths[i-1], ".") && strings.Contains(p, ".") {
+			b.WriteString("\n")
+		}
+		if f.imports[p] != path.Base(p) {
+			b.WriteString(f.imports[p])
+			b.WriteString(" ")
+		}
+		b.WriteString(strconv.Quote(p))
+		b.WriteString("\n")
+	}
+	b.WriteString(")\n\n")
+	b.WriteString(body)
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return b.String(), fmt.Errorf("failed to format: %w", err)
+	}
+	return string(formatted), nil
+}
+
+func (g *serverCodegen) pb(f *goFile) string {
+	return f.use(g.opts.ProtoPackage, protoPackageAlias)
+}
+
+// typeExpr returns the Go expression of the type t.
+func typeExpr(f *goFile, t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		pkgName, _, _ := strings.Cut(t.String(), ".")
+		return f.use(t.PkgPath(), pkgName) + "." + t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + typeExpr(f, t.Elem())
+	case reflect.Slice:
+		return "[]" + typeExpr(f, t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), typeExpr(f, t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", typeExpr(f, t.Key()), typeExpr(f, t.Elem()))
+	case reflect.Struct:
+		fields := make([]string, t.NumField())
+		for i := range fields {
+			sf := t.Field(i)
+			field := typeExpr(f, sf.Type)
+			if !sf.Anonymous {
+				field = sf.Name + " " + field
+			}
+			if sf.Tag != "" {
+				field += " " + strconv.Quote(string(sf.Tag))
+			}
+			fields[i] = field
+		}
+		return "struct{" + strings.Join(fields, "; ") + "}"
+	default:
+		return t.String()
+	}
+}
+
+// pbTypeExpr returns the Go expression of the type protoc-gen-go generates
+// for a proto type.
+func (g *serverCodegen) pbTypeExpr(f *goFile, protoType string) string {
+	switch {
+	case protoType == timestampType:
+		return "*" + f.use(timestampPackage, "timestamppb") + ".Timestamp"
+	case protoType == durationType:
+		return "*" + f.use(durationPackage, "durationpb") + ".Duration"
+	case protoType == emptyType:
+		return "*" + f.use(emptyPackage, "emptypb") + ".Empty"
+	case g.messages[protoType] != nil:
+		return "*" + g.pb(f) + "." + goCamelCase(protoType)
+	case g.enums[protoType] != nil:
+		return g.pb(f) + "." + goCamelCase(protoType)
+	default:
+		return scalarGoTypes[protoType].String()
+	}
+}
+
+func fromPBFunc(name string) string {
+	return strcase.ToCamelCase(goCamelCase(name)) + "FromPB"
+}
+
+func toPBFunc(name string) string {
+	return strcase.ToCamelCase(goCamelCase(name)) + "ToPB"
+}
+
+// fromPB returns the expression converting src, of the protoc-gen-go type
+// of protoType, to the Go type t, and false when it cannot be converted.
+func (g *serverCodegen) fromPB(f *goFile, t reflect.Type, protoType, src string) (string, bool) {
+	if t.Kind() == reflect.Pointer {
+		elem := t.Elem()
+		switch {
+		case g.messages[protoType] != nil && g.types[protoType] == elem:
+			return fromPBFunc(protoType) + "(" + src + ")", true
+		case protoType == timestampType && elem == reflect.TypeFor[time.Time]():
+			return "convertPtr(" + src + ", (*" + f.use(timestampPackage, "timestamppb") + ".Timestamp).AsTime)", true
+		case protoType == durationType && elem == reflect.TypeFor[time.Duration]():
+			return "convertPtr(" + src + ", (*" + f.use(durationPackage, "durationpb") + ".Duration).AsDuration)", true
+		}
+		inner, ok := g.fromPB(f, elem, protoType, src)
+		return "ptr(" + inner + ")", ok
+	}
+
+	switch {
+	case g.messages[protoType] != nil:
+		return "deref(" + fromPBFunc(protoType) + "(" + src + "))", g.types[protoType] == t
+	case protoType == timestampType || protoType == durationType:
+		inner, ok := g.fromPB(f, reflect.PointerTo(t), protoType, src)
+		return "deref(" + inner + ")", ok
+	case g.enums[protoType] != nil:
+		return fromPBFunc(protoType) + "(" + src + ")", g.enumTypes[protoType] == t
+	}
+	pbType, ok := scalarGoTypes[protoType]
+	if !ok || !pbType.ConvertibleTo(t) {
+		return "", false
+	}
+	if pbType == t {
+		return src, true
+	}
+	return typeExpr(f, t) + "(" + src + ")", true
+}
+
+// toPB returns the expression converting src, of the Go type t, to the
+// protoc-gen-go type of protoType, and false when it cannot be converted.
+// src must be addressable.
+func (g *serverCodegen) toPB(f *goFile, t reflect.Type, protoType, src string) (string, bool) {
+	if t.Kind() == reflect.Pointer {
+		elem := t.Elem()
+		switch {
+		case g.messages[protoType] != nil && g.types[protoType] == elem:
+			return toPBFunc(protoType) + "(" + src + ")", true
+		case protoType == timestampType && elem == reflect.TypeFor[time.Time]():
+			return "convertValue(" + src + ", " + f.use(timestampPackage, "timestamppb") + ".New)", true
+		case protoType == durationType && elem == reflect.TypeFor[time.Duration]():
+			return "convertValue(" + src + ", " + f.use(durationPackage, "durationpb") + ".New)", true
+		}
+		return g.toPB(f, elem, protoType, "deref("+src+")")
+	}
+
+	switch {
+	case g.messages[protoType] != nil:
+		return toPBFunc(protoType) + "(&" + src + ")", g.types[protoType] == t
+	case protoType == timestampType:
+		return f.use(timestampPackage, "timestamppb") + ".New(" + src + ")", t == reflect.TypeFor[time.Time]()
+	case protoType == durationType:
+		return f.use(durationPackage, "durationpb") + ".New(" + src + ")", t == reflect.TypeFor[time.Duration]()
+	case g.enums[protoType] != nil:
+		return toPBFunc(protoType) + "(" + src + ")", g.enumTypes[protoType] == t
+	}
+	pbType, ok := scalarGoTypes[protoType]
+	if !ok || !t.ConvertibleTo(pbType) {
+		return "", false
+	}
+	if pbType == t {
+		return src, true
+	}
+	return pbType.String() + "(" + src + ")", true
+}
+
+// assignField returns the statements setting the field target from src,
+// converting with convert, which is fromPB or toPB; mapType is the Go type
+// of target when it is a map.
+func (g *serverCodegen) assignField(
+	f *goFile,
+	target, src string,
+	t reflect.Type, fld field,
+	convert func(*goFile, reflect.Type, string, string) (string, bool),
+	mapType func() string,
+) (string, bool) {
+	switch {
+	case isMapType(fld.Type):
+		if t.Kind() != reflect.Map {
+			return "", false
+		}
+		types := referencedTypes(fld.Type)
+		key, keyOK := convert(f, t.Key(), types[0], "k")
+		value, valueOK := convert(f, t.Elem(), types[1], "v")
+		if !keyOK || !valueOK {
+			return "", false
+		}
+		return fmt.Sprintf("if len(%[2]s) > 0 {\n%[1]s = make(%[3]s, len(%[2]s))\nfor k, v := range %[2]s {\n%[1]s[%[4]s] = %[5]s\n}\n}\n",
+			target, src, mapType(), key, value), true
+	case fld.IsRepeated:
+		if t.Kind() != reflect.Slice || fld.Type == "bytes" && t.Elem().Kind() != reflect.Slice {
+			return "", false
+		}
+		if pbType, ok := scalarGoTypes[fld.Type]; ok && pbType == t.Elem() {
+			return target + " = " + src + "\n", true
+		}
+		elem, ok := convert(f, t.Elem(), fld.Type, "v")
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("for _, v := range %[2]s {\n%[1]s = append(%[1]s, %[3]s)\n}\n", target, src, elem), true
+	default:
+		expr, ok := convert(f, t, fld.Type, src)
+		if !ok {
+			return "", false
+		}
+		return target + " = " + expr + "\n", true
+	}
+}
+
+// generateConversions returns the source of convert.go, with the functions
+// converting each message and enum from and to its protoc-gen-go type.
+func (g *serverCodegen) generateConversions() (string, error) {
+	f := newGoFile()
+	var b strings.Builder
+	pb := g.pb(f)
+
+	for _, msg := range g.definitions.Messages {
+		t, ok := g.types[msg.Name]
+		if !ok {
+			continue
+		}
+		model := typeExpr(f, t)
+		pbType := pb + "." + goCamelCase(msg.Name)
+
+		fmt.Fprintf(&b, "// %s converts a %s message to the struct of the model.\n", fromPBFunc(msg.Name), msg.Name)
+		fmt.Fprintf(&b, "func %s(m *%s) *%s {\n", fromPBFunc(msg.Name), pbType, model)
+		b.WriteString("if m == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&b, "out := &%s{}\n", model)
+		for _, fld := range msg.Fields {
+			sf, _ := t.FieldByName(fld.Name)
+			pbName := goCamelCase(strcase.ToSnakeCase(fld.Name))
+			code, ok := g.assignField(f, "out."+fld.Name, "m.Get"+pbName+"()", sf.Type, fld, g.fromPB,
+				func() string { return typeExpr(f, sf.Type) })
+			if !ok {
+				code = "// TODO: convert " + fld.Name + "\n"
+			}
+			b.WriteString(code)
+		}
+		b.WriteString("return out\n}\n\n")
+
+		fmt.Fprintf(&b, "// %s converts the struct of the model to a %s message.\n", toPBFunc(msg.Name), msg.Name)
+		fmt.Fprintf(&b, "func %s(in *%s) *%s {\n", toPBFunc(msg.Name), model, pbType)
+		b.WriteString("if in == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&b, "out := &%s{}\n", pbType)
+		for _, fld := range msg.Fields {
+			sf, _ := t.FieldByName(fld.Name)
+			pbName := goCamelCase(strcase.ToSnakeCase(fld.Name))
+			code, ok := g.assignField(f, "out."+pbName, "in."+fld.Name, sf.Type, fld, g.toPB,
+				func() string {
+					types := referencedTypes(fld.Type)
+					return "map[" + g.pbTypeExpr(f, types[0]) + "]" + g.pbTypeExpr(f, types[1])
+				})
+			if !ok {
+				code = "// TODO: convert " + fld.Name + "\n"
+			}
+			b.WriteString(code)
+		}
+		b.WriteString("return out\n}\n\n")
+	}
+
+	for _, e := range g.definitions.Enums {
+		t, ok := g.enumTypes[e.Name]
+		if !ok {
+			continue
+		}
+		g.writeEnumConversions(f, &b, e, t)
+	}
+
+	b.WriteString(conversionHelpers)
+	return f.source("// Code generated by goat. DO NOT EDIT.\n\n", g.opts.Package, b.String())
+}
+
+func (g *serverCodegen) writeEnumConversions(f *goFile, b *strings.Builder, e *enum, t reflect.Type) {
+	model := typeExpr(f, t)
+	pbType := g.pb(f) + "." + goCamelCase(e.Name)
+	goValues := lookupEnumGoValues(t)
+
+	fmt.Fprintf(b, "// %s converts a %s enum value to the type of the model.\n", fromPBFunc(e.Name), e.Name)
+	fmt.Fprintf(b, "func %s(v %s) %s {\n", fromPBFunc(e.Name), pbType, model)
+	if t.Kind() == reflect.String {
+		b.WriteString("switch v {\n")
+		for _, value := range e.Values {
+			if goValue, ok := goValues[value.Number]; ok {
+				fmt.Fprintf(b, "case %s_%s:\nreturn %s\n", pbType, value.Name, strconv.Quote(goValue))
+			}
+		}
+		b.WriteString("default:\nreturn \"\"\n}\n}\n\n")
+	} else {
+		fmt.Fprintf(b, "return %s(v)\n}\n\n", model)
+	}
+
+	fmt.Fprintf(b, "// %s converts a value of the model to a %s enum value.\n", toPBFunc(e.Name), e.Name)
+	fmt.Fprintf(b, "func %s(v %s) %s {\n", toPBFunc(e.Name), model, pbType)
+	if t.Kind() == reflect.String {
+		b.WriteString("switch v {\n")
+		unspecified := ""
+		for _, value := range e.Values {
+			if goValue, ok := goValues[value.Number]; ok {
+				fmt.Fprintf(b, "case %s:\nreturn %s_%s\n", strconv.Quote(goValue), pbType, value.Name)
+			} else if value.Number == 0 {
+				unspecified = value.Name
+			}
+		}
+		fmt.Fprintf(b, "default:\nreturn %s_%s\n}\n}\n\n", pbType, unspecified)
+	} else {
+		fmt.Fprintf(b, "return %s(v)\n}\n\n", pbType)
+	}
+}
+
+// conversionHelpers are the generic functions the conversions use.
+const conversionHelpers = `// deref returns *p, or the zero value when p is nil.
+func deref[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// convertPtr converts m, or returns nil when m is nil.
+func convertPtr[M, T any](m *M, convert func(*M) T) *T {
+	if m == nil {
+		return nil
+	}
+	v := convert(m)
+	return &v
+}
+
+// convertValue converts *v, or returns the zero value when v is nil.
+func convertValue[T, R any](v *T, convert func(T) R) R {
+	if v == nil {
+		var zero R
+		return zero
+	}
+	return convert(*v)
+}
+`
+
+// generateServer returns the source of the server skeleton of a service.
+func (g *serverCodegen) generateServer(service *service) (string, error) {
+	f := newGoFile()
+	var b strings.Builder
+	pb := g.pb(f)
+	name := goCamelCase(service.Name)
+	f.use("context", "context")
+
+	fmt.Fprintf(&b, "// %sServer implements the %s service of the model.\n", name, service.Name)
+	fmt.Fprintf(&b, "type %sServer struct {\n%s.Unimplemented%sServer\n", name, pb, name)
+	b.WriteString("// TODO: add the state the handlers of the model keep in the state machine.\n}\n\n")
+	fmt.Fprintf(&b, "var _ %s.%sServer = (*%sServer)(nil)\n\n", pb, name, name)
+
+	for _, m := range service.Methods {
+		methodName := goCamelCase(m.Name)
+		if m.Description != "" {
+			writeComment(&b, "", m.Description)
+		} else {
+			fmt.Fprintf(&b, "// %s implements the %s rpc.\n", methodName, m.Name)
+		}
+
+		inType := strings.TrimPrefix(g.pbTypeExpr(f, m.InputType), "*")
+		outType := strings.TrimPrefix(g.pbTypeExpr(f, m.OutputType), "*")
+		handler := "the " + m.Name + " handler of the model does"
+		switch {
+		case !m.ClientStreaming && !m.ServerStreaming:
+			fmt.Fprintf(&b, "func (s *%sServer) %s(ctx context.Context, req *%s) (*%s, error) {\n", name, methodName, inType, outType)
+			b.WriteString(g.convertInput(f, m.InputType))
+			out, result := g.convertOutput(f, m.OutputType)
+			b.WriteString(out)
+			todo := "fill out from in"
+			switch {
+			case m.InputType == emptyType && m.OutputType == emptyType:
+				todo = "implement " + m.Name
+			case m.InputType == emptyType:
+				todo = "fill out"
+			case m.OutputType == emptyType:
+				todo = "act on in"
+			}
+			fmt.Fprintf(&b, "// TODO: %s, as %s.\n", todo, handler)
+			b.WriteString(g.discardInput(m.InputType))
+			fmt.Fprintf(&b, "return %s, nil\n}\n\n", result)
+		case m.ServerStreaming && !m.ClientStreaming:
+			grpc := f.use(grpcPackage, "grpc")
+			fmt.Fprintf(&b, "func (s *%sServer) %s(req *%s, stream %s.ServerStreamingServer[%s]) error {\n", name, methodName, inType, grpc, outType)
+			b.WriteString(g.convertInput(f, m.InputType))
+			fmt.Fprintf(&b, "// TODO: send each output with stream.Send, as %s.\n", handler)
+			b.WriteString(g.discardInput(m.InputType))
+			b.WriteString("return nil\n}\n\n")
+		default:
+			grpc := f.use(grpcPackage, "grpc")
+			f.use("errors", "errors")
+			f.use("io", "io")
+			streamType := "ClientStreamingServer"
+			if m.ServerStreaming {
+				streamType = "BidiStreamingServer"
+			}
+			fmt.Fprintf(&b, "func (s *%sServer) %s(stream %s.%s[%s, %s]) error {\n", name, methodName, grpc, streamType, inType, outType)
+			b.WriteString("for {\nreq, err := stream.Recv()\nif errors.Is(err, io.EOF) {\n")
+			if m.ServerStreaming {
+				b.WriteString("return nil\n")
+			} else {
+				out, result := g.convertOutput(f, m.OutputType)
+				b.WriteString(out)
+				fmt.Fprintf(&b, "// TODO: fill out from the inputs received, as %s.\n", handler)
+				fmt.Fprintf(&b, "return stream.SendAndClose(%s)\n", result)
+			}
+			b.WriteString("}\nif err != nil {\nreturn err\n}\n")
+			b.WriteString(g.convertInput(f, m.InputType))
+			if m.ServerStreaming {
+				fmt.Fprintf(&b, "// TODO: send the outputs for in with stream.Send, as %s.\n", handler)
+			} else {
+				fmt.Fprintf(&b, "// TODO: keep what the response needs from in, as %s.\n", handler)
+			}
+			b.WriteString(g.discardInput(m.InputType))
+			b.WriteString("}\n}\n\n")
+		}
+	}
+
+	return f.source("", g.opts.Package, b.String())
+}
+
+// convertInput returns the statement converting req to the struct of the
+// model, in, unless the input is Empty.
+func (*serverCodegen) convertInput(_ *goFile, inputType string) string {
+	if inputType == emptyType {
+		return ""
+	}
+	return "in := " + fromPBFunc(inputType) + "(req)\n"
+}
+
+func (*serverCodegen) discardInput(inputType string) string {
+	if inputType == emptyType {
+		return "_ = req\n"
+	}
+	return "_ = in\n"
+}
+
+// convertOutput returns the statement declaring the struct of the model to
+// fill, out, and the expression converting it to the response.
+func (g *serverCodegen) convertOutput(f *goFile, outputType string) (string, string) {
+	if outputType == emptyType {
+		return "", "&" + f.use(emptyPackage, "emptypb") + ".Empty{}"
+	}
+	return "out := &" + typeExpr(f, g.types[outputType]) + "{}\n", toPBFunc(outputType) + "(out)"
+}
+
+// goCamelCase returns the Go name protoc-gen-go generates for a proto name,
+// such as UserId for user_id.
+func goCamelCase(s string) string {
+	var b strings.Builder
+	upper := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i+1 < len(s) && s[i+1] >= 'a' && s[i+1] <= 'z':
+			upper = true
+		case c >= '0' && c <= '9':
+			b.WriteByte(c)
+			upper = true
+		case upper && c >= 'a' && c <= 'z':
+			b.WriteByte(c - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteByte(c)
+			upper = false
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,763 @@
+package protobuf
+
+import (
+	"context"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The stand-ins below declare what the generated server code uses from
+// grpc-go, the well-known types and protoc-gen-go, so that it can be
+// type-checked without those dependencies. The model types are those of this
+// package, test files included.
+var serverStandIns = map[string]string{
+	grpcPackage: `package grpc
+
+type ServerStreamingServer[Res any] interface{ Send(*Res) error }
+type ClientStreamingServer[Req, Res any] interface {
+	Recv() (*Req, error)
+	SendAndClose(*Res) error
+}
+type BidiStreamingServer[Req, Res any] interface {
+	Recv() (*Req, error)
+	Send(*Res) error
+}
+`,
+	timestampPackage: `package timestamppb
+
+import "time"
+
+type Timestamp struct{ Seconds int64 }
+
+func New(t time.Time) *Timestamp        { return &Timestamp{Seconds: t.Unix()} }
+func (x *Timestamp) AsTime() time.Time { return time.Unix(x.Seconds, 0) }
+`,
+	durationPackage: `package durationpb
+
+import "time"
+
+type Duration struct{ Seconds int64 }
+
+func New(d time.Duration) *Duration           { return &Duration{Seconds: int64(d.Seconds())} }
+func (x *Duration) AsDuration() time.Duration { return time.Duration(x.Seconds) * time.Second }
+`,
+	emptyPackage: `package emptypb
+
+type Empty struct{}
+`,
+	"example.com/gen/userpb": `package userpb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type TestStatus int32
+
+const (
+	TestStatus_TEST_STATUS_UNSPECIFIED TestStatus = 0
+	TestStatus_TEST_STATUS_ACTIVE      TestStatus = 1
+	TestStatus_TEST_STATUS_IN_PROGRESS TestStatus = 2
+)
+
+type TestPriority int32
+
+type TestAddress struct {
+	City  string
+	Lines []string
+}
+
+func (x *TestAddress) GetCity() string    { return x.City }
+func (x *TestAddress) GetLines() []string { return x.Lines }
+
+type TestProfileExtra struct{ Note string }
+
+func (x *TestProfileExtra) GetNote() string { return x.Note }
+
+type TestProfile struct {
+	CreatedAt int64
+	UpdatedAt *timestamppb.Timestamp
+	Retention *durationpb.Duration
+	Address   *TestAddress
+	Previous  []*TestAddress
+	Scores    map[string]int64
+	Homes     map[int32]*TestAddress
+	Status    TestStatus
+	Priority  TestPriority
+	Avatar    []byte
+	Extra     *TestProfileExtra
+}
+
+func (x *TestProfile) GetCreatedAt() int64                     { return x.CreatedAt }
+func (x *TestProfile) GetUpdatedAt() *timestamppb.Timestamp    { return x.UpdatedAt }
+func (x *TestProfile) GetRetention() *durationpb.Duration      { return x.Retention }
+func (x *TestProfile) GetAddress() *TestAddress                { return x.Address }
+func (x *TestProfile) GetPrevious() []*TestAddress             { return x.Previous }
+func (x *TestProfile) GetScores() map[string]int64             { return x.Scores }
+func (x *TestProfile) GetHomes() map[int32]*TestAddress        { return x.Homes }
+func (x *TestProfile) GetStatus() TestStatus                   { return x.Status }
+func (x *TestProfile) GetPriority() TestPriority               { return x.Priority }
+func (x *TestProfile) GetAvatar() []byte                       { return x.Avatar }
+func (x *TestProfile) GetExtra() *TestProfileExtra             { return x.Extra }
+
+type TestRequest1 struct{ Data string }
+
+func (x *TestRequest1) GetData() string { return x.Data }
+
+type TestResponse1 struct{ Result string }
+
+func (x *TestResponse1) GetResult() string { return x.Result }
+
+type TestUser struct {
+	Name      string
+	Home      *TestAddress
+	CreatedAt *timestamppb.Timestamp
+}
+
+func (x *TestUser) GetName() string                        { return x.Name }
+func (x *TestUser) GetHome() *TestAddress                  { return x.Home }
+func (x *TestUser) GetCreatedAt() *timestamppb.Timestamp   { return x.CreatedAt }
+
+type TestService1Server interface {
+	GetProfile(context.Context, *TestRequest1) (*TestProfile, error)
+	WatchUsers(*TestRequest1, grpc.ServerStreamingServer[TestUser]) error
+	Upload(grpc.ClientStreamingServer[TestUser, TestResponse1]) error
+	Chat(grpc.BidiStreamingServer[TestUser, TestUser]) error
+	Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	mustEmbedUnimplementedTestService1Server()
+}
+
+type UnimplementedTestService1Server struct{}
+
+func (UnimplementedTestService1Server) GetProfile(context.Context, *TestRequest1) (*TestProfile, error) {
+	return nil, nil
+}
+func (UnimplementedTestService1Server) WatchUsers(*TestRequest1, grpc.ServerStreamingServer[TestUser]) error {
+	return nil
+}
+func (UnimplementedTestService1Server) Upload(grpc.ClientStreamingServer[TestUser, TestResponse1]) error {
+	return nil
+}
+func (UnimplementedTestService1Server) Chat(grpc.BidiStreamingServer[TestUser, TestUser]) error {
+	return nil
+}
+func (UnimplementedTestService1Server) Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, nil
+}
+func (UnimplementedTestService1Server) mustEmbedUnimplementedTestService1Server() {}
+`,
+}
+
+// standInImporter type-checks the stand-ins in place of the packages they
+// stand for, this package from its source, test files included, and imports
+// the other packages as usual.
+type standInImporter struct {
+	fset     *token.FileSet
+	packages map[string]*types.Package
+	std      types.Importer
+}
+
+func (i *standInImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := i.packages[path]; ok {
+		return pkg, nil
+	}
+	sources := map[string]string{"standin.go": serverStandIns[path]}
+	if path == modelPackage {
+		var err error
+		if sources, err = packageSources("."); err != nil {
+			return nil, err
+		}
+	} else if sources["standin.go"] == "" {
+		return i.std.Import(path)
+	}
+	pkg, err := i.check(path, sources)
+	if err != nil {
+		return nil, err
+	}
+	i.packages[path] = pkg
+	return pkg, nil
+}
+
+func (i *standInImporter) check(path string, sources map[string]string) (*types.Package, error) {
+	var files []*ast.File
+	for name, src := range sources {
+		file, err := parser.ParseFile(i.fset, name, src, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	config := types.Config{Importer: i}
+	return config.Check(path, i.fset, files, nil)
+}
+
+// modelPackage is the package of the model types the generated code uses.
+const modelPackage = "github.com/goatx/goat/protobuf"
+
+// packageSources reads the files of package protobuf in dir, leaving out
+// those of external test packages.
+func packageSources(dir string) (map[string]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]string)
+	for _, path := range paths {
+		// #nosec G304 - the files of this package
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, content, parser.PackageClauseOnly)
+		if err != nil {
+			return nil, err
+		}
+		if file.Name.Name == "protobuf" {
+			sources[filepath.Base(path)] = string(content)
+		}
+	}
+	return sources, nil
+}
+
+func newServerTestSpec() *ServiceSpec[*TestService1] {
+	RegisterEnum(TestStatusActive, TestStatusInProgress)
+	RegisterEnum(TestPriorityLow, TestPriorityHigh)
+
+	spec := NewServiceSpec(&TestService1{})
+	state := &TestIdleState{}
+	spec.DefineStates(state).SetInitialState(state)
+	OnMessage(spec, state, "GetProfile",
+		func(ctx context.Context, event *TestRequest1, sm *TestService1) Response[*TestProfile] {
+			return SendTo(ctx, sm, &TestProfile{})
+		},
+		WithDescription("GetProfile returns the profile of a user."))
+	OnServerStream(spec, state, "WatchUsers",
+		func(ctx context.Context, event *TestRequest1, sm *TestService1) StreamResponse[*TestUser] {
+			return SendStream(ctx, sm, &TestUser{})
+		})
+	OnClientStream(spec, state, "Upload",
+		func(ctx context.Context, event *TestUser, sm *TestService1) StreamResponse[*TestResponse1] {
+			return SendStream[*TestResponse1](ctx, sm)
+		})
+	OnBidiStream(spec, state, "Chat",
+		func(ctx context.Context, event *TestUser, sm *TestService1) StreamResponse[*TestUser] {
+			return SendStream(ctx, sm, event)
+		})
+	OnMessage(spec, state, "Ping",
+		func(ctx context.Context, event *Empty[*TestService1, *TestService1], sm *TestService1) Response[*Empty[*TestService1, *TestService1]] {
+			return SendTo(ctx, sm, &Empty[*TestService1, *TestService1]{})
+		})
+	return spec
+}
+
+func TestGenerateServer(t *testing.T) {
+	dir := t.TempDir()
+	opts := ServerOptions{OutputDir: dir, ProtoPackage: "example.com/gen/userpb"}
+	if err := GenerateServer(opts, newServerTestSpec()); err != nil {
+		t.Fatalf("GenerateServer() error = %v", err)
+	}
+
+	sources := make(map[string]string)
+	for _, name := range []string{"test_service1_server.go", "convert.go"} {
+		// #nosec G304 - the file is in t.TempDir()
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+		sources[name] = string(content)
+	}
+
+	fset := token.NewFileSet()
+	imp := &standInImporter{fset: fset, packages: make(map[string]*types.Package), std: importer.ForCompiler(fset, "source", nil)}
+	if _, err := imp.check("example.com/server", sources); err != nil {
+		t.Fatalf("generated code does not compile: %v\n%s\n%s", err, sources["test_service1_server.go"], sources["convert.go"])
+	}
+
+	server := sources["test_service1_server.go"]
+	for _, want := range []string{
+		"\tpb.UnimplementedTestService1Server\n",
+		"var _ pb.TestService1Server = (*TestService1Server)(nil)\n",
+		"// GetProfile returns the profile of a user.\nfunc (s *TestService1Server) GetProfile(ctx context.Context, req *pb.TestRequest1) (*pb.TestProfile, error) {\n" +
+			"\tin := testRequest1FromPB(req)\n" +
+			"\tout := &protobuf.TestProfile{}\n" +
+			"\t// TODO: fill out from in, as the GetProfile handler of the model does.\n" +
+			"\t_ = in\n" +
+			"\treturn testProfileToPB(out), nil\n}\n",
+		"func (s *TestService1Server) WatchUsers(req *pb.TestRequest1, stream grpc.ServerStreamingServer[pb.TestUser]) error {\n",
+		"func (s *TestService1Server) Upload(stream grpc.ClientStreamingServer[pb.TestUser, pb.TestResponse1]) error {\n",
+		"\t\t\treturn stream.SendAndClose(testResponse1ToPB(out))\n",
+		"func (s *TestService1Server) Chat(stream grpc.BidiStreamingServer[pb.TestUser, pb.TestUser]) error {\n",
+		"func (s *TestService1Server) Ping(ctx context.Context, req *emptypb.Empty) (*emptypb.Empty, error) {\n" +
+			"\t// TODO: implement Ping, as the Ping handler of the model does.\n",
+	} {
+		if !strings.Contains(server, want) {
+			t.Errorf("test_service1_server.go does not contain %q:\n%s", want, server)
+		}
+	}
+
+	convert := sources["convert.go"]
+	for _, want := range []string{
+		"// Code generated by goat. DO NOT EDIT.\n",
+		"\tout.UpdatedAt = convertPtr(m.GetUpdatedAt(), (*timestamppb.Timestamp).AsTime)\n",
+		"\tout.Retention = deref(convertPtr(m.GetRetention(), (*durationpb.Duration).AsDuration))\n",
+		"\tout.Address = deref(testAddressFromPB(m.GetAddress()))\n",
+		"\t\tout.Previous = append(out.Previous, testAddressFromPB(v))\n",
+		"\tout.Extra = deref(testProfileExtraFromPB(m.GetExtra()))\n",
+		"func testProfileExtraFromPB(m *pb.TestProfileExtra) *struct{ Note string } {\n",
+		"\tout.Lines = m.GetLines()\n",
+		"\tout.Status = testStatusFromPB(m.GetStatus())\n",
+		"\tcase pb.TestStatus_TEST_STATUS_IN_PROGRESS:\n\t\treturn \"in-progress\"\n",
+		"\tcase \"active\":\n\t\treturn pb.TestStatus_TEST_STATUS_ACTIVE\n",
+		"\tdefault:\n\t\treturn pb.TestStatus_TEST_STATUS_UNSPECIFIED\n",
+		"\treturn protobuf.TestPriority(v)\n",
+		"\t\t\tout.Homes[k] = testAddressToPB(&v)\n",
+		"\tout.UpdatedAt = convertValue(in.UpdatedAt, timestamppb.New)\n",
+		"\tout.Retention = durationpb.New(in.Retention)\n",
+	} {
+		if !strings.Contains(convert, want) {
+			t.Errorf("convert.go does not contain %q:\n%s", want, convert)
+		}
+	}
+}
+
+func TestGenerateServer_keepsEditedServer(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "test_service1_server.go")
+	edited := "package server\n\n// edited by hand\n"
+	if err := os.WriteFile(serverPath, []byte(edited), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	opts := ServerOptions{OutputDir: dir, ProtoPackage: "example.com/gen/userpb"}
+	if err := GenerateServer(opts, newServerTestSpec()); err != nil {
+		t.Fatalf("GenerateServer() error = %v", err)
+	}
+
+	// #nosec G304 - the file is in t.TempDir()
+	content, err := os.ReadFile(serverPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(content) != edited {
+		t.Errorf("test_service1_server.go = %q, want the edited file %q", content, edited)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "convert.go")); err != nil {
+		t.Errorf("convert.go was not written: %v", err)
+	}
+}
+
+func TestGenerateServer_requiresProtoPackage(t *testing.T) {
+	err := GenerateServer(ServerOptions{OutputDir: t.TempDir()}, newServerTestSpec())
+	if err == nil || err.Error() != "proto package is required" {
+		t.Errorf("GenerateServer() error = %v, want %q", err, "proto package is required")
+	}
+}
+
+func TestGoCamelCase(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"user_id", "UserId"},
+		{"created_at", "CreatedAt"},
+		{"TestUser", "TestUser"},
+		{"testNode", "TestNode"},
+		{"address2_line", "Address2Line"},
+		{"v1beta", "V1Beta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			if got := goCamelCase(tt.input); got != tt.want {
+				t.Fatalf("goCamelCase(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// The files of a module that generates a server from a model with
+// GenerateServer and runs the generated code against stand-ins of grpc-go,
+// the well-known types and protoc-gen-go.
+var roundTripModule = map[string]string{
+	"go.mod": `module example.com/roundtrip
+
+go 1.24
+
+require (
+	github.com/goatx/goat v0.0.0
+	google.golang.org/grpc v0.0.0
+	google.golang.org/protobuf v0.0.0
+)
+
+replace (
+	github.com/goatx/goat => GOAT_DIR
+	google.golang.org/grpc => ./standin/grpc
+	google.golang.org/protobuf => ./standin/protobuf
+)
+`,
+	"standin/grpc/go.mod":     "module google.golang.org/grpc\n\ngo 1.24\n",
+	"standin/grpc/grpc.go":    serverStandIns[grpcPackage],
+	"standin/protobuf/go.mod": "module google.golang.org/protobuf\n\ngo 1.24\n",
+	"standin/protobuf/types/known/timestamppb/timestamp.go": `package timestamppb
+
+import "time"
+
+type Timestamp struct {
+	Seconds int64
+	Nanos   int32
+}
+
+func New(t time.Time) *Timestamp {
+	return &Timestamp{Seconds: t.Unix(), Nanos: int32(t.Nanosecond())}
+}
+
+func (x *Timestamp) AsTime() time.Time { return time.Unix(x.Seconds, int64(x.Nanos)).UTC() }
+`,
+	"standin/protobuf/types/known/durationpb/duration.go": `package durationpb
+
+import "time"
+
+type Duration struct{ Nanos int64 }
+
+func New(d time.Duration) *Duration           { return &Duration{Nanos: int64(d)} }
+func (x *Duration) AsDuration() time.Duration { return time.Duration(x.Nanos) }
+`,
+	"standin/protobuf/types/known/emptypb/empty.go": serverStandIns[emptyPackage],
+	"model/model.go": `package model
+
+import (
+	"time"
+
+	"github.com/goatx/goat"
+	"github.com/goatx/goat/protobuf"
+)
+
+type Service struct{ goat.StateMachine }
+
+type Idle struct{ goat.State }
+
+type Status string
+
+const (
+	StatusActive     Status = "active"
+	StatusInProgress Status = "in-progress"
+)
+
+type Priority int32
+
+const (
+	PriorityLow Priority = iota + 1
+	PriorityHigh
+)
+
+type Address struct {
+	City  string
+	Lines []string
+}
+
+type Timestamps struct {
+	CreatedAt int64
+	UpdatedAt *time.Time
+	Retention time.Duration
+}
+
+type Profile struct {
+	protobuf.Message[*Service, *Service]
+	Timestamps
+	Address  Address
+	Previous []*Address
+	Scores   map[string]int64
+	Homes    map[int32]Address
+	Status   Status
+	Priority Priority
+	Avatar   []byte
+	Extra    struct {
+		Note string
+	}
+}
+
+type Request struct {
+	protobuf.Message[*Service, *Service]
+	Data string
+}
+`,
+	"gen/main.go": `package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"example.com/roundtrip/model"
+	"github.com/goatx/goat/protobuf"
+)
+
+func main() {
+	protobuf.RegisterEnum(model.StatusActive, model.StatusInProgress)
+	protobuf.RegisterEnum(model.PriorityLow, model.PriorityHigh)
+
+	spec := protobuf.NewServiceSpec(&model.Service{})
+	state := &model.Idle{}
+	spec.DefineStates(state).SetInitialState(state)
+	protobuf.OnMessage(spec, state, "GetProfile",
+		func(ctx context.Context, event *model.Request, sm *model.Service) protobuf.Response[*model.Profile] {
+			return protobuf.SendTo(ctx, sm, &model.Profile{})
+		})
+	protobuf.OnClientStream(spec, state, "Upload",
+		func(ctx context.Context, event *model.Profile, sm *model.Service) protobuf.StreamResponse[*model.Request] {
+			return protobuf.SendStream[*model.Request](ctx, sm)
+		})
+	protobuf.OnBidiStream(spec, state, "Chat",
+		func(ctx context.Context, event *model.Profile, sm *model.Service) protobuf.StreamResponse[*model.Profile] {
+			return protobuf.SendStream(ctx, sm, event)
+		})
+
+	opts := protobuf.ServerOptions{OutputDir: os.Args[1], ProtoPackage: "example.com/roundtrip/pb"}
+	if err := protobuf.GenerateServer(opts, spec); err != nil {
+		log.Fatal(err)
+	}
+}
+`,
+	"pb/pb.go": `package pb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Status int32
+
+const (
+	Status_STATUS_UNSPECIFIED Status = 0
+	Status_STATUS_ACTIVE      Status = 1
+	Status_STATUS_IN_PROGRESS Status = 2
+)
+
+type Priority int32
+
+type Address struct {
+	City  string
+	Lines []string
+}
+
+func (x *Address) GetCity() string {
+	if x == nil {
+		return ""
+	}
+	return x.City
+}
+
+func (x *Address) GetLines() []string {
+	if x == nil {
+		return nil
+	}
+	return x.Lines
+}
+
+type ProfileExtra struct{ Note string }
+
+func (x *ProfileExtra) GetNote() string {
+	if x == nil {
+		return ""
+	}
+	return x.Note
+}
+
+type Profile struct {
+	CreatedAt int64
+	UpdatedAt *timestamppb.Timestamp
+	Retention *durationpb.Duration
+	Address   *Address
+	Previous  []*Address
+	Scores    map[string]int64
+	Homes     map[int32]*Address
+	Status    Status
+	Priority  Priority
+	Avatar    []byte
+	Extra     *ProfileExtra
+}
+
+func (x *Profile) GetCreatedAt() int64                  { return x.CreatedAt }
+func (x *Profile) GetUpdatedAt() *timestamppb.Timestamp { return x.UpdatedAt }
+func (x *Profile) GetRetention() *durationpb.Duration   { return x.Retention }
+func (x *Profile) GetAddress() *Address                 { return x.Address }
+func (x *Profile) GetPrevious() []*Address              { return x.Previous }
+func (x *Profile) GetScores() map[string]int64          { return x.Scores }
+func (x *Profile) GetHomes() map[int32]*Address         { return x.Homes }
+func (x *Profile) GetStatus() Status                    { return x.Status }
+func (x *Profile) GetPriority() Priority                { return x.Priority }
+func (x *Profile) GetAvatar() []byte                    { return x.Avatar }
+func (x *Profile) GetExtra() *ProfileExtra              { return x.Extra }
+
+type Request struct{ Data string }
+
+func (x *Request) GetData() string { return x.Data }
+
+type ServiceServer interface {
+	GetProfile(context.Context, *Request) (*Profile, error)
+	Upload(grpc.ClientStreamingServer[Profile, Request]) error
+	Chat(grpc.BidiStreamingServer[Profile, Profile]) error
+	mustEmbedUnimplementedServiceServer()
+}
+
+type UnimplementedServiceServer struct{}
+
+func (UnimplementedServiceServer) GetProfile(context.Context, *Request) (*Profile, error) {
+	return nil, nil
+}
+func (UnimplementedServiceServer) Upload(grpc.ClientStreamingServer[Profile, Request]) error {
+	return nil
+}
+func (UnimplementedServiceServer) Chat(grpc.BidiStreamingServer[Profile, Profile]) error {
+	return nil
+}
+func (UnimplementedServiceServer) mustEmbedUnimplementedServiceServer() {}
+`,
+	"server/roundtrip_test.go": `package server
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"example.com/roundtrip/model"
+	"example.com/roundtrip/pb"
+)
+
+func TestConversionsRoundTrip(t *testing.T) {
+	updated := time.Unix(1700000000, 42).UTC()
+	in := &model.Profile{
+		Timestamps: model.Timestamps{CreatedAt: 7, UpdatedAt: &updated, Retention: 90 * time.Minute},
+		Address:    model.Address{City: "Tokyo", Lines: []string{"1-1", "Chiyoda"}},
+		Previous:   []*model.Address{{City: "Osaka"}, {City: "Kyoto", Lines: []string{"Sakyo"}}},
+		Scores:     map[string]int64{"go": 3, "rust": 1},
+		Homes:      map[int32]model.Address{1: {City: "Nagoya"}},
+		Status:     model.StatusInProgress,
+		Priority:   model.PriorityHigh,
+		Avatar:     []byte{0xca, 0xfe},
+	}
+	in.Extra.Note = "vip"
+
+	m := profileToPB(in)
+	if m.GetStatus() != pb.Status_STATUS_IN_PROGRESS || m.GetPriority() != pb.Priority(model.PriorityHigh) {
+		t.Errorf("profileToPB() enums = %v, %v", m.GetStatus(), m.GetPriority())
+	}
+	if got := profileFromPB(m); !reflect.DeepEqual(got, in) {
+		t.Errorf("profileFromPB(profileToPB(in)) = %+v, want %+v", got, in)
+	}
+}
+
+type clientStream struct {
+	received []*pb.Profile
+	sent     *pb.Request
+}
+
+func (s *clientStream) Recv() (*pb.Profile, error) {
+	if len(s.received) == 0 {
+		return nil, io.EOF
+	}
+	m := s.received[0]
+	s.received = s.received[1:]
+	return m, nil
+}
+
+func (s *clientStream) SendAndClose(m *pb.Request) error {
+	s.sent = m
+	return nil
+}
+
+type bidiStream struct {
+	clientStream
+}
+
+func (s *bidiStream) Send(*pb.Profile) error { return nil }
+
+func TestServer(t *testing.T) {
+	var server pb.ServiceServer = &ServiceServer{}
+
+	profile, err := server.GetProfile(context.Background(), &pb.Request{Data: "id"})
+	if err != nil || profile == nil {
+		t.Errorf("GetProfile() = %v, %v, want a profile", profile, err)
+	}
+
+	upload := &clientStream{received: []*pb.Profile{profileToPB(&model.Profile{}), {Status: pb.Status_STATUS_ACTIVE}}}
+	if err := server.Upload(upload); err != nil || upload.sent == nil || len(upload.received) != 0 {
+		t.Errorf("Upload() = %v, sent %v with %d profiles left, want every profile received and a response", err, upload.sent, len(upload.received))
+	}
+
+	chat := &bidiStream{clientStream{received: []*pb.Profile{{}}}}
+	if err := server.Chat(chat); err != nil || len(chat.received) != 0 {
+		t.Errorf("Chat() = %v with %d profiles left, want every profile received", err, len(chat.received))
+	}
+}
+`,
+}
+
+func TestGenerateServer_roundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the generated code with the go command")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is not available")
+	}
+	goatDir, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for name, content := range roundTripModule {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		content = strings.ReplaceAll(content, "GOAT_DIR", goatDir)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The module needs the checksums of the dependencies of goat.
+	// #nosec G304 - the go.sum of this module
+	sums, err := os.ReadFile(filepath.Join(goatDir, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.sum"), sums, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) {
+		t.Helper()
+		// #nosec G204 - runs the go command on the module written above
+		cmd := exec.Command(goCmd, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off", "GOPROXY=off", "GOSUMDB=off")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	run("run", "./gen", "server")
+	run("test", "./server")
+}
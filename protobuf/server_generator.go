@@ -0,0 +1,106 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/goatx/goat/internal/strcase"
+)
+
+const (
+	defaultServerOutputDir = "./server"
+	defaultServerPackage   = "server"
+)
+
+type ServerOptions struct {
+	// OutputDir is the directory the server files are written to. Defaults
+	// to "./server".
+	OutputDir string
+	// Package is the package name of the generated files. Defaults to
+	// "server".
+	Package string
+	// ProtoPackage is the import path of the Go package that protoc-gen-go
+	// and protoc-gen-go-grpc generate from the .proto file written by
+	// Generate (required). All messages must be in this package.
+	ProtoPackage string
+}
+
+// GenerateServer generates the skeleton of a Go gRPC server for each service
+// specification, to be completed by hand with the logic of the handlers of
+// the model.
+//
+// This function creates:
+//   - <service_name>_server.go: A server type implementing the service
+//     interface generated by protoc-gen-go-grpc, with a method per rpc that
+//     converts the request to the message struct of the model, leaves a TODO
+//     for the handler logic, and converts the response back. The file is
+//     only written when it does not exist yet, so edits are kept.
+//   - convert.go: The conversions between the protoc-gen-go types and the
+//     message structs, rewritten on every call.
+//
+// The message structs must not be declared in package main, which cannot
+// be imported.
+//
+// The function applies default values:
+//   - OutputDir defaults to "./server" if not specified
+//   - Package defaults to "server" if not specified
+//
+// Example:
+//
+//	err := protobuf.GenerateServer(protobuf.ServerOptions{
+//	    OutputDir:    "./internal/server",
+//	    ProtoPackage: "example.com/gen/userpb",
+//	}, userServiceSpec)
+//
+// Returns an error if ProtoPackage is empty, if a message struct cannot be
+// imported, or if directory creation, code generation or file writing fails.
+func GenerateServer(opts ServerOptions, specs ...AbstractServiceSpec) error {
+	if opts.ProtoPackage == "" {
+		return fmt.Errorf("proto package is required")
+	}
+	if opts.OutputDir == "" {
+		opts.OutputDir = defaultServerOutputDir
+	}
+	if opts.Package == "" {
+		opts.Package = defaultServerPackage
+	}
+
+	codegen, err := newServerCodegen(opts, specs...)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	for _, service := range codegen.definitions.Services {
+		filename := strcase.ToSnakeCase(service.Name) + "_server.go"
+		outputPath := filepath.Join(opts.OutputDir, filename)
+		if _, err := os.Stat(outputPath); err == nil {
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to check %s: %w", filename, err)
+		}
+
+		code, err := codegen.generateServer(service)
+		if err != nil {
+			return fmt.Errorf("failed to generate server for %s: %w", service.Name, err)
+		}
+		if err := os.WriteFile(outputPath, []byte(code), 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filename, err)
+		}
+	}
+
+	code, err := codegen.generateConversions()
+	if err != nil {
+		return fmt.Errorf("failed to generate convert.go: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(opts.OutputDir, "convert.go"), []byte(code), 0o600); err != nil {
+		return fmt.Errorf("failed to write convert.go: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package protobuf
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/goatx/goat"
+	"github.com/goatx/goat/internal/handlerctx"
+	"github.com/goatx/goat/internal/typeutil"
+	"github.com/goatx/goat/protobuf/codes"
+)
+
+// Status is the event SendError sends in place of the output of a method,
+// standing for the gRPC status the call fails with. Handle it in the client
+// state machine to check how failures are dealt with.
+//
+// Example:
+//
+//	goat.OnEvent(clientSpec, waiting, func(ctx context.Context, st *protobuf.Status, c *Client) {
+//	    if st.Code == codes.Unavailable {
+//	        goat.Goto(ctx, retrying)
+//	    }
+//	})
+type Status struct {
+	goat.Event[goat.AbstractStateMachine, goat.AbstractStateMachine]
+	Code    codes.Code
+	Message string
+}
+
+// SendError sends a Status to the target state machine in place of the
+// output message, modelling a method that fails with a gRPC error. The type
+// of the output must be given, as it cannot be inferred from the handler.
+// Returning SendError from some branches of a handler and SendTo from
+// others lets the model checker explore both outcomes. The failures are
+// recorded with the inputs they happen for, and the E2E test generator
+// expects them. A code outside the codes package, or codes.OK, sends
+// nothing and makes the model checker return an error.
+//
+// Parameters:
+//   - ctx: The context of the handler
+//   - target: The state machine to send the Status to
+//   - code: The status code, one of those of the codes package but codes.OK
+//   - message: The status message
+//
+// Returns the Response to return from the handler.
+//
+// Example:
+//
+//	user, ok := s.Users[req.ID]
+//	if !ok {
+//	    return protobuf.SendError[*GetUserResponse](ctx, req.Sender(), codes.NotFound, "user not found")
+//	}
+//	return protobuf.SendTo(ctx, req.Sender(), &GetUserResponse{Name: user.Name})
+func SendError[O AbstractMessage](ctx context.Context, target goat.AbstractStateMachine, code codes.Code, message string) Response[O] {
+	status := &Status{Code: code, Message: message}
+	if err := checkCode(code); err != nil {
+		handlerctx.Fail(ctx, err)
+		return Response[O]{status: status}
+	}
+	goat.SendTo(ctx, target, status)
+	return Response[O]{status: status}
+}
+
+// checkCode returns an error unless code is an error code of the codes
+// package.
+func checkCode(code codes.Code) error {
+	switch {
+	case code == codes.OK:
+		return errors.New("SendError requires an error code; use SendTo to respond with codes.OK")
+	case code > codes.Unauthenticated:
+		return fmt.Errorf("SendError requires a code of the codes package, not %s", code)
+	}
+	return nil
+}
+
+// methodError is a failure of a method through SendError, and the input it
+// failed for.
+type methodError struct {
+	Code    codes.Code
+	Message string
+	// InputType and Input are the name and the fields of the input, as
+	// serializeMessage writes them.
+	InputType string
+	Input     map[string]any
+}
+
+// addError records that a method failed with status for input, keeping the
+// errors of the method sorted.
+func (ps *ServiceSpec[T]) addError(methodName string, input AbstractMessage, status *Status) {
+	e := methodError{
+		Code:      status.Code,
+		Message:   status.Message,
+		InputType: typeutil.Name(input),
+		Input:     serializeMessage(input),
+	}
+	for i := range ps.rpcMethods {
+		m := &ps.rpcMethods[i]
+		if m.MethodName != methodName || slices.ContainsFunc(m.Errors, func(other methodError) bool { return reflect.DeepEqual(other, e) }) {
+			continue
+		}
+		m.Errors = append(m.Errors, e)
+		slices.SortFunc(m.Errors, compareErrors)
+	}
+}
+
+// compareErrors orders errors by code, message and input.
+func compareErrors(a, b methodError) int {
+	return cmp.Or(
+		cmp.Compare(a.Code, b.Code),
+		cmp.Compare(a.Message, b.Message),
+		cmp.Compare(a.InputType, b.InputType),
+		cmp.Compare(fmt.Sprint(a.Input), fmt.Sprint(b.Input)),
+	)
+}
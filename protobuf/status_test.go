@@ -0,0 +1,144 @@
+package protobuf
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/goatx/goat"
+	"github.com/goatx/goat/protobuf/codes"
+	"github.com/google/go-cmp/cmp"
+)
+
+type testStatusService struct {
+	goat.StateMachine
+	Down bool
+}
+
+type testStatusClient struct {
+	goat.StateMachine
+	Server *testStatusService
+	Failed codes.Code
+	Name   string
+}
+
+type testGetRequest struct {
+	Message[*testStatusClient, *testStatusService]
+}
+
+type testGetResponse struct {
+	Message[*testStatusService, *testStatusClient]
+	Name string
+}
+
+func TestSendError_modelChecking(t *testing.T) {
+	serviceSpec := NewServiceSpec(&testStatusService{})
+	serviceState := &TestIdleState{}
+	serviceSpec.DefineStates(serviceState).SetInitialState(serviceState)
+	// The service may or may not be down.
+	goat.OnEntry(serviceSpec.StateMachineSpec, serviceState, func(ctx context.Context, s *testStatusService) {})
+	goat.OnEntry(serviceSpec.StateMachineSpec, serviceState, func(ctx context.Context, s *testStatusService) {
+		s.Down = true
+	})
+	OnMessage(serviceSpec, serviceState, "Get",
+		func(ctx context.Context, req *testGetRequest, s *testStatusService) Response[*testGetResponse] {
+			if s.Down {
+				return SendError[*testGetResponse](ctx, req.Sender(), codes.Unavailable, "service is down")
+			}
+			return SendTo(ctx, req.Sender(), &testGetResponse{Name: "alice"})
+		})
+
+	clientSpec := goat.NewStateMachineSpec(&testStatusClient{})
+	clientState := &TestIdleState{}
+	clientSpec.DefineStates(clientState).SetInitialState(clientState)
+	goat.OnEntry(clientSpec, clientState, func(ctx context.Context, c *testStatusClient) {
+		goat.SendTo(ctx, c.Server, &testGetRequest{})
+	})
+	goat.OnEvent(clientSpec, clientState, func(ctx context.Context, res *testGetResponse, c *testStatusClient) {
+		c.Name = res.Name
+	})
+	goat.OnEvent(clientSpec, clientState, func(ctx context.Context, st *Status, c *testStatusClient) {
+		c.Failed = st.Code
+	})
+
+	service, err := serviceSpec.NewInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := clientSpec.NewInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Server = service
+
+	neverFails := goat.NewCondition("never fails", client, func(c *testStatusClient) bool {
+		return c.Failed == codes.OK
+	})
+	neverSucceeds := goat.NewCondition("never succeeds", client, func(c *testStatusClient) bool {
+		return c.Name == ""
+	})
+
+	result, err := goat.Test(
+		goat.WithStateMachines(client, service),
+		goat.WithRules(goat.Always(neverFails), goat.Always(neverSucceeds)),
+		goat.WithReporter(goat.NewQuietReporter()),
+	)
+	if err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+
+	// Both outcomes are explored.
+	violated := make([]string, 0)
+	for _, v := range result.Violations {
+		violated = append(violated, v.Rule)
+	}
+	slices.Sort(violated)
+	if diff := cmp.Diff([]string{"Always never fails", "Always never succeeds"}, violated); diff != "" {
+		t.Errorf("violated rules mismatch (-want +got):\n%s", diff)
+	}
+
+	want := []methodError{{Code: codes.Unavailable, Message: "service is down", InputType: "testGetRequest", Input: map[string]any{}}}
+	if diff := cmp.Diff(want, serviceSpec.getRPCMethods()[0].Errors); diff != "" {
+		t.Errorf("recorded errors mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSendError_invalidCode(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want string
+	}{
+		{codes.OK, "SendError requires an error code; use SendTo to respond with codes.OK"},
+		{codes.Unauthenticated + 1, "SendError requires a code of the codes package, not Code(17)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			spec := NewServiceSpec(&TestService1{})
+			idle := &TestIdleState{}
+			spec.DefineStates(idle).SetInitialState(idle)
+			goat.OnEntry(spec.StateMachineSpec, idle, func(ctx context.Context, s *TestService1) {
+				goat.SendTo(ctx, s, &TestRequest1{})
+			})
+			OnMessage(spec, idle, "Do",
+				func(ctx context.Context, req *TestRequest1, s *TestService1) Response[*TestResponse1] {
+					return SendError[*TestResponse1](ctx, s, tt.code, "")
+				})
+			instance, err := spec.NewInstance()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = goat.Test(goat.WithStateMachines(instance), goat.WithReporter(goat.NewQuietReporter()))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Test() error = %v, want %q", err, tt.want)
+			}
+			if _, _, err := executeHandler(spec, "Do", &TestRequest1{}); err == nil || err.Error() != tt.want {
+				t.Errorf("executeHandler() error = %v, want %q", err, tt.want)
+			}
+			if errs := spec.getRPCMethods()[0].Errors; len(errs) != 0 {
+				t.Errorf("recorded errors = %v, want none", errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package protobuf
+
+import (
+	"context"
+
+	"github.com/goatx/goat"
+	"github.com/goatx/goat/internal/validate"
+)
+
+// StreamResponse represents the messages a streaming handler sends back.
+// You must create this by calling SendStream.
+type StreamResponse[O AbstractMessage] struct {
+	events []O
+}
+
+// SendStream sends each event to the target state machine, in order, as
+// messages of a response stream, and returns them as a StreamResponse. Each
+// event is queued separately, so the target processes them one at a time
+// and other state machines can act in between. Sending no event is valid:
+// a client streaming handler sends nothing until it has received the whole
+// stream. Each event is checked against its constraints as SendTo checks
+// the message it sends.
+//
+// Parameters:
+//   - ctx: The context of the handler
+//   - target: The state machine to send the events to
+//   - events: The messages to send
+//
+// Returns the StreamResponse to return from the handler.
+//
+// Example:
+//
+//	return protobuf.SendStream(ctx, client, &Update{Seq: 1}, &Update{Seq: 2})
+func SendStream[O AbstractMessage](ctx context.Context, target goat.AbstractStateMachine, events ...O) StreamResponse[O] {
+	for _, event := range events {
+		validate.Report(ctx, validMessagesRule, event)
+		goat.SendTo(ctx, target, event)
+	}
+	return StreamResponse[O]{events: events}
+}
+
+// OnServerStream registers a server streaming method: the client sends one
+// input message, and the handler answers with a stream of any number of
+// output messages sent with SendStream.
+//
+// Parameters:
+//   - spec: The service specification to register the method with
+//   - state: The state in which the method is handled
+//   - methodName: The name of the rpc
+//   - handler: The function handling the input message
+//   - opts: Optional configuration, such as WithDescription
+//
+// Example:
+//
+//	protobuf.OnServerStream(spec, idle, "ListUsers",
+//	    func(ctx context.Context, req *ListUsersRequest, s *UserService) protobuf.StreamResponse[*User] {
+//	        return protobuf.SendStream(ctx, req.Sender(), &User{Name: "alice"}, &User{Name: "bob"})
+//	    })
+func OnServerStream[T goat.AbstractStateMachine, I AbstractMessage, O AbstractMessage](
+	spec *ServiceSpec[T],
+	state goat.AbstractState,
+	methodName string,
+	handler func(context.Context, I, T) StreamResponse[O],
+	opts ...MethodOption,
+) {
+	spec.docs.RegisterCaller(1)
+	onStream(spec, state, methodName, handler, false, true, opts)
+}
+
+// OnClientStream registers a client streaming method: the client sends a
+// stream of input messages, each of which is an event processed by the
+// handler, and the service answers with one output message. The handler
+// keeps what it needs from each message in the state machine, and sends
+// the output with SendStream once the stream is complete, for example when
+// a message marks its end.
+//
+// Parameters:
+//   - spec: The service specification to register the method with
+//   - state: The state in which the method is handled
+//   - methodName: The name of the rpc
+//   - handler: The function handling each input message
+//   - opts: Optional configuration, such as WithDescription
+//
+// Example:
+//
+//	protobuf.OnClientStream(spec, idle, "Upload",
+//	    func(ctx context.Context, chunk *Chunk, s *Storage) protobuf.StreamResponse[*UploadResult] {
+//	        s.Size += len(chunk.Data)
+//	        if !chunk.Last {
+//	            return protobuf.SendStream[*UploadResult](ctx, chunk.Sender())
+//	        }
+//	        return protobuf.SendStream(ctx, chunk.Sender(), &UploadResult{Size: s.Size})
+//	    })
+func OnClientStream[T goat.AbstractStateMachine, I AbstractMessage, O AbstractMessage](
+	spec *ServiceSpec[T],
+	state goat.AbstractState,
+	methodName string,
+	handler func(context.Context, I, T) StreamResponse[O],
+	opts ...MethodOption,
+) {
+	spec.docs.RegisterCaller(1)
+	onStream(spec, state, methodName, handler, true, false, opts)
+}
+
+// OnBidiStream registers a bidirectional streaming method: the client sends
+// a stream of input messages, each of which is an event processed by the
+// handler, and the handler answers each with any number of output messages
+// sent with SendStream.
+//
+// Parameters:
+//   - spec: The service specification to register the method with
+//   - state: The state in which the method is handled
+//   - methodName: The name of the rpc
+//   - handler: The function handling each input message
+//   - opts: Optional configuration, such as WithDescription
+//
+// Example:
+//
+//	protobuf.OnBidiStream(spec, idle, "Chat",
+//	    func(ctx context.Context, msg *ChatMessage, s *ChatService) protobuf.StreamResponse[*ChatMessage] {
+//	        return protobuf.SendStream(ctx, msg.Sender(), &ChatMessage{Text: "ack: " + msg.Text})
+//	    })
+func OnBidiStream[T goat.AbstractStateMachine, I AbstractMessage, O AbstractMessage](
+	spec *ServiceSpec[T],
+	state goat.AbstractState,
+	methodName string,
+	handler func(context.Context, I, T) StreamResponse[O],
+	opts ...MethodOption,
+) {
+	spec.docs.RegisterCaller(1)
+	onStream(spec, state, methodName, handler, true, true, opts)
+}
+
+func onStream[T goat.AbstractStateMachine, I AbstractMessage, O AbstractMessage](
+	spec *ServiceSpec[T],
+	state goat.AbstractState,
+	methodName string,
+	handler func(context.Context, I, T) StreamResponse[O],
+	clientStreaming, serverStreaming bool,
+	opts []MethodOption,
+) {
+	addMethod[T, I, O](spec, methodName, clientStreaming, serverStreaming, opts)
+
+	goat.OnEvent(spec.StateMachineSpec, state, func(ctx context.Context, event I, sm T) {
+		handler(ctx, event, sm)
+	})
+}
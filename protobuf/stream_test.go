@@ -0,0 +1,149 @@
+package protobuf
+
+import (
+	"context"
+	"testing"
+
+	"github.com/goatx/goat"
+	"github.com/google/go-cmp/cmp"
+)
+
+type testStreamClient struct {
+	goat.StateMachine
+	Server   *TestService1
+	Received []int64
+}
+
+type testStreamRequest struct {
+	Message[*testStreamClient, *TestService1]
+	Count int64
+	Last  bool
+}
+
+type testStreamUpdate struct {
+	Message[*TestService1, *testStreamClient]
+	Seq int64
+}
+
+func TestOnStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(spec *ServiceSpec[*TestService1], state *TestIdleState)
+		want     rpcMethod
+	}{
+		{
+			name: "server stream",
+			register: func(spec *ServiceSpec[*TestService1], state *TestIdleState) {
+				OnServerStream(spec, state, "Watch",
+					func(ctx context.Context, req *testStreamRequest, s *TestService1) StreamResponse[*testStreamUpdate] {
+						return SendStream[*testStreamUpdate](ctx, req.Sender())
+					})
+			},
+			want: rpcMethod{ServiceType: "TestService1", MethodName: "Watch", InputType: "testStreamRequest", OutputType: "testStreamUpdate", ServerStreaming: true},
+		},
+		{
+			name: "client stream",
+			register: func(spec *ServiceSpec[*TestService1], state *TestIdleState) {
+				OnClientStream(spec, state, "Upload",
+					func(ctx context.Context, req *testStreamRequest, s *TestService1) StreamResponse[*testStreamUpdate] {
+						return SendStream[*testStreamUpdate](ctx, req.Sender())
+					})
+			},
+			want: rpcMethod{ServiceType: "TestService1", MethodName: "Upload", InputType: "testStreamRequest", OutputType: "testStreamUpdate", ClientStreaming: true},
+		},
+		{
+			name: "bidirectional stream",
+			register: func(spec *ServiceSpec[*TestService1], state *TestIdleState) {
+				OnBidiStream(spec, state, "Chat",
+					func(ctx context.Context, req *testStreamRequest, s *TestService1) StreamResponse[*testStreamUpdate] {
+						return SendStream[*testStreamUpdate](ctx, req.Sender())
+					})
+			},
+			want: rpcMethod{ServiceType: "TestService1", MethodName: "Chat", InputType: "testStreamRequest", OutputType: "testStreamUpdate", ClientStreaming: true, ServerStreaming: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewServiceSpec(&TestService1{})
+			state := &TestIdleState{}
+			spec.DefineStates(state).SetInitialState(state)
+
+			tt.register(spec, state)
+
+			if diff := cmp.Diff([]rpcMethod{tt.want}, spec.getRPCMethods()); diff != "" {
+				t.Errorf("rpc methods mismatch (-want +got):\n%s", diff)
+			}
+			if _, ok := spec.getMessages()["testStreamUpdate"]; !ok {
+				t.Error("output message is not analyzed")
+			}
+			if _, _, err := executeHandler(spec, tt.want.MethodName, &testStreamRequest{}); err == nil {
+				t.Error("executeHandler() error = nil, want an error for a streaming method")
+			}
+		})
+	}
+}
+
+func TestOnServerStream_modelChecking(t *testing.T) {
+	serviceSpec := NewServiceSpec(&TestService1{})
+	serviceState := &TestIdleState{}
+	serviceSpec.DefineStates(serviceState).SetInitialState(serviceState)
+	OnServerStream(serviceSpec, serviceState, "Count",
+		func(ctx context.Context, req *testStreamRequest, s *TestService1) StreamResponse[*testStreamUpdate] {
+			updates := make([]*testStreamUpdate, 0, req.Count)
+			for i := range req.Count {
+				updates = append(updates, &testStreamUpdate{Seq: i + 1})
+			}
+			return SendStream(ctx, req.Sender(), updates...)
+		})
+
+	clientSpec := goat.NewStateMachineSpec(&testStreamClient{})
+	clientState := &TestIdleState{}
+	clientSpec.DefineStates(clientState).SetInitialState(clientState)
+	goat.OnEntry(clientSpec, clientState, func(ctx context.Context, c *testStreamClient) {
+		goat.SendTo(ctx, c.Server, &testStreamRequest{Count: 3})
+	})
+	goat.OnEvent(clientSpec, clientState, func(ctx context.Context, update *testStreamUpdate, c *testStreamClient) {
+		c.Received = append(c.Received, update.Seq)
+	})
+
+	service, err := serviceSpec.NewInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := clientSpec.NewInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Server = service
+
+	// The updates arrive one by one and in order.
+	inOrder := goat.NewCondition("in order", client, func(c *testStreamClient) bool {
+		for i, seq := range c.Received {
+			if seq != int64(i+1) {
+				return false
+			}
+		}
+		return true
+	})
+	notAll := goat.NewCondition("not all received", client, func(c *testStreamClient) bool {
+		return len(c.Received) < 3
+	})
+
+	result, err := goat.Test(
+		goat.WithStateMachines(client, service),
+		goat.WithRules(goat.Always(inOrder), goat.Always(notAll)),
+		goat.WithReporter(goat.NewQuietReporter()),
+	)
+	if err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+
+	violated := make([]string, 0)
+	for _, v := range result.Violations {
+		violated = append(violated, v.Rule)
+	}
+	if diff := cmp.Diff([]string{"Always not all received"}, violated); diff != "" {
+		t.Errorf("violated rules mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,6 +1,8 @@
 package protobuf
 
 import (
+	"time"
+
 	"github.com/goatx/goat"
 )
 
@@ -35,3 +37,52 @@ type TestResponse2 struct {
 type TestIdleState struct {
 	goat.State
 }
+
+type TestStatus string
+
+const (
+	TestStatusActive     TestStatus = "active"
+	TestStatusInProgress TestStatus = "in-progress"
+)
+
+type TestPriority int
+
+const (
+	TestPriorityLow  TestPriority = 1
+	TestPriorityHigh TestPriority = 2
+)
+
+func (p TestPriority) String() string {
+	if p == TestPriorityHigh {
+		return "High"
+	}
+	return "Low"
+}
+
+type TestAddress struct {
+	City  string
+	Lines []string
+}
+
+type TestTimestamps struct {
+	CreatedAt int64
+	UpdatedAt *time.Time
+	Retention time.Duration
+}
+
+type TestProfile struct {
+	Message[*TestService1, *TestService1]
+	TestTimestamps
+	Address  TestAddress
+	Previous []*TestAddress
+	Scores   map[string]int64
+	Homes    map[int32]TestAddress
+	Status   TestStatus
+	Priority TestPriority
+	Avatar   []byte
+	Callback func()
+	Grid     [][]int64
+	Extra    struct {
+		Note string
+	}
+}
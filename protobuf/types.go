@@ -3,13 +3,17 @@ package protobuf
 import (
 	"context"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/goatx/goat"
+	"github.com/goatx/goat/internal/godoc"
 	"github.com/goatx/goat/internal/typeutil"
 )
 
-type handlerFunc func(ctx context.Context, input AbstractMessage, sm goat.AbstractStateMachine) AbstractMessage
+// handlerFunc runs the handler of a unary method, returning its output or
+// the Status it fails with.
+type handlerFunc func(ctx context.Context, input AbstractMessage, sm goat.AbstractStateMachine) (AbstractMessage, *Status)
 
 type AbstractMessage interface {
 	isMessage() bool
@@ -42,6 +46,14 @@ type AbstractServiceSpec interface {
 	isServiceSpec() bool
 	getRPCMethods() []rpcMethod
 	getMessages() map[string]*message
+	getEnums() map[string]*enum
+	getWarnings() []string
+	getOptions() []option
+	getMethodOptions() map[string][]option
+	getMessageTypes() map[string]reflect.Type
+	getNameConflicts() []error
+	getFieldSources() map[string]map[string]fieldSource
+	getDocs() *godoc.Docs
 	getHandlers() map[string]handlerFunc
 	getServiceName() string
 	newInstance() (goat.AbstractStateMachine, error)
@@ -51,7 +63,26 @@ type ServiceSpec[T goat.AbstractStateMachine] struct {
 	*goat.StateMachineSpec[T]
 	rpcMethods []rpcMethod
 	messages   map[string]*message
+	enums      map[string]*enum
 	handlers   map[string]handlerFunc
+	// warnings lists the fields left out of the messages, reported by
+	// Generate.
+	warnings []string
+	// options and methodOptions are the options of the service and of its
+	// methods, by method name.
+	options       []option
+	methodOptions map[string][]option
+	// messageTypes maps message names to the Go types analyzed as them.
+	messageTypes map[string]reflect.Type
+	// nameConflicts lists the distinct Go types given the same message
+	// name, reported by Generate.
+	nameConflicts []error
+	// fieldSources maps message and field names to the Go fields they
+	// stand for.
+	fieldSources map[string]map[string]fieldSource
+	// docs looks up the doc comments Generate writes, from the packages
+	// registering methods.
+	docs godoc.Docs
 }
 
 func (*ServiceSpec[T]) isServiceSpec() bool {
@@ -66,6 +97,63 @@ func (ps *ServiceSpec[T]) getMessages() map[string]*message {
 	return ps.messages
 }
 
+func (ps *ServiceSpec[T]) getEnums() map[string]*enum {
+	return ps.enums
+}
+
+func (ps *ServiceSpec[T]) getWarnings() []string {
+	return ps.warnings
+}
+
+func (ps *ServiceSpec[T]) getOptions() []option {
+	return ps.options
+}
+
+func (ps *ServiceSpec[T]) getMethodOptions() map[string][]option {
+	return ps.methodOptions
+}
+
+func (ps *ServiceSpec[T]) getMessageTypes() map[string]reflect.Type {
+	return ps.messageTypes
+}
+
+func (ps *ServiceSpec[T]) getNameConflicts() []error {
+	return ps.nameConflicts
+}
+
+func (ps *ServiceSpec[T]) getFieldSources() map[string]map[string]fieldSource {
+	return ps.fieldSources
+}
+
+func (ps *ServiceSpec[T]) getDocs() *godoc.Docs {
+	return &ps.docs
+}
+
+// SetOption adds an option to the generated service, written as
+// `option name = value;`. The value is written as is, so string values need
+// their quotes.
+//
+// Example:
+//
+//	spec.SetOption("deprecated", "true")
+func (ps *ServiceSpec[T]) SetOption(name, value string) {
+	ps.options = append(ps.options, option{Name: name, Value: value})
+}
+
+// SetMethodOption adds an option to a generated method, written as
+// `option name = value;` in the body of the rpc. The value is written as is,
+// so string values need their quotes.
+//
+// Example:
+//
+//	spec.SetMethodOption("DeleteUser", "idempotency_level", "IDEMPOTENT")
+func (ps *ServiceSpec[T]) SetMethodOption(methodName, name, value string) {
+	if ps.methodOptions == nil {
+		ps.methodOptions = make(map[string][]option)
+	}
+	ps.methodOptions[methodName] = append(ps.methodOptions[methodName], option{Name: name, Value: value})
+}
+
 func (ps *ServiceSpec[T]) getHandlers() map[string]handlerFunc {
 	return ps.handlers
 }
@@ -89,16 +177,81 @@ func (ps *ServiceSpec[T]) addMessage(msg *message) {
 	ps.messages[msg.Name] = msg
 }
 
+// addAnalysis adds the messages and enums found by a messageAnalyzer, and
+// the warnings not reported yet.
+func (ps *ServiceSpec[T]) addAnalysis(a *messageAnalyzer) {
+	for _, msg := range a.messages {
+		ps.addMessage(msg)
+	}
+	if ps.messageTypes == nil {
+		ps.messageTypes = make(map[string]reflect.Type)
+	}
+	for t, name := range a.names {
+		if other, ok := ps.messageTypes[name]; ok && other != t {
+			ps.nameConflicts = append(ps.nameConflicts, typeutil.NameConflict(name, other, t))
+		}
+		ps.messageTypes[name] = t
+	}
+	if ps.fieldSources == nil {
+		ps.fieldSources = make(map[string]map[string]fieldSource)
+	}
+	for name, sources := range a.fieldSources {
+		ps.fieldSources[name] = sources
+	}
+	if ps.enums == nil {
+		ps.enums = make(map[string]*enum)
+	}
+	for name, e := range a.enums {
+		ps.enums[name] = e
+	}
+	for _, warning := range a.warnings {
+		if !slices.Contains(ps.warnings, warning) {
+			ps.warnings = append(ps.warnings, warning)
+		}
+	}
+}
+
 type rpcMethod struct {
 	ServiceType string
 	MethodName  string
 	InputType   string
 	OutputType  string
+	// ClientStreaming and ServerStreaming report that the client sends a
+	// stream of inputs and that the service sends a stream of outputs.
+	ClientStreaming bool
+	ServerStreaming bool
+	// Description documents the method, set with WithDescription.
+	Description string
+	// Errors are the failures of the handler through SendError, sorted,
+	// recorded as the handler runs in model checking and in E2E test
+	// generation.
+	Errors []methodError
+}
+
+// option is a service or method option.
+type option struct {
+	Name  string
+	Value string
 }
 
 type message struct {
 	Name   string
 	Fields []field
+	// ReservedNumbers and ReservedNames are the numbers and names of removed
+	// fields, which must not be reused.
+	ReservedNumbers []int
+	ReservedNames   []string
+	// Description is the doc comment of the Go type.
+	Description string
+}
+
+// fieldSource is the Go field a message field stands for, whose doc comment
+// Generate writes as the description of the message field.
+type fieldSource struct {
+	// owner is the struct type declaring the field, which is that of an
+	// embedded struct for a promoted field.
+	owner reflect.Type
+	name  string
 }
 
 type field struct {
@@ -106,4 +259,11 @@ type field struct {
 	Type       string
 	Number     int
 	IsRepeated bool
+	// Tagged reports that Number comes from a proto struct tag.
+	Tagged bool
+	// Description is the doc comment of the Go field.
+	Description string
+	// Options are the field options, such as the buf.validate rules standing
+	// for the validate struct tag.
+	Options []option
 }
@@ -0,0 +1,144 @@
+package protobuf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goatx/goat/internal/validate"
+)
+
+const (
+	validateImport = "buf/validate/validate.proto"
+	validateOption = "(buf.validate.field)"
+	// validMessagesRule is the rule SendTo and SendStream report a violation
+	// of when a handler sends a message breaking its constraints.
+	validMessagesRule = "valid messages"
+)
+
+// protoNumberTypes are the scalar types whose buf.validate rules are named
+// after them.
+var protoNumberTypes = []string{"int32", "int64", "uint32", "uint64", "float", "double"}
+
+// validationOptions returns the buf.validate options standing for the
+// constraints of a field, or an error when they cannot apply to its type.
+// enumType is the registered enum type of the field or its items, or nil:
+// the values of in are then written as the numbers of the enum values.
+func validationOptions(c *validate.Constraints, protoType string, repeated bool, enumType reflect.Type) ([]option, error) {
+	var options []option
+	add := func(rule, value string) {
+		options = append(options, option{Name: validateOption + "." + rule, Value: value})
+	}
+	if c.Required {
+		add("required", "true")
+	}
+
+	// Lengths bound the number of items of lists and maps, and the other
+	// constraints apply to each item of a list.
+	itemRule := ""
+	lengthRule := ""
+	switch {
+	case repeated:
+		itemRule = "repeated.items."
+		lengthRule = "repeated.%s_items"
+	case isMapType(protoType):
+		lengthRule = "map.%s_pairs"
+	case protoType == "string" || protoType == "bytes":
+		lengthRule = protoType + ".%s_len"
+	}
+	if lengthRule != "" {
+		if c.MinLen != nil {
+			add(fmt.Sprintf(lengthRule, "min"), strconv.Itoa(*c.MinLen))
+		}
+		if c.MaxLen != nil {
+			add(fmt.Sprintf(lengthRule, "max"), strconv.Itoa(*c.MaxLen))
+		}
+	}
+
+	hasItemConstraints := c.Min != nil || c.Max != nil || c.Pattern != "" || len(c.In) > 0
+	if !hasItemConstraints {
+		return options, nil
+	}
+	typeRule := itemRule + protoType + "."
+	switch {
+	case enumType != nil:
+		if c.Min != nil || c.Max != nil || c.Pattern != "" {
+			return nil, fmt.Errorf("min, max and pattern do not apply to enum fields")
+		}
+		for _, v := range c.In {
+			number, err := enumNumber(enumType, v)
+			if err != nil {
+				return nil, err
+			}
+			add(itemRule+"enum.in", strconv.Itoa(number))
+		}
+	case slices.Contains(protoNumberTypes, protoType):
+		if c.Min != nil {
+			bound, err := formatBound(*c.Min, protoType)
+			if err != nil {
+				return nil, fmt.Errorf("min: %w", err)
+			}
+			add(typeRule+"gte", bound)
+		}
+		if c.Max != nil {
+			bound, err := formatBound(*c.Max, protoType)
+			if err != nil {
+				return nil, fmt.Errorf("max: %w", err)
+			}
+			add(typeRule+"lte", bound)
+		}
+		for _, v := range c.In {
+			add(typeRule+"in", v)
+		}
+	case protoType == "string":
+		if c.Pattern != "" {
+			add(typeRule+"pattern", strconv.Quote(c.Pattern))
+		}
+		for _, v := range c.In {
+			add(typeRule+"in", strconv.Quote(v))
+		}
+	default:
+		return nil, fmt.Errorf("min, max, pattern and in do not apply to %s fields", protoType)
+	}
+	return options, nil
+}
+
+// formatBound returns a bound of a number field as written in a buf.validate
+// rule: as an integer for the integer types, which cannot take fractions,
+// or negative numbers for the unsigned ones.
+func formatBound(bound float64, protoType string) (string, error) {
+	switch protoType {
+	case "int32", "int64":
+		if bound != math.Trunc(bound) || bound < math.MinInt64 || bound >= math.MaxInt64 {
+			return "", fmt.Errorf("%v is not a bound of %s fields", bound, protoType)
+		}
+		return strconv.FormatInt(int64(bound), 10), nil
+	case "uint32", "uint64":
+		if bound != math.Trunc(bound) || bound < 0 || bound >= math.MaxUint64 {
+			return "", fmt.Errorf("%v is not a bound of %s fields", bound, protoType)
+		}
+		return strconv.FormatUint(uint64(bound), 10), nil
+	}
+	return strconv.FormatFloat(bound, 'f', -1, 64), nil
+}
+
+// writeFieldOptions writes the options of a field, such as
+// " [(buf.validate.field).required = true]", unless it has none.
+func writeFieldOptions(builder *strings.Builder, options []option) {
+	if len(options) == 0 {
+		return
+	}
+	builder.WriteString(" [")
+	for i, opt := range options {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(opt.Name)
+		builder.WriteString(" = ")
+		builder.WriteString(opt.Value)
+	}
+	builder.WriteString("]")
+}
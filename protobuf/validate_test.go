@@ -0,0 +1,233 @@
+package protobuf
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/goatx/goat"
+	"github.com/google/go-cmp/cmp"
+)
+
+type testJoinClient struct {
+	goat.StateMachine
+	Server *testJoinService
+	Joined bool
+}
+
+type testJoinService struct {
+	goat.StateMachine
+}
+
+type testJoinRequest struct {
+	Message[*testJoinClient, *testJoinService]
+	RoomID int64    `validate:"min=1"`
+	Name   string   `validate:"required,maxlen=20,pattern=^[a-z]+$"`
+	Kind   string   `validate:"in=public|private"`
+	Scores []int32  `validate:"maxlen=3,min=0,max=100"`
+	Avatar []byte   `validate:"minlen=1"`
+	Labels []string `validate:"pattern=^#"`
+}
+
+type testJoinResponse struct {
+	Message[*testJoinService, *testJoinClient]
+	Seat int32 `validate:"min=1,max=10"`
+}
+
+func TestAnalyzeMessage_validation(t *testing.T) {
+	got := analyzeMessage(&testJoinRequest{})
+
+	want := &message{
+		Name: "testJoinRequest",
+		Fields: []field{
+			{Name: "RoomID", Type: "int64", Number: 1, Options: []option{
+				{Name: "(buf.validate.field).int64.gte", Value: "1"},
+			}},
+			{Name: "Name", Type: "string", Number: 2, Options: []option{
+				{Name: "(buf.validate.field).required", Value: "true"},
+				{Name: "(buf.validate.field).string.max_len", Value: "20"},
+				{Name: "(buf.validate.field).string.pattern", Value: `"^[a-z]+$"`},
+			}},
+			{Name: "Kind", Type: "string", Number: 3, Options: []option{
+				{Name: "(buf.validate.field).string.in", Value: `"public"`},
+				{Name: "(buf.validate.field).string.in", Value: `"private"`},
+			}},
+			{Name: "Scores", Type: "int32", Number: 4, IsRepeated: true, Options: []option{
+				{Name: "(buf.validate.field).repeated.max_items", Value: "3"},
+				{Name: "(buf.validate.field).repeated.items.int32.gte", Value: "0"},
+				{Name: "(buf.validate.field).repeated.items.int32.lte", Value: "100"},
+			}},
+			{Name: "Avatar", Type: "bytes", Number: 5, Options: []option{
+				{Name: "(buf.validate.field).bytes.min_len", Value: "1"},
+			}},
+			{Name: "Labels", Type: "string", Number: 6, IsRepeated: true, Options: []option{
+				{Name: "(buf.validate.field).repeated.items.string.pattern", Value: `"^#"`},
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("analyzeMessage() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type testUnsupportedConstraint struct {
+	Message[*testJoinService, *testJoinClient]
+	Response *testJoinResponse `validate:"minlen=1"`
+}
+
+func TestAnalyzeMessage_invalidValidateTag(t *testing.T) {
+	defer func() {
+		want := "invalid validate tag on testUnsupportedConstraint.Response: minlen needs a string, bytes, list or map, not protobuf.testJoinResponse"
+		if diff := cmp.Diff(want, recover()); diff != "" {
+			t.Errorf("panic mismatch (-want +got):\n%s", diff)
+		}
+	}()
+	analyzeMessage(&testUnsupportedConstraint{})
+}
+
+type testEnumFilterRequest struct {
+	Message[*testJoinClient, *testJoinService]
+	Status     TestStatus     `validate:"in=in-progress|active"`
+	Priorities []TestPriority `validate:"in=2"`
+}
+
+type testUnknownEnumValue struct {
+	Message[*testJoinClient, *testJoinService]
+	Status TestStatus `validate:"in=done"`
+}
+
+func TestAnalyzeMessage_enumValidation(t *testing.T) {
+	RegisterEnum(TestStatusActive, TestStatusInProgress)
+	RegisterEnum(TestPriorityLow, TestPriorityHigh)
+
+	got := analyzeMessage(&testEnumFilterRequest{})
+	want := &message{
+		Name: "testEnumFilterRequest",
+		Fields: []field{
+			{Name: "Status", Type: "TestStatus", Number: 1, Options: []option{
+				{Name: "(buf.validate.field).enum.in", Value: "2"},
+				{Name: "(buf.validate.field).enum.in", Value: "1"},
+			}},
+			{Name: "Priorities", Type: "TestPriority", Number: 2, IsRepeated: true, Options: []option{
+				{Name: "(buf.validate.field).repeated.items.enum.in", Value: "2"},
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("analyzeMessage() mismatch (-want +got):\n%s", diff)
+	}
+
+	defer func() {
+		want := `invalid validate tag on testUnknownEnumValue.Status: "done" is not a registered value of TestStatus`
+		if diff := cmp.Diff(want, recover()); diff != "" {
+			t.Errorf("panic mismatch (-want +got):\n%s", diff)
+		}
+	}()
+	analyzeMessage(&testUnknownEnumValue{})
+}
+
+func TestFileWriter_generateFileContent_validation(t *testing.T) {
+	writer := &fileWriter{packageName: "test.package"}
+	definitions := &definitions{
+		Messages: []*message{analyzeMessage(&testJoinResponse{})},
+	}
+
+	want := `syntax = "proto3";
+
+package test.package;
+
+import "buf/validate/validate.proto";
+
+message testJoinResponse {
+  int32 seat = 1 [(buf.validate.field).int32.gte = 1, (buf.validate.field).int32.lte = 10];
+}
+`
+	if got := writer.generateFileContent(definitions); got != want {
+		t.Errorf("generateFileContent() = %q, want %q", got, want)
+	}
+}
+
+func TestSendTo_validation(t *testing.T) {
+	serviceSpec := NewServiceSpec(&testJoinService{})
+	serviceState := &TestIdleState{}
+	serviceSpec.DefineStates(serviceState).SetInitialState(serviceState)
+	OnMessage(serviceSpec, serviceState, "Join",
+		func(ctx context.Context, req *testJoinRequest, s *testJoinService) Response[*testJoinResponse] {
+			// The service hands out a seat past the last one.
+			return SendTo(ctx, req.Sender(), &testJoinResponse{Seat: 11})
+		})
+
+	clientSpec := goat.NewStateMachineSpec(&testJoinClient{})
+	clientState := &TestIdleState{}
+	clientSpec.DefineStates(clientState).SetInitialState(clientState)
+	goat.OnEntry(clientSpec, clientState, func(ctx context.Context, c *testJoinClient) {
+		goat.SendTo(ctx, c.Server, &testJoinRequest{RoomID: 1, Name: "alice"})
+	})
+	goat.OnEvent(clientSpec, clientState, func(ctx context.Context, res *testJoinResponse, c *testJoinClient) {
+		c.Joined = true
+	})
+
+	service, err := serviceSpec.NewInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := clientSpec.NewInstance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Server = service
+
+	result, err := goat.Test(
+		goat.WithStateMachines(client, service),
+		goat.WithReporter(goat.NewQuietReporter()),
+	)
+	if err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+
+	var violated []string
+	for _, v := range result.Violations {
+		violated = append(violated, v.Rule)
+	}
+	if diff := cmp.Diff([]string{"Always valid messages"}, violated); diff != "" {
+		t.Fatalf("violated rules mismatch (-want +got):\n%s", diff)
+	}
+	// The violation ends with the world the invalid message is sent in,
+	// which holds nothing else than the message.
+	last := result.Violations[0].Path[len(result.Violations[0].Path)-1]
+	if len(last.SharedVars) != 0 {
+		t.Errorf("shared variables of the violating world = %v, want none", last.SharedVars)
+	}
+	if len(last.QueuedEvents) != 1 || last.QueuedEvents[0].EventName != "testJoinResponse" {
+		t.Errorf("queued events of the violating world = %v, want the testJoinResponse", last.QueuedEvents)
+	}
+}
+
+func TestFormatBound(t *testing.T) {
+	tests := []struct {
+		bound     float64
+		protoType string
+		want      string
+		wantErr   bool
+	}{
+		{bound: 1, protoType: "int32", want: "1"},
+		{bound: -5, protoType: "int64", want: "-5"},
+		{bound: 1e15, protoType: "int64", want: "1000000000000000"},
+		{bound: 0, protoType: "uint64", want: "0"},
+		{bound: 0.5, protoType: "double", want: "0.5"},
+		{bound: 1.5, protoType: "int32", wantErr: true},
+		{bound: -1, protoType: "uint32", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v %s", tt.bound, tt.protoType), func(t *testing.T) {
+			got, err := formatBound(tt.bound, tt.protoType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("formatBound() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("formatBound() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
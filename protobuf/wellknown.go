@@ -0,0 +1,64 @@
+package protobuf
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/goatx/goat"
+)
+
+// Empty is a message without fields, generated as google.protobuf.Empty.
+// Use it as the input or output of methods that take or return nothing.
+//
+// Example:
+//
+//	protobuf.OnMessage(spec, idle, "Ping",
+//	    func(ctx context.Context, req *protobuf.Empty[*Client, *Server], s *Server) protobuf.Response[*protobuf.Empty[*Server, *Client]] {
+//	        return protobuf.SendTo(ctx, req.Sender(), &protobuf.Empty[*Server, *Client]{})
+//	    })
+type Empty[Sender goat.AbstractStateMachine, Recipient goat.AbstractStateMachine] struct {
+	Message[Sender, Recipient]
+}
+
+const (
+	timestampType = "google.protobuf.Timestamp"
+	durationType  = "google.protobuf.Duration"
+	emptyType     = "google.protobuf.Empty"
+)
+
+// wellKnownImports maps the well-known types to the file defining them.
+var wellKnownImports = map[string]string{
+	timestampType: "google/protobuf/timestamp.proto",
+	durationType:  "google/protobuf/duration.proto",
+	emptyType:     "google/protobuf/empty.proto",
+}
+
+func isEmptyType(t reflect.Type) bool {
+	t = derefType(t)
+	return t.Kind() == reflect.Struct &&
+		t.PkgPath() == "github.com/goatx/goat/protobuf" &&
+		strings.HasPrefix(t.Name(), "Empty[")
+}
+
+// wellKnownType returns the well-known type standing for a Go type, or "".
+func wellKnownType(t reflect.Type) string {
+	switch {
+	case t == reflect.TypeFor[time.Time]():
+		return timestampType
+	case t == reflect.TypeFor[time.Duration]():
+		return durationType
+	case isEmptyType(t):
+		return emptyType
+	default:
+		return ""
+	}
+}
+
+// messageTypeName returns the proto type of a method input or output.
+func messageTypeName(msg AbstractMessage) string {
+	if isEmptyType(reflect.TypeOf(msg)) {
+		return emptyType
+	}
+	return getEventTypeName(msg)
+}
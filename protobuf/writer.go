@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -14,6 +15,8 @@ type fileWriter struct {
 	outputDir   string
 	packageName string
 	goPackage   string
+	// options are the file options other than go_package.
+	options map[string]string
 }
 
 func newFileWriter(outputDir, packageName, goPackage string) *fileWriter {
@@ -25,13 +28,13 @@ func newFileWriter(outputDir, packageName, goPackage string) *fileWriter {
 }
 
 func (w *fileWriter) writeProtoFile(filename string, definitions *definitions) error {
-	if err := os.MkdirAll(w.outputDir, 0o750); err != nil {
+	filePath := filepath.Join(w.outputDir, filename)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o750); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	content := w.generateFileContent(definitions)
 
-	filePath := filepath.Join(w.outputDir, filename)
 	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
 		return fmt.Errorf("failed to write proto file: %w", err)
 	}
@@ -44,24 +47,40 @@ func (w *fileWriter) generateFileContent(definitions *definitions) string {
 
 	builder.WriteString("syntax = \"proto3\";\n\n")
 
+	// The package, imports and options are written as sections separated by
+	// blank lines.
+	var sections []string
 	if w.packageName != "" {
-		builder.WriteString("package ")
-		builder.WriteString(w.packageName)
-		builder.WriteString(";\n")
+		sections = append(sections, "package "+w.packageName+";\n")
 	}
-
-	if w.packageName != "" && w.goPackage != "" {
-		builder.WriteString("\n")
+	if imports := fileImports(definitions); len(imports) > 0 {
+		var section strings.Builder
+		for _, path := range imports {
+			section.WriteString("import \"" + path + "\";\n")
+		}
+		sections = append(sections, section.String())
 	}
-
-	if w.goPackage != "" {
-		builder.WriteString("option go_package = \"")
-		builder.WriteString(w.goPackage)
-		builder.WriteString("\";\n")
+	if w.goPackage != "" || len(w.options) > 0 {
+		var section strings.Builder
+		if w.goPackage != "" {
+			section.WriteString("option go_package = \"" + w.goPackage + "\";\n")
+		}
+		for _, name := range sortedKeys(w.options) {
+			section.WriteString("option " + name + " = " + w.options[name] + ";\n")
+		}
+		sections = append(sections, section.String())
+	}
+	for _, section := range sections {
+		builder.WriteString(section)
+		builder.WriteString("\n")
 	}
 
-	if w.packageName != "" || w.goPackage != "" {
+	for i, enum := range definitions.Enums {
+		w.writeEnum(&builder, enum)
 		builder.WriteString("\n")
+		if i < len(definitions.Enums)-1 || len(definitions.Messages) > 0 || len(definitions.Services) > 0 {
+			builder.WriteString("\n")
+		}
 	}
 
 	for i, message := range definitions.Messages {
@@ -84,10 +103,26 @@ func (w *fileWriter) generateFileContent(definitions *definitions) string {
 }
 
 func (*fileWriter) writeMessage(builder *strings.Builder, message *message) {
+	writeComment(builder, "", message.Description)
 	builder.WriteString("message ")
 	builder.WriteString(message.Name)
 	builder.WriteString(" {\n")
 
+	if len(message.ReservedNumbers) > 0 {
+		numbers := make([]string, len(message.ReservedNumbers))
+		for i, number := range message.ReservedNumbers {
+			numbers[i] = strconv.Itoa(number)
+		}
+		builder.WriteString("  reserved ")
+		builder.WriteString(strings.Join(numbers, ", "))
+		builder.WriteString(";\n")
+	}
+	if len(message.ReservedNames) > 0 {
+		builder.WriteString("  reserved \"")
+		builder.WriteString(strings.Join(message.ReservedNames, "\", \""))
+		builder.WriteString("\";\n")
+	}
+
 	for _, field := range message.Fields {
 		fieldType := field.Type
 		if field.IsRepeated {
@@ -95,12 +130,30 @@ func (*fileWriter) writeMessage(builder *strings.Builder, message *message) {
 		}
 
 		fieldName := strcase.ToSnakeCase(field.Name)
+		writeComment(builder, "  ", field.Description)
 		builder.WriteString("  ")
 		builder.WriteString(fieldType)
 		builder.WriteString(" ")
 		builder.WriteString(fieldName)
 		builder.WriteString(" = ")
 		builder.WriteString(strconv.Itoa(field.Number))
+		writeFieldOptions(builder, field.Options)
+		builder.WriteString(";\n")
+	}
+
+	builder.WriteString("}")
+}
+
+func (*fileWriter) writeEnum(builder *strings.Builder, enum *enum) {
+	builder.WriteString("enum ")
+	builder.WriteString(enum.Name)
+	builder.WriteString(" {\n")
+
+	for _, value := range enum.Values {
+		builder.WriteString("  ")
+		builder.WriteString(value.Name)
+		builder.WriteString(" = ")
+		builder.WriteString(strconv.Itoa(value.Number))
 		builder.WriteString(";\n")
 	}
 
@@ -112,15 +165,101 @@ func (*fileWriter) writeService(builder *strings.Builder, service *service) {
 	builder.WriteString(service.Name)
 	builder.WriteString(" {\n")
 
+	for _, option := range service.Options {
+		builder.WriteString("  option ")
+		builder.WriteString(option.Name)
+		builder.WriteString(" = ")
+		builder.WriteString(option.Value)
+		builder.WriteString(";\n")
+	}
+	if len(service.Options) > 0 && len(service.Methods) > 0 {
+		builder.WriteString("\n")
+	}
+
 	for _, method := range service.Methods {
+		writeComment(builder, "  ", method.Description)
 		builder.WriteString("  rpc ")
 		builder.WriteString(method.Name)
 		builder.WriteString("(")
+		if method.ClientStreaming {
+			builder.WriteString("stream ")
+		}
 		builder.WriteString(method.InputType)
 		builder.WriteString(") returns (")
+		if method.ServerStreaming {
+			builder.WriteString("stream ")
+		}
 		builder.WriteString(method.OutputType)
-		builder.WriteString(");\n")
+		if len(method.Options) == 0 {
+			builder.WriteString(");\n")
+			continue
+		}
+		builder.WriteString(") {\n")
+		for _, option := range method.Options {
+			builder.WriteString("    option ")
+			builder.WriteString(option.Name)
+			builder.WriteString(" = ")
+			builder.WriteString(option.Value)
+			builder.WriteString(";\n")
+		}
+		builder.WriteString("  }\n")
 	}
 
 	builder.WriteString("}")
 }
+
+// writeComment writes text as a leading comment, one line comment per line.
+func writeComment(builder *strings.Builder, indent, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		builder.WriteString(indent)
+		builder.WriteString("//")
+		if line != "" {
+			builder.WriteString(" ")
+			builder.WriteString(line)
+		}
+		builder.WriteString("\n")
+	}
+}
+
+// fileImports returns the files imported by definitions, including those of
+// the well-known types they use, in order.
+func fileImports(definitions *definitions) []string {
+	imports := slices.Clone(definitions.Imports)
+	use := func(typeName string) {
+		if path, ok := wellKnownImports[typeName]; ok {
+			imports = append(imports, path)
+		}
+	}
+	for _, message := range definitions.Messages {
+		for _, field := range message.Fields {
+			for _, typeName := range referencedTypes(field.Type) {
+				use(typeName)
+			}
+			for _, opt := range field.Options {
+				if strings.HasPrefix(opt.Name, validateOption) {
+					imports = append(imports, validateImport)
+				}
+			}
+		}
+	}
+	for _, service := range definitions.Services {
+		for _, method := range service.Methods {
+			use(method.InputType)
+			use(method.OutputType)
+		}
+	}
+	return sortedUnique(imports)
+}
+
+// referencedTypes returns the types a field type refers to: the key and
+// value types of a map, or else the type itself.
+func referencedTypes(fieldType string) []string {
+	if inner, ok := strings.CutPrefix(fieldType, "map<"); ok {
+		key, value, _ := strings.Cut(strings.TrimSuffix(inner, ">"), ",")
+		return []string{strings.TrimSpace(key), strings.TrimSpace(value)}
+	}
+	return []string{fieldType}
+}
@@ -86,6 +86,19 @@ option go_package = "github.com/test/proto";
 								InputType:  "GetUserRequest",
 								OutputType: "GetUserResponse",
 							},
+							{
+								Name:            "WatchUsers",
+								InputType:       "GetUserRequest",
+								OutputType:      "GetUserResponse",
+								ServerStreaming: true,
+							},
+							{
+								Name:            "SyncUsers",
+								InputType:       "GetUserRequest",
+								OutputType:      "GetUserResponse",
+								ClientStreaming: true,
+								ServerStreaming: true,
+							},
 						},
 					},
 				},
@@ -99,6 +112,8 @@ option go_package = "github.com/test/proto";
 service UserService {
   rpc CreateUser(CreateUserRequest) returns (CreateUserResponse);
   rpc GetUser(GetUserRequest) returns (GetUserResponse);
+  rpc WatchUsers(GetUserRequest) returns (stream GetUserResponse);
+  rpc SyncUsers(stream GetUserRequest) returns (stream GetUserResponse);
 }
 `,
 		},
@@ -147,6 +162,61 @@ message User {
   string user_name = 2;
   repeated string tags = 3;
 }
+`,
+		},
+		{
+			name: "generates enums, maps and message fields",
+			writer: &fileWriter{
+				packageName: "test.package",
+			},
+			definitions: &definitions{
+				Enums: []*enum{
+					{
+						Name: "Role",
+						Values: []enumValue{
+							{Name: "ROLE_UNSPECIFIED", Number: 0},
+							{Name: "ROLE_ADMIN", Number: 1},
+						},
+					},
+				},
+				Messages: []*message{
+					{
+						Name: "Address",
+						Fields: []field{
+							{Name: "City", Type: "string", Number: 1},
+						},
+					},
+					{
+						Name: "User",
+						Fields: []field{
+							{Name: "Role", Type: "Role", Number: 1},
+							{Name: "Addresses", Type: "Address", Number: 2, IsRepeated: true},
+							{Name: "Scores", Type: "map<string, int64>", Number: 3},
+							{Name: "Avatar", Type: "bytes", Number: 4},
+						},
+					},
+				},
+				Services: []*service{},
+			},
+			wantContent: `syntax = "proto3";
+
+package test.package;
+
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_ADMIN = 1;
+}
+
+message Address {
+  string city = 1;
+}
+
+message User {
+  Role role = 1;
+  repeated Address addresses = 2;
+  map<string, int64> scores = 3;
+  bytes avatar = 4;
+}
 `,
 		},
 	}
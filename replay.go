@@ -0,0 +1,185 @@
+package goat
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Choice is one scheduling decision of the model checker: which state
+// machine, or which orthogonal region of it, processes the event at the head
+// of its queue, and which handler processes it when several are registered
+// for the same state and event.
+type Choice struct {
+	// Machine is the ID of the state machine.
+	Machine string
+	// Region is the orthogonal region, or empty for the machine's main
+	// states.
+	Region string
+	// Handler is the handler to run, as in Step.Handler, or -1 when the event
+	// is processed without a handler.
+	Handler int
+	// Expected is the world the choice led to during model checking. Replay
+	// reports an error when replaying the choice leads to a different world.
+	// It is not checked when it has no state machines.
+	Expected WorldSnapshot
+}
+
+// Schedule returns the scheduling choices that lead from the initial world
+// through the violation: the choices of Steps followed, for a temporal rule
+// violation, by one round of the loop. Steps where no state machine could
+// move are left out. Pass the schedule to Replay to execute the violation
+// again.
+//
+// Returns one Choice per step of the violation.
+//
+// Example:
+//
+//	schedule := result.Violations[0].Schedule()
+//	_, err := goat.Replay(schedule, opts...)
+func (v *Violation) Schedule() []Choice {
+	schedule := make([]Choice, 0, len(v.Steps)+len(v.LoopSteps))
+	for i, step := range v.Steps {
+		if step.Machine == "" {
+			continue
+		}
+		schedule = append(schedule, Choice{Machine: step.Machine, Region: step.Region, Handler: step.Handler, Expected: snapshotAt(v.Path, i+1)})
+	}
+	for i, step := range v.LoopSteps {
+		if step.Machine == "" {
+			continue
+		}
+		// The last step of the loop returns to its first world.
+		schedule = append(schedule, Choice{Machine: step.Machine, Region: step.Region, Handler: step.Handler, Expected: snapshotAt(v.Loop, (i+1)%max(len(v.Loop), 1))})
+	}
+	return schedule
+}
+
+func snapshotAt(snapshots []WorldSnapshot, i int) WorldSnapshot {
+	if i < len(snapshots) {
+		return snapshots[i]
+	}
+	return WorldSnapshot{}
+}
+
+// Replay executes a schedule, such as one returned by Violation.Schedule,
+// from the initial world of the model configured by opts. Each choice runs
+// only the chosen handler, directly in the calling goroutine, so breakpoints
+// set in handlers stop at each step of the schedule in order. Replay checks
+// that each choice leads to the world it expects, which fails when handlers
+// behave differently than during model checking, for example because they
+// depend on state outside the state machines.
+//
+// Parameters:
+//   - schedule: The choices to execute, in order
+//   - opts: Configuration options, as passed to Test
+//
+// Returns the worlds visited, starting with the initial world, and an error
+// if the model cannot be built, a choice cannot be executed, or it leads to a
+// different world than expected. The worlds visited before the error are
+// returned too.
+//
+// Example:
+//
+//	result, _ := goat.Test(opts...)
+//	for _, v := range result.Violations {
+//	    if _, err := goat.Replay(v.Schedule(), opts...); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func Replay(schedule []Choice, opts ...Option) ([]WorldSnapshot, error) {
+	model, err := newModel(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	env := model.initial.env
+	worlds := []WorldSnapshot{model.buildWorldSnapshot(model.initial)}
+	for i, c := range schedule {
+		next, err := replayChoice(env, c)
+		if err != nil {
+			return worlds, fmt.Errorf("step %d: %w", i, err)
+		}
+		snapshot := model.buildWorldSnapshot(newWorld(next))
+		worlds = append(worlds, snapshot)
+		if len(c.Expected.StateMachines) > 0 && !reflect.DeepEqual(snapshot, c.Expected) {
+			return worlds, fmt.Errorf("step %d (%s): the world differs from the expected one: %s",
+				i, describeStepText(describeStep(env, next, c.Machine, c.Region)), describeDifferences(c.Expected, snapshot))
+		}
+		env = next
+	}
+	return worlds, nil
+}
+
+func replayChoice(env environment, c Choice) (environment, error) {
+	sm, ok := env.machines[c.Machine]
+	if !ok {
+		return environment{}, fmt.Errorf("no state machine %s", c.Machine)
+	}
+	target := c.Machine
+	if c.Region != "" {
+		target += "#" + c.Region
+	}
+	if len(env.queue[queueID(c.Machine, c.Region)]) == 0 {
+		return environment{}, fmt.Errorf("no event is queued for %s", target)
+	}
+	if getInnerStateMachine(sm).halted {
+		return environment{}, fmt.Errorf("%s is halted", c.Machine)
+	}
+
+	handler := c.Handler
+	if handler < 0 {
+		handler = anyHandler
+	}
+	lss, err := stepLocalHandler(env, c.Machine, c.Region, handler)
+	if err != nil {
+		return environment{}, err
+	}
+	for _, ls := range lss {
+		if ls.handler() == c.Handler {
+			return ls.env, nil
+		}
+	}
+	if c.Handler < 0 {
+		return environment{}, fmt.Errorf("%s processed its event with a handler, not without one", target)
+	}
+	return environment{}, fmt.Errorf("%s processed its event without a handler, not with handler %d", target, c.Handler)
+}
+
+// describeDifferences lists how got differs from want, such as
+// "Server: Count is 2, not 1".
+func describeDifferences(want, got WorldSnapshot) string {
+	d := diffWorlds(want, got)
+	var diffs []string
+	for _, m := range d.Machines {
+		if m.Added {
+			diffs = append(diffs, m.Name+" is not expected")
+			continue
+		}
+		if m.StateAfter != "" {
+			diffs = append(diffs, fmt.Sprintf("%s: state is %s, not %s", m.Name, fieldsLabel(m.StateAfter), fieldsLabel(m.StateBefore)))
+		}
+		for _, r := range m.Regions {
+			diffs = append(diffs, fmt.Sprintf("%s#%s: state is %s, not %s", m.Name, r.Name, fieldsLabel(r.After), fieldsLabel(r.Before)))
+		}
+		for _, f := range m.Fields {
+			diffs = append(diffs, fmt.Sprintf("%s: %s is %s, not %s", m.Name, f.Name, f.After, f.Before))
+		}
+	}
+	if len(got.StateMachines) < len(want.StateMachines) {
+		diffs = append(diffs, fmt.Sprintf("%d state machine(s) are missing", len(want.StateMachines)-len(got.StateMachines)))
+	}
+	for _, e := range d.QueueRemoved {
+		diffs = append(diffs, "missing queued event "+queueEntryLabel(e))
+	}
+	for _, e := range d.QueueAdded {
+		diffs = append(diffs, "unexpected queued event "+queueEntryLabel(e))
+	}
+	for _, v := range d.SharedVars {
+		diffs = append(diffs, fmt.Sprintf("%s is %s, not %s", v.Name, v.After, v.Before))
+	}
+	if len(diffs) == 0 {
+		return "the worlds differ"
+	}
+	return strings.Join(diffs, "; ")
+}
@@ -0,0 +1,152 @@
+package goat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newReplayTestOptions(t *testing.T, calls *[]string) []Option {
+	t.Helper()
+	idle := newTestState("idle")
+	accepted := newTestState("accepted")
+	rejected := newTestState("rejected")
+
+	receiverSpec := NewStateMachineSpec(&testStateMachine{})
+	receiverSpec.DefineStates(idle, accepted, rejected).SetInitialState(idle)
+	OnEvent(receiverSpec, idle, func(ctx context.Context, _ *testEvent, _ *testStateMachine) {
+		*calls = append(*calls, "accept")
+		Goto(ctx, accepted)
+	})
+	OnEvent(receiverSpec, idle, func(ctx context.Context, _ *testEvent, _ *testStateMachine) {
+		*calls = append(*calls, "reject")
+		Goto(ctx, rejected)
+	})
+	receiver, err := receiverSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	senderSpec := NewStateMachineSpec(&hierarchyTestStateMachine{})
+	senderSpec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(senderSpec, idle, func(ctx context.Context, _ *hierarchyTestStateMachine) {
+		*calls = append(*calls, "send")
+		SendTo(ctx, receiver, &testEvent{Value: 1})
+	})
+	sender, err := senderSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	return []Option{
+		WithStateMachines(receiver, sender),
+		WithRules(Always(NewCondition("not rejected", receiver, func(sm *testStateMachine) bool {
+			return !sameState(sm.currentState(), rejected)
+		}))),
+		WithReporter(NewQuietReporter()),
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var calls []string
+	opts := newReplayTestOptions(t, &calls)
+	result, err := Test(opts...)
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	if len(result.Violations) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(result.Violations))
+	}
+	violation := result.Violations[0]
+
+	schedule := violation.Schedule()
+	wantSchedule := []Choice{
+		{Machine: "hierarchyTestStateMachine", Handler: 0},
+		{Machine: "testStateMachine", Handler: -1},
+		{Machine: "testStateMachine", Handler: 1},
+		{Machine: "testStateMachine", Handler: -1},
+		{Machine: "testStateMachine", Handler: 0},
+	}
+	if diff := cmp.Diff(wantSchedule, schedule, cmp.FilterPath(func(p cmp.Path) bool {
+		return p.Last().String() == ".Expected"
+	}, cmp.Ignore())); diff != "" {
+		t.Errorf("Schedule() mismatch (-want +got):\n%s", diff)
+	}
+
+	calls = nil
+	worlds, err := Replay(schedule, opts...)
+	if err != nil {
+		t.Fatalf("Replay() returned error: %v", err)
+	}
+	if diff := cmp.Diff(violation.Path, worlds); diff != "" {
+		t.Errorf("Replay() worlds mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"send", "reject"}, calls); diff != "" {
+		t.Errorf("handlers called during Replay() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReplay_errors(t *testing.T) {
+	var calls []string
+	opts := newReplayTestOptions(t, &calls)
+	result, err := Test(opts...)
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	schedule := result.Violations[0].Schedule()
+
+	tests := []struct {
+		name     string
+		schedule func() []Choice
+		want     string
+	}{
+		{
+			name: "unknown machine",
+			schedule: func() []Choice {
+				return []Choice{{Machine: "missing"}}
+			},
+			want: "step 0: no state machine missing",
+		},
+		{
+			name: "empty queue",
+			schedule: func() []Choice {
+				return []Choice{schedule[0], {Machine: "hierarchyTestStateMachine", Handler: -1}}
+			},
+			want: "step 1: no event is queued for hierarchyTestStateMachine",
+		},
+		{
+			name: "unknown handler",
+			schedule: func() []Choice {
+				s := append([]Choice{}, schedule[:3]...)
+				s[2].Handler = 2
+				return s
+			},
+			want: "step 2: state Name=idle has 2 handler(s) for testEvent, not handler 2",
+		},
+		{
+			name: "different world",
+			schedule: func() []Choice {
+				s := append([]Choice{}, schedule[:3]...)
+				s[2].Handler = 0
+				return s
+			},
+			want: "step 2 (testStateMachine processed testEvent(Value=1)): the world differs from the expected one: " +
+				"missing queued event testStateMachine << transitionEvent(To=&{{0} rejected}); " +
+				"unexpected queued event testStateMachine << transitionEvent(To=&{{0} accepted})",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Replay(tt.schedule(), opts...)
+			if err == nil {
+				t.Fatalf("Replay() returned no error, want %q", tt.want)
+			}
+			if !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("Replay() error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package goat
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WriteHTMLReport performs model checking and writes a single self-contained
+// HTML file describing the run: the summary, a step-through trace of each
+// violation with the fields that changed at each step highlighted, the
+// handler and state coverage, and the explored world graph as collapsible
+// sections. The file needs no external resources, so it can be kept as a CI
+// artifact.
+//
+// Parameters:
+//   - w: Writer to output the HTML report to
+//   - opts: Configuration options including state machines and rules
+//
+// Returns an error if model creation, solving or writing the report fails.
+//
+// Example:
+//
+//	file, err := os.Create("report.html")
+//	if err != nil {
+//	    return err
+//	}
+//	defer file.Close()
+//	err = goat.WriteHTMLReport(file, goat.WithStateMachines(sm), goat.WithRules(goat.Always(cond)))
+func WriteHTMLReport(w io.Writer, opts ...Option) error {
+	model, err := newModel(opts...)
+	if err != nil {
+		return err
+	}
+
+	model.explored = newExploration()
+	start := time.Now()
+	if err := model.Solve(); err != nil {
+		return err
+	}
+	trResults := model.checkLTL()
+	executionTime := time.Since(start).Milliseconds()
+
+	result := model.buildResult(trResults, executionTime)
+	result.Coverage = model.coverage()
+
+	return htmlReportTemplate.Execute(w, model.htmlReport(result))
+}
+
+type htmlReport struct {
+	Summary    Summary
+	Violations []htmlViolation
+	Coverage   *Coverage
+	Worlds     []htmlWorld
+}
+
+type htmlViolation struct {
+	Rule string
+	Path []htmlTraceWorld
+	Loop []htmlTraceWorld
+}
+
+// htmlTraceWorld is one world of a violation trace, along with the step that
+// led to it.
+type htmlTraceWorld struct {
+	Index     int
+	Step      string
+	Machines  []htmlMachine
+	Queue     []string
+	Vars      []htmlField
+	Violation bool
+}
+
+type htmlMachine struct {
+	Name         string
+	State        string
+	StateChanged bool
+	Fields       []htmlField
+}
+
+type htmlField struct {
+	Name    string
+	Value   string
+	Changed bool
+}
+
+type htmlWorld struct {
+	ID      worldID
+	Label   string
+	Initial bool
+	Failed  []ConditionName
+	Next    []worldID
+}
+
+func (m *model) htmlReport(result *Result) htmlReport {
+	report := htmlReport{
+		Summary:  result.Summary,
+		Coverage: result.Coverage,
+	}
+
+	for _, v := range result.Violations {
+		hv := htmlViolation{Rule: v.Rule}
+		if hv.Rule == "" {
+			hv.Rule = "Condition failed"
+		}
+		snapshots, steps := v.trace()
+		trace := htmlTrace(snapshots, steps)
+		hv.Path = trace[:len(v.Path)]
+		switch {
+		case v.Loop != nil:
+			hv.Loop = trace[len(v.Path):]
+		case len(hv.Path) > 0:
+			hv.Path[len(hv.Path)-1].Violation = true
+		}
+		report.Violations = append(report.Violations, hv)
+	}
+
+	worldIDs := make([]worldID, 0, len(m.worlds))
+	for wid := range m.worlds {
+		worldIDs = append(worldIDs, wid)
+	}
+	sort.Slice(worldIDs, func(i, j int) bool { return worldIDs[i] < worldIDs[j] })
+	for _, wid := range worldIDs {
+		w := m.worlds[wid]
+		report.Worlds = append(report.Worlds, htmlWorld{
+			ID:      wid,
+			Label:   w.label(),
+			Initial: wid == m.initial.id,
+			Failed:  w.failedInvariants,
+			Next:    m.accessible[wid],
+		})
+	}
+	return report
+}
+
+// htmlTrace converts the worlds of a violation trace into trace worlds,
+// marking what changed from the world before as reported by diffWorlds.
+// steps[i-1] leads to snapshots[i].
+func htmlTrace(snapshots []WorldSnapshot, steps []Step) []htmlTraceWorld {
+	trace := make([]htmlTraceWorld, 0, len(snapshots))
+	for i, snapshot := range snapshots {
+		tw := htmlTraceWorld{Index: i}
+		// The first world is shown as is rather than as entirely added.
+		prev := snapshot
+		if i > 0 {
+			prev = snapshots[i-1]
+			tw.Step = describeStepText(steps[i-1])
+		}
+
+		for j, md := range machineDiffs(prev, snapshot) {
+			sm := snapshot.StateMachines[j]
+			hm := htmlMachine{Name: sm.Name, State: fieldsLabel(sm.State), StateChanged: md.Added || md.StateAfter != ""}
+			for _, f := range parseDetails(sm.Details) {
+				hm.Fields = append(hm.Fields, htmlField{Name: f.name, Value: f.value, Changed: hasFieldChange(md.Fields, f.name)})
+			}
+			for _, r := range sm.Regions {
+				hm.Fields = append(hm.Fields, htmlField{Name: "#" + r.Name, Value: fieldsLabel(r.State), Changed: hasFieldChange(md.Regions, r.Name)})
+			}
+			tw.Machines = append(tw.Machines, hm)
+		}
+
+		for _, e := range snapshot.QueuedEvents {
+			tw.Queue = append(tw.Queue, queueEntryLabel(e))
+		}
+
+		changedVars := diffWorlds(prev, snapshot).SharedVars
+		for _, v := range snapshot.SharedVars {
+			tw.Vars = append(tw.Vars, htmlField{Name: v.Name, Value: v.Details, Changed: hasFieldChange(changedVars, v.Name)})
+		}
+
+		trace = append(trace, tw)
+	}
+	return trace
+}
+
+func hasFieldChange(changes []FieldChange, name string) bool {
+	return slices.ContainsFunc(changes, func(c FieldChange) bool { return c.Name == name })
+}
+
+func detailValues(details string) map[string]string {
+	values := make(map[string]string)
+	for _, f := range parseDetails(details) {
+		values[f.name] = f.value
+	}
+	return values
+}
+
+// describeStepText summarises a step as a sentence, such as
+// "Client processed eStart and sent eRequest to Server".
+func describeStepText(step Step) string {
+	if step.Machine == "" {
+		return "no state machine can move"
+	}
+	machine := step.Machine
+	if step.Region != "" {
+		machine += "#" + step.Region
+	}
+	text := fmt.Sprintf("%s processed %s", machine, eventLabel(step.EventName, step.EventDetails))
+	sent := make([]string, 0, len(step.Sent))
+	for _, e := range step.Sent {
+		sent = append(sent, fmt.Sprintf("%s to %s", eventLabel(e.EventName, e.Details), e.Target))
+	}
+	if len(sent) > 0 {
+		text += " and sent " + strings.Join(sent, ", ")
+	}
+	return text
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>goat report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1, h2, h3 { font-weight: 600; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; vertical-align: top; font-family: monospace; }
+th { background: #f4f4f4; }
+.changed { background: #fff3b0; font-weight: bold; }
+.violation { border-left: 4px solid #d33; padding-left: 0.5em; }
+.ok { color: #2a7; }
+.bad { color: #d33; }
+.step { color: #555; font-style: italic; }
+details { margin: 0.3em 0; }
+summary { cursor: pointer; }
+pre { background: #f8f8f8; padding: 0.5em; }
+</style>
+</head>
+<body>
+<h1>goat report</h1>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Total worlds</th><td>{{.Summary.TotalWorlds}}</td></tr>
+<tr><th>Execution time</th><td>{{.Summary.ExecutionTimeMs}}ms</td></tr>
+<tr><th>Violations</th><td>{{len .Violations}}</td></tr>
+</table>
+
+<h2>Violations</h2>
+{{- if not .Violations}}
+<p class="ok">No violations found.</p>
+{{- end}}
+{{- range .Violations}}
+<div class="violation">
+<h3>{{.Rule}}</h3>
+{{- template "trace" .Path}}
+{{- if .Loop}}
+<h4>Repeats forever</h4>
+{{- template "trace" .Loop}}
+{{- end}}
+</div>
+{{- end}}
+
+{{- with .Coverage}}
+<h2>Coverage</h2>
+{{- range .Specs}}
+<h3>{{.Name}}</h3>
+<table>
+<tr><th>State</th><th>Reached</th></tr>
+{{- range .States}}
+<tr><td>{{if .Region}}{{.Region}}: {{end}}{{.State}}</td><td class="{{if .Reached}}ok{{else}}bad{{end}}">{{if .Reached}}yes{{else}}no{{end}}</td></tr>
+{{- end}}
+</table>
+{{- if .Handlers}}
+<table>
+<tr><th>Handler</th><th>State</th><th>Alternative</th><th>Fired</th></tr>
+{{- range .Handlers}}
+<tr><td>{{.Handler}}</td><td>{{if .Region}}{{.Region}}: {{end}}{{.State}}</td><td>{{.Alternative}}</td><td class="{{if .Fired}}ok{{else}}bad{{end}}">{{if .Fired}}yes{{else}}no{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .UnhandledEvents}}
+<p>Unhandled events: {{range $i, $e := .UnhandledEvents}}{{if $i}}, {{end}}{{$e}}{{end}}</p>
+{{- end}}
+{{- end}}
+{{- end}}
+
+<h2>World graph</h2>
+<details>
+<summary>{{len .Worlds}} worlds</summary>
+{{- range .Worlds}}
+<details id="w{{.ID}}">
+<summary>World {{.ID}}{{if .Initial}} (initial){{end}}{{range .Failed}} <span class="bad">violates {{.}}</span>{{end}}</summary>
+<pre>{{.Label}}</pre>
+<p>Next:{{range .Next}} <a href="#w{{.}}">{{.}}</a>{{else}} none{{end}}</p>
+</details>
+{{- end}}
+</details>
+</body>
+</html>
+{{define "trace"}}
+{{- range .}}
+<details{{if .Violation}} open{{end}}>
+<summary>[{{.Index}}]{{if .Step}} <span class="step">{{.Step}}</span>{{end}}{{if .Violation}} <span class="bad">violation here</span>{{end}}</summary>
+<table>
+<tr><th>Machine</th><th>State</th><th>Fields</th></tr>
+{{- range .Machines}}
+<tr><td>{{.Name}}</td><td{{if .StateChanged}} class="changed"{{end}}>{{.State}}</td><td>{{range .Fields}}<div{{if .Changed}} class="changed"{{end}}>{{.Name}} = {{.Value}}</div>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- if .Vars}}
+<table>
+<tr><th>Shared variable</th><th>Value</th></tr>
+{{- range .Vars}}
+<tr><td>{{.Name}}</td><td{{if .Changed}} class="changed"{{end}}>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<p>Queue:{{range .Queue}}<br><code>{{.}}</code>{{else}} empty{{end}}</p>
+</details>
+{{- end}}
+{{- end}}
+`))
@@ -0,0 +1,120 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	idle := newTestState("idle")
+	done := newTestState("done")
+
+	spec := NewStateMachineSpec(&testStateMachine{})
+	spec.DefineStates(idle, done).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, sm *testStateMachine) {
+		SendTo(ctx, sm, &testEvent{Value: 1})
+	})
+	OnEvent(spec, idle, func(ctx context.Context, _ *testEvent, _ *testStateMachine) {
+		Goto(ctx, done)
+	})
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteHTMLReport(&buf,
+		WithStateMachines(sm),
+		WithRules(Always(NewCondition("not done", sm, func(sm *testStateMachine) bool {
+			return !sameState(sm.currentState(), done)
+		}))),
+	)
+	if err != nil {
+		t.Fatalf("WriteHTMLReport() returned error: %v", err)
+	}
+	report := buf.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<h3>Always not done</h3>",
+		`<span class="step">testStateMachine processed testEvent(Value=1)</span>`,
+		`<td class="changed">Name=done</td>`,
+		`<span class="bad">violation here</span>`,
+		"<h2>Coverage</h2>",
+		"<td>OnEvent(testEvent)</td>",
+		"(initial)",
+		"testStateMachine &lt;&lt; entryEvent",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+	if strings.Contains(report, "<script") || strings.Contains(report, "http") {
+		t.Error("report should not depend on external resources")
+	}
+}
+
+func TestWriteHTMLReport_initialWorldViolation(t *testing.T) {
+	idle := newTestState("idle")
+	spec := NewStateMachineSpec(&testStateMachine{})
+	spec.DefineStates(idle).SetInitialState(idle)
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteHTMLReport(&buf,
+		WithStateMachines(sm),
+		WithRules(Always(NewCondition("never idle", sm, func(sm *testStateMachine) bool {
+			return !sameState(sm.currentState(), idle)
+		}))),
+	)
+	if err != nil {
+		t.Fatalf("WriteHTMLReport() returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "<h3>Always never idle</h3>") {
+		t.Error("report should list the violation of the initial world")
+	}
+}
+
+func TestHTMLReport_emptyPath(t *testing.T) {
+	m := &model{}
+	report := m.htmlReport(&Result{Violations: []Violation{{Rule: "Always never idle"}}})
+	if len(report.Violations) != 1 || len(report.Violations[0].Path) != 0 {
+		t.Errorf("htmlReport() violations = %+v, want one with an empty path", report.Violations)
+	}
+}
+
+func TestHTMLTrace_machinesOfOneType(t *testing.T) {
+	server := StateMachineSnapshot{Name: "Server", State: "{Name:Name,Type:string,Value:idle}", Details: "{Name:Count,Type:int,Value:0}"}
+	client := StateMachineSnapshot{Name: "Client", State: "{Name:Name,Type:string,Value:idle}", Details: "no fields"}
+	busy := server
+	busy.Details = "{Name:Count,Type:int,Value:1}"
+
+	// The machines are listed in another order in the second world, and
+	// only the second server changed.
+	snapshots := []WorldSnapshot{
+		{StateMachines: []StateMachineSnapshot{server, client, server}},
+		{StateMachines: []StateMachineSnapshot{client, server, busy}},
+	}
+	trace := htmlTrace(snapshots, []Step{{Machine: "Server"}})
+
+	var got [][]bool
+	for _, tw := range trace {
+		var changed []bool
+		for _, m := range tw.Machines {
+			changed = append(changed, m.StateChanged || slices.ContainsFunc(m.Fields, func(f htmlField) bool { return f.Changed }))
+		}
+		got = append(got, changed)
+	}
+	want := [][]bool{{false, false, false}, {false, false, true}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("changed machines mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,229 @@
+package goat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Reporter receives what a model checking run has to say while it runs:
+// warnings about the specification, progress, and the final result. Test,
+// Debug, WriteDot and the other functions taking options report through the
+// Reporter configured with WithReporter or WithOutput. Without either,
+// warnings are written to os.Stderr and the result of Test to os.Stdout.
+type Reporter interface {
+	// Warn reports a problem with the specification that does not stop
+	// model checking, such as a pointer field shared between worlds.
+	Warn(message string)
+	// Progress reports how far the run has got.
+	Progress(p Progress)
+	// Result reports the result of Test once model checking is finished.
+	Result(result *Result)
+}
+
+// WithReporter makes model checking report warnings, progress and the result
+// of Test to r instead of printing them.
+//
+// Parameters:
+//   - r: The Reporter to use, such as NewJSONReporter(w) or NewQuietReporter()
+//
+// Returns an Option that can be passed to Test(), Debug() or WriteDot().
+//
+// Example:
+//
+//	result, err := goat.Test(goat.WithStateMachines(sm), goat.WithReporter(goat.NewQuietReporter()))
+func WithReporter(r Reporter) Option {
+	return optionFunc(func(o *options) {
+		o.reporter = r
+	})
+}
+
+// WithOutput makes model checking write its warnings and the result of Test
+// as text to w, instead of to os.Stderr and os.Stdout, as with
+// WithReporter(NewTextReporter(w)). It also makes Explore write its session
+// to w.
+//
+// Parameters:
+//   - w: Writer to output the text to
+//
+// Returns an Option that can be passed to Test(), Debug(), WriteDot() or
+// Explore().
+//
+// Example:
+//
+//	var buf bytes.Buffer
+//	result, err := goat.Test(goat.WithStateMachines(sm), goat.WithOutput(&buf))
+func WithOutput(w io.Writer) Option {
+	return optionFunc(func(o *options) {
+		o.reporter = NewTextReporter(w)
+		o.output = w
+	})
+}
+
+// defaultReporter returns the reporter used without WithReporter or
+// WithOutput.
+func defaultReporter() Reporter {
+	return &textReporter{out: os.Stdout, warn: os.Stderr}
+}
+
+type textReporter struct {
+	out  io.Writer
+	warn io.Writer
+}
+
+// NewTextReporter returns a Reporter that writes warnings and the result as
+// text to w, the way Test prints them by default. Progress is not written.
+//
+// Parameters:
+//   - w: Writer to output the text to
+//
+// Returns the Reporter.
+//
+// Example:
+//
+//	goat.WithReporter(goat.NewTextReporter(os.Stderr))
+func NewTextReporter(w io.Writer) Reporter {
+	return &textReporter{out: w, warn: w}
+}
+
+func (r *textReporter) Warn(message string) {
+	_, _ = fmt.Fprintf(r.warn, "WARNING: %s\n", message)
+}
+
+func (*textReporter) Progress(Progress) {}
+
+func (r *textReporter) Result(result *Result) {
+	_, _ = fmt.Fprint(r.out, result)
+}
+
+type quietReporter struct{}
+
+// NewQuietReporter returns a Reporter that discards everything, for callers
+// that only look at the returned Result.
+//
+// Returns the Reporter.
+//
+// Example:
+//
+//	goat.WithReporter(goat.NewQuietReporter())
+func NewQuietReporter() Reporter {
+	return quietReporter{}
+}
+
+func (quietReporter) Warn(string)       {}
+func (quietReporter) Progress(Progress) {}
+func (quietReporter) Result(*Result)    {}
+
+type jsonReporter struct {
+	encoder *json.Encoder
+}
+
+// NewJSONReporter returns a Reporter that writes one JSON object per line
+// to w. Every object has a "type" field: "warning" objects carry a
+// "message", "progress" objects a "progress", and the "result" object a
+// "result" in the format written by Result.WriteJSON.
+//
+// Parameters:
+//   - w: Writer to output the JSON lines to
+//
+// Returns the Reporter.
+//
+// Example:
+//
+//	goat.WithReporter(goat.NewJSONReporter(os.Stdout))
+func NewJSONReporter(w io.Writer) Reporter {
+	return &jsonReporter{encoder: json.NewEncoder(w)}
+}
+
+type reportLineJSON struct {
+	Type     string        `json:"type"`
+	Message  string        `json:"message,omitempty"`
+	Progress *progressJSON `json:"progress,omitempty"`
+	Result   *resultJSON   `json:"result,omitempty"`
+}
+
+type progressJSON struct {
+	Phase        string  `json:"phase"`
+	Worlds       int     `json:"worlds"`
+	Frontier     int     `json:"frontier"`
+	Depth        int     `json:"depth"`
+	Rules        int     `json:"rules"`
+	RulesChecked int     `json:"rules_checked"`
+	ProductNodes int     `json:"product_nodes"`
+	Violations   int     `json:"violations"`
+	Rate         float64 `json:"rate"`
+	MemoryBytes  uint64  `json:"memory_bytes"`
+	ElapsedMs    int64   `json:"elapsed_ms"`
+	Done         bool    `json:"done"`
+}
+
+func (r *jsonReporter) Warn(message string) {
+	_ = r.encoder.Encode(reportLineJSON{Type: "warning", Message: message})
+}
+
+func (r *jsonReporter) Progress(p Progress) {
+	_ = r.encoder.Encode(reportLineJSON{Type: "progress", Progress: &progressJSON{
+		Phase:        p.Phase,
+		Worlds:       p.Worlds,
+		Frontier:     p.Frontier,
+		Depth:        p.Depth,
+		Rules:        p.Rules,
+		RulesChecked: p.RulesChecked,
+		ProductNodes: p.ProductNodes,
+		Violations:   p.Violations,
+		Rate:         p.Rate,
+		MemoryBytes:  p.MemoryBytes,
+		ElapsedMs:    p.Elapsed.Milliseconds(),
+		Done:         p.Done,
+	}})
+}
+
+func (r *jsonReporter) Result(result *Result) {
+	doc := result.toJSON()
+	_ = r.encoder.Encode(reportLineJSON{Type: "result", Result: &doc})
+}
+
+type progressReporter struct {
+	textReporter
+}
+
+// NewProgressReporter returns a Reporter that writes warnings and the result
+// as text to w like NewTextReporter, and also a line for each progress
+// report, as formatted by Progress.String.
+//
+// Parameters:
+//   - w: Writer to output the text to
+//
+// Returns the Reporter.
+//
+// Example:
+//
+//	goat.WithReporter(goat.NewProgressReporter(os.Stderr))
+func NewProgressReporter(w io.Writer) Reporter {
+	return &progressReporter{textReporter{out: w, warn: w}}
+}
+
+func (r *progressReporter) Progress(p Progress) {
+	_, _ = fmt.Fprintln(r.out, p.String())
+}
+
+// report returns the reporter the model reports to.
+func (m *model) report() Reporter {
+	if m.reporter == nil {
+		return defaultReporter()
+	}
+	return m.reporter
+}
+
+// warnLines reports each line check writes as a warning.
+func warnLines(r Reporter, check func(w io.Writer)) {
+	var sb strings.Builder
+	check(&sb)
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if line != "" {
+			r.Warn(strings.TrimPrefix(line, "WARNING: "))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package goat
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newReporterTestOptions(t *testing.T, opts ...Option) []Option {
+	t.Helper()
+	spec := NewStateMachineSpec(&smWithDomainPointer{})
+	spec.DefineStates(newTestState("s")).SetInitialState(newTestState("s"))
+	sm, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+	return append([]Option{
+		WithStateMachines(sm),
+		WithRules(Always(BoolCondition("never", false))),
+	}, opts...)
+}
+
+const reporterTestWarning = "type \"smWithDomainPointer\" has pointer field \"Data\" (*int) which will be shared between states during model checking, potentially causing incorrect results. Consider using a value type instead."
+
+func TestWithOutput(t *testing.T) {
+	var buf bytes.Buffer
+	result, err := Test(newReporterTestOptions(t, WithOutput(&buf))...)
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+
+	want := "WARNING: " + reporterTestWarning + "\n" + result.String()
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewQuietReporter(t *testing.T) {
+	var buf bytes.Buffer
+	result, err := Test(newReporterTestOptions(t, WithOutput(&buf), WithReporter(NewQuietReporter()))...)
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+	if !result.HasViolation() {
+		t.Errorf("expected a violation")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestNewJSONReporter(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Test(newReporterTestOptions(t, WithReporter(NewJSONReporter(&buf)))...); err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+
+	var types []string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var msg struct {
+			Type     string `json:"type"`
+			Message  string `json:"message"`
+			Progress struct {
+				Phase  string `json:"phase"`
+				Worlds int    `json:"worlds"`
+				Done   bool   `json:"done"`
+			} `json:"progress"`
+			Result struct {
+				SchemaVersion string `json:"schema_version"`
+				Violations    []any  `json:"violations"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", line, err)
+		}
+		types = append(types, msg.Type)
+		switch msg.Type {
+		case "warning":
+			if msg.Message != reporterTestWarning {
+				t.Errorf("warning = %q, want %q", msg.Message, reporterTestWarning)
+			}
+		case "progress":
+			if msg.Progress.Phase != PhaseExplore || msg.Progress.Worlds != 2 || !msg.Progress.Done {
+				t.Errorf("unexpected progress: %+v", msg.Progress)
+			}
+		case "result":
+			if msg.Result.SchemaVersion != ResultSchemaVersion || len(msg.Result.Violations) != 1 {
+				t.Errorf("unexpected result: %+v", msg.Result)
+			}
+		}
+	}
+	if diff := cmp.Diff([]string{"warning", "progress", "result"}, types); diff != "" {
+		t.Errorf("message types mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewProgressReporter(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteDot(&bytes.Buffer{}, newReporterTestOptions(t,
+		WithRules(EventuallyAlways(BoolCondition("done", true))),
+		WithReporter(NewProgressReporter(&buf)),
+	)...)
+	if err != nil {
+		t.Fatalf("WriteDot() returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a warning and a progress line, got %q", buf.String())
+	}
+	if lines[0] != "WARNING: "+reporterTestWarning {
+		t.Errorf("unexpected warning line %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "explore: 2 worlds, frontier 0, depth 1, 1 violations, ") || !strings.HasSuffix(lines[1], " (done)") {
+		t.Errorf("unexpected progress line %q", lines[1])
+	}
+}
@@ -10,6 +10,8 @@ import (
 type Result struct {
 	Violations []Violation
 	Summary    Summary
+	// Coverage is set when WithCoverage or WithFailOnDeadHandlers is used.
+	Coverage *Coverage
 }
 
 // HasViolation reports whether any violations were found.
@@ -39,6 +41,10 @@ func (r *Result) String() string {
 	if len(invariants) == 0 && len(temporals) == 0 {
 		sb.WriteString("No violations found.\n")
 	}
+	if r.Coverage != nil {
+		sb.WriteString("\n")
+		sb.WriteString(r.Coverage.String())
+	}
 
 	fmt.Fprintln(&sb, "\nModel Checking Summary:")
 	fmt.Fprintf(&sb, "Total Worlds: %d\n", r.Summary.TotalWorlds)
@@ -60,6 +66,52 @@ type Violation struct {
 	Rule string
 	Path []WorldSnapshot
 	Loop []WorldSnapshot
+	// Steps describes how each world of Path leads to the next one, so
+	// Steps[i] takes Path[i] to Path[i+1].
+	Steps []Step
+	// LoopSteps describes how each world of Loop leads to the next one. Its
+	// last step takes the last world of Loop back to the first.
+	LoopSteps []Step
+}
+
+// Step describes the transition between two consecutive worlds of a
+// violation: one state machine processed the event at the head of one of its
+// queues. A Step with an empty Machine means that no state machine could
+// move and the world repeats itself.
+type Step struct {
+	// Machine is the ID of the state machine that processed the event.
+	Machine string
+	// Region is the orthogonal region that processed the event, or empty
+	// when the machine's main states processed it.
+	Region       string
+	EventName    string
+	EventDetails string
+	// Handler is the position of the handler that processed the event among
+	// the handlers the handling state has for the event, which the model
+	// checker explores as non-deterministic alternatives. It is 0 for the
+	// first, and -1 when no handler processed the event. The built-in
+	// handlers that change state on Goto and halt on Halt come before those
+	// registered with OnTransition and OnHalt.
+	Handler int
+	// StateBefore and StateAfter are the state of the processing region
+	// before and after the step.
+	StateBefore string
+	StateAfter  string
+	// Sent lists the events sent by the step with SendTo, Broadcast,
+	// Multicast or Halt, grouped by recipient. Events a machine queues for
+	// itself to enter and exit states are not listed.
+	Sent []SentEvent
+	// Spawned lists the IDs of the state machines created with Spawn.
+	Spawned []string
+}
+
+// SentEvent is an event sent during a Step.
+type SentEvent struct {
+	// Target is the ID of the recipient state machine.
+	Target    string
+	EventName string
+	Details   string
+	Group     string
 }
 
 // WorldSnapshot represents a world — the combination of every state machine's
@@ -68,6 +120,15 @@ type Violation struct {
 type WorldSnapshot struct {
 	StateMachines []StateMachineSnapshot
 	QueuedEvents  []EventSnapshot
+	// SharedVars holds the shared variables declared with WithSharedVar,
+	// sorted by name.
+	SharedVars []SharedVarSnapshot
+}
+
+// SharedVarSnapshot is a snapshot of a shared variable.
+type SharedVarSnapshot struct {
+	Name    string
+	Details string
 }
 
 // StateMachineSnapshot is a snapshot of a single state machine.
@@ -75,13 +136,28 @@ type StateMachineSnapshot struct {
 	Name    string
 	State   string
 	Details string
+	// Regions holds the current state of each orthogonal region defined
+	// with DefineRegion, in definition order.
+	Regions []RegionSnapshot
+}
+
+// RegionSnapshot is a snapshot of an orthogonal region of a state machine.
+type RegionSnapshot struct {
+	Name  string
+	State string
 }
 
 // EventSnapshot is a snapshot of a queued event.
 type EventSnapshot struct {
 	TargetMachine string
-	EventName     string
-	Details       string
+	// TargetRegion is the orthogonal region the event is queued for, or
+	// empty when it is queued for the machine's main states.
+	TargetRegion string
+	EventName    string
+	Details      string
+	// Group names the recipients of a Broadcast or the group of a Multicast
+	// the event was part of, or is empty for events sent with SendTo.
+	Group string
 }
 
 func (m *model) buildResult(trResults []temporalRuleResult, executionTimeMs int64) *Result {
@@ -100,8 +176,9 @@ func (m *model) buildResult(trResults []temporalRuleResult, executionTimeMs int6
 				rule = ""
 			}
 			result.Violations = append(result.Violations, Violation{
-				Rule: rule,
-				Path: m.buildWorldSnapshots(w.path),
+				Rule:  rule,
+				Path:  m.buildWorldSnapshots(w.path),
+				Steps: m.buildSteps(w.path),
 			})
 		}
 	}
@@ -115,9 +192,11 @@ func (m *model) buildResult(trResults []temporalRuleResult, executionTimeMs int6
 			continue
 		}
 		result.Violations = append(result.Violations, Violation{
-			Rule: tr.Rule,
-			Path: m.buildWorldSnapshots(l.Prefix),
-			Loop: m.buildWorldSnapshots(l.Loop),
+			Rule:      tr.Rule,
+			Path:      m.buildWorldSnapshots(l.Prefix),
+			Loop:      m.buildWorldSnapshots(l.Loop),
+			Steps:     m.buildSteps(l.Prefix),
+			LoopSteps: m.buildSteps(append(append([]worldID{}, l.Loop...), l.Loop[0])),
 		})
 	}
 
@@ -143,28 +222,134 @@ func (*model) buildWorldSnapshot(w world) WorldSnapshot {
 	sms := make([]StateMachineSnapshot, 0, len(smIDs))
 	for _, smID := range smIDs {
 		sm := w.env.machines[smID]
+		var regions []RegionSnapshot
+		for _, r := range getInnerStateMachine(sm).regions {
+			regions = append(regions, RegionSnapshot{
+				Name:  r.name,
+				State: getStateDetails(r.state),
+			})
+		}
 		sms = append(sms, StateMachineSnapshot{
 			Name:    getStateMachineName(sm),
 			State:   getStateDetails(sm.currentState()),
 			Details: getStateMachineDetails(sm),
+			Regions: regions,
 		})
 	}
 
 	events := make([]EventSnapshot, 0)
 	for _, smID := range smIDs {
-		if evts, ok := w.env.queue[smID]; ok {
-			for _, evt := range evts {
+		for _, region := range regionNames(w.env.machines[smID]) {
+			for _, evt := range w.env.queue[queueID(smID, region)] {
 				events = append(events, EventSnapshot{
 					TargetMachine: getStateMachineName(w.env.machines[smID]),
+					TargetRegion:  region,
 					EventName:     getEventName(evt),
 					Details:       getEventDetails(evt),
+					Group:         evt.groupName(),
 				})
 			}
 		}
 	}
 
+	var vars []SharedVarSnapshot
+	for _, name := range sharedVarNames(w.env.vars) {
+		vars = append(vars, SharedVarSnapshot{
+			Name:    name,
+			Details: getSharedVarDetails(w.env.vars[name]),
+		})
+	}
+
 	return WorldSnapshot{
 		StateMachines: sms,
 		QueuedEvents:  events,
+		SharedVars:    vars,
+	}
+}
+
+// buildSteps replays each transition between consecutive worlds of ids to
+// recover which state machine moved and what it sent.
+func (m *model) buildSteps(ids []worldID) []Step {
+	if len(ids) < 2 {
+		return nil
+	}
+	steps := make([]Step, 0, len(ids)-1)
+	for i := 0; i+1 < len(ids); i++ {
+		steps = append(steps, findStep(m.worlds[ids[i]], m.worlds[ids[i+1]]))
+	}
+	return steps
+}
+
+func findStep(from, to world) Step {
+	gss, err := globalSteps(from.env)
+	if err != nil {
+		return Step{}
+	}
+	for _, gs := range gss {
+		if getInnerStateMachine(from.env.machines[gs.smID]).halted {
+			continue
+		}
+		if id(gs.env) == to.id {
+			step := describeStep(from.env, gs.env, gs.smID, gs.region)
+			step.Handler = gs.handler()
+			return step
+		}
+	}
+	return Step{}
+}
+
+func describeStep(from, to environment, smID, region string) Step {
+	qid := queueID(smID, region)
+	event := from.queue[qid][0]
+	step := Step{
+		Machine:      smID,
+		Region:       region,
+		EventName:    getEventName(event),
+		EventDetails: getEventDetails(event),
+		StateBefore:  getStateDetails(getInnerStateMachine(from.machines[smID]).stateIn(region)),
+		StateAfter:   getStateDetails(getInnerStateMachine(to.machines[smID]).stateIn(region)),
+	}
+
+	targets := make([]string, 0, len(to.machines))
+	for target := range to.machines {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		if _, ok := from.machines[target]; !ok {
+			step.Spawned = append(step.Spawned, target)
+			continue
+		}
+		before := from.queue[target]
+		if target == smID && region == "" {
+			before = before[1:]
+		}
+		after := to.queue[target]
+		if len(after) <= len(before) {
+			continue
+		}
+		for _, e := range after[len(before):] {
+			if isLifecycleEvent(e) {
+				continue
+			}
+			step.Sent = append(step.Sent, SentEvent{
+				Target:    target,
+				EventName: getEventName(e),
+				Details:   getEventDetails(e),
+				Group:     e.groupName(),
+			})
+		}
+	}
+	return step
+}
+
+// isLifecycleEvent reports whether e is one of the events a state machine
+// queues for itself to move between states.
+func isLifecycleEvent(e AbstractEvent) bool {
+	switch e.(type) {
+	case *entryEvent, *exitEvent, *transitionEvent, *ancestorEntryEvent, *ancestorExitEvent:
+		return true
 	}
+	return false
 }
@@ -74,6 +74,17 @@ func TestTest(t *testing.T) {
 								QueuedEvents:  []EventSnapshot{},
 							},
 						},
+						Steps: []Step{
+							{
+								Machine:      "testStateMachine",
+								EventName:    "entryEvent",
+								EventDetails: "no fields",
+								Handler:      -1,
+								StateBefore:  "{Name:Name,Type:string,Value:s}",
+								StateAfter:   "{Name:Name,Type:string,Value:s}",
+							},
+						},
+						LoopSteps: []Step{{}},
 					},
 				},
 				Summary: Summary{TotalWorlds: 2},
@@ -0,0 +1,191 @@
+package goat
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// WithSharedVar declares a shared variable that every handler can read and
+// write atomically through its context. Shared variables model state that is
+// not owned by a single component, such as a database row or a lock-free
+// register, without a dedicated state machine and request/response events.
+// Their values are part of each world, so they take part in state comparison
+// and appear in results.
+//
+// Parameters:
+//   - name: The name of the variable
+//   - init: The initial value of the variable
+//
+// Returns an Option that can be passed to Test() or Debug().
+//
+// Example:
+//
+//	goat.Test(
+//	    goat.WithStateMachines(writer1, writer2),
+//	    goat.WithSharedVar("balance", 100),
+//	)
+func WithSharedVar[T any](name string, init T) Option {
+	return optionFunc(func(o *options) {
+		if o.sharedVars == nil {
+			o.sharedVars = make(map[string]any)
+		}
+		o.sharedVars[name] = init
+	})
+}
+
+// GetSharedVar returns the current value of a shared variable.
+// This function must be called from within event handlers registered with
+// OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//   - name: The name given to WithSharedVar
+//
+// Panics if the variable was not declared or holds a value of another type.
+//
+// Example:
+//
+//	balance := goat.GetSharedVar[int](ctx, "balance")
+func GetSharedVar[T any](ctx context.Context, name string) T {
+	env := getEnvFromContext(ctx)
+	value, ok := env.vars[name]
+	if !ok {
+		panic(fmt.Sprintf("shared variable %q is not declared; use WithSharedVar", name))
+	}
+	typed, ok := value.(T)
+	if !ok {
+		panic(fmt.Sprintf("shared variable %q holds %T, not %T", name, value, typed))
+	}
+	return typed
+}
+
+// SetSharedVar updates the value of a shared variable.
+// This function must be called from within event handlers registered with
+// OnEvent, OnEntry, OnExit, OnTransition, or OnHalt functions.
+//
+// Parameters:
+//   - ctx: Context passed to the event handler
+//   - name: The name given to WithSharedVar
+//   - value: The new value
+//
+// Panics if the variable was not declared or holds a value of another type.
+//
+// Example:
+//
+//	goat.SetSharedVar(ctx, "balance", balance-amount)
+func SetSharedVar[T any](ctx context.Context, name string, value T) {
+	env := getEnvFromContext(ctx)
+	current, ok := env.vars[name]
+	if !ok {
+		panic(fmt.Sprintf("shared variable %q is not declared; use WithSharedVar", name))
+	}
+	// The variable keeps the type it was declared with, so that GetSharedVar
+	// cannot fail far from the handler setting a value of another type. The
+	// dynamic types are compared, as any value satisfies an interface T.
+	if current != nil && any(value) != nil && reflect.TypeOf(current) != reflect.TypeOf(value) {
+		panic(fmt.Sprintf("shared variable %q holds %T, not %T", name, current, value))
+	}
+	env.vars[name] = value
+}
+
+// NewSharedVarCondition creates a condition on the value of a shared variable.
+//
+// Parameters:
+//   - name: The condition name
+//   - varName: The name given to WithSharedVar
+//   - check: A predicate function that returns true if the condition holds
+//
+// Returns a Condition that can be used with Test() (for example via WithRules(Always(...))).
+// The condition does not hold when the variable is missing or has another type.
+//
+// Example:
+//
+//	nonNegative := goat.NewSharedVarCondition("non-negative", "balance", func(balance int) bool {
+//	    return balance >= 0
+//	})
+func NewSharedVarCondition[T any](name, varName string, check func(T) bool) Condition {
+	return conditionFunc{name: ConditionName(name), fn: func(w world) bool {
+		value, ok := lookupSharedVar[T](w.env, varName)
+		if !ok {
+			return false
+		}
+		return check(value)
+	}}
+}
+
+// LookupSharedVar provides type-safe access to a shared variable from the
+// Machines accessor passed to NewMultiCondition check functions.
+//
+// Parameters:
+//   - m: Machines accessor provided to the check function
+//   - name: The name given to WithSharedVar
+//
+// Returns the typed value and true on success. Returns the zero value
+// and false when the variable does not exist or the type does not match.
+//
+// Example:
+//
+//	goat.NewMultiCondition("ledger", func(machines goat.Machines) bool {
+//	    balance, ok := goat.LookupSharedVar[int](machines, "balance")
+//	    if !ok { return false }
+//	    a, ok := goat.GetMachine(machines, account)
+//	    if !ok { return false }
+//	    return a.Cached == balance
+//	}, account)
+func LookupSharedVar[T any](m Machines, name string) (T, bool) {
+	impl, ok := m.(*machinesImpl)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return lookupSharedVar[T](impl.world.env, name)
+}
+
+func lookupSharedVar[T any](env environment, name string) (T, bool) {
+	var zero T
+	value, ok := env.vars[name]
+	if !ok {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return typed, true
+}
+
+func cloneSharedVars(vars map[string]any) map[string]any {
+	if vars == nil {
+		return nil
+	}
+	vc := make(map[string]any, len(vars))
+	for name, value := range vars {
+		vc[name] = cloneValue(value)
+	}
+	return vc
+}
+
+func sharedVarNames(vars map[string]any) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getSharedVarDetails formats a shared variable like the fields of state
+// machines. Pointers are followed, so that the address of a value does not
+// take part in world identity.
+func getSharedVarDetails(value any) string {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Kind() == reflect.Pointer {
+		return fmt.Sprintf("{Type:%T,Value:<nil>}", value)
+	}
+	return fmt.Sprintf("{Type:%T,Value:%s}", value, valueDetails(v))
+}
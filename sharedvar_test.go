@@ -0,0 +1,195 @@
+package goat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type sharedVarRow struct {
+	Tags []string
+}
+
+func TestSharedVarAccess(t *testing.T) {
+	t.Run("reads and writes declared variables", func(t *testing.T) {
+		sm := newTestStateMachine(newTestState("s"))
+		env := newTestEnvironment(sm)
+		env.vars = map[string]any{"counter": 1}
+		ctx := withEnvAndSM(&env, sm)
+
+		SetSharedVar(ctx, "counter", GetSharedVar[int](ctx, "counter")+1)
+
+		if got := GetSharedVar[int](ctx, "counter"); got != 2 {
+			t.Errorf("GetSharedVar() = %d, want 2", got)
+		}
+	})
+
+	t.Run("panics on undeclared variable", func(t *testing.T) {
+		sm := newTestStateMachine(newTestState("s"))
+		env := newTestEnvironment(sm)
+		ctx := withEnvAndSM(&env, sm)
+
+		defer func() {
+			if recover() == nil {
+				t.Error("SetSharedVar() should panic for an undeclared variable")
+			}
+		}()
+		SetSharedVar(ctx, "missing", 1)
+	})
+
+	t.Run("panics on type mismatch", func(t *testing.T) {
+		sm := newTestStateMachine(newTestState("s"))
+		env := newTestEnvironment(sm)
+		env.vars = map[string]any{"counter": 1}
+		ctx := withEnvAndSM(&env, sm)
+
+		defer func() {
+			if recover() == nil {
+				t.Error("GetSharedVar() should panic for a type mismatch")
+			}
+		}()
+		GetSharedVar[string](ctx, "counter")
+	})
+
+	t.Run("panics when setting a value of another type", func(t *testing.T) {
+		sm := newTestStateMachine(newTestState("s"))
+		env := newTestEnvironment(sm)
+		env.vars = map[string]any{"counter": 1}
+		ctx := withEnvAndSM(&env, sm)
+
+		defer func() {
+			want := `shared variable "counter" holds int, not string`
+			if got := recover(); got != want {
+				t.Errorf("SetSharedVar() panic = %v, want %q", got, want)
+			}
+		}()
+		SetSharedVar(ctx, "counter", "one")
+	})
+
+	t.Run("panics when setting a value of another dynamic type", func(t *testing.T) {
+		sm := newTestStateMachine(newTestState("s"))
+		env := newTestEnvironment(sm)
+		env.vars = map[string]any{"counter": 1}
+		ctx := withEnvAndSM(&env, sm)
+
+		defer func() {
+			want := `shared variable "counter" holds int, not string`
+			if got := recover(); got != want {
+				t.Errorf("SetSharedVar() panic = %v, want %q", got, want)
+			}
+		}()
+		SetSharedVar[any](ctx, "counter", "one")
+	})
+}
+
+func TestGetSharedVarDetails(t *testing.T) {
+	first, second := 7, 7
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "value", value: 7, want: "{Type:int,Value:7}"},
+		{name: "pointer", value: &first, want: "{Type:*int,Value:7}"},
+		{name: "other pointer to an equal value", value: &second, want: "{Type:*int,Value:7}"},
+		{name: "nil pointer", value: (*int)(nil), want: "{Type:*int,Value:<nil>}"},
+		{name: "nil", value: nil, want: "{Type:<nil>,Value:<nil>}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getSharedVarDetails(tt.value); got != tt.want {
+				t.Errorf("getSharedVarDetails() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloneSharedVars(t *testing.T) {
+	original := map[string]any{
+		"row":     sharedVarRow{Tags: []string{"a"}},
+		"counter": 3,
+	}
+
+	cloned := cloneSharedVars(original)
+	cloned["row"].(sharedVarRow).Tags[0] = "b"
+
+	if diff := cmp.Diff([]string{"a"}, original["row"].(sharedVarRow).Tags); diff != "" {
+		t.Errorf("clone should not share slice fields (-want +got):\n%s", diff)
+	}
+	if cloned["counter"] != 3 {
+		t.Errorf("cloned counter = %v, want 3", cloned["counter"])
+	}
+	if cloneSharedVars(nil) != nil {
+		t.Error("cloning nil variables should return nil")
+	}
+}
+
+func TestSharedVars_modelChecking(t *testing.T) {
+	// Two writers increment a shared counter without coordination; the
+	// invariant only allows one increment to be observed.
+	idle := newTestState("idle")
+	spec := NewStateMachineSpec(&testStateMachine{})
+	spec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(spec, idle, func(ctx context.Context, _ *testStateMachine) {
+		SetSharedVar(ctx, "counter", GetSharedVar[int](ctx, "counter")+1)
+	})
+
+	w1, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+	w2, err := spec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	result, err := Test(
+		WithStateMachines(w1, w2),
+		WithSharedVar("counter", 0),
+		WithRules(Always(NewSharedVarCondition("counter<=1", "counter", func(c int) bool {
+			return c <= 1
+		}))),
+	)
+	if err != nil {
+		t.Fatalf("Test() returned error: %v", err)
+	}
+
+	if len(result.Violations) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(result.Violations))
+	}
+	path := result.Violations[0].Path
+	last := path[len(path)-1]
+	want := []SharedVarSnapshot{{Name: "counter", Details: "{Type:int,Value:2}"}}
+	if diff := cmp.Diff(want, last.SharedVars); diff != "" {
+		t.Errorf("shared vars of violating world mismatch (-want +got):\n%s", diff)
+	}
+	// {0,0 entries queued}, {1, one queued} x2 machines, {2, none queued}
+	if result.Summary.TotalWorlds != 4 {
+		t.Errorf("TotalWorlds = %d, want 4", result.Summary.TotalWorlds)
+	}
+}
+
+func TestLookupSharedVar(t *testing.T) {
+	sm := newTestStateMachine(newTestState("s"))
+	env := newTestEnvironment(sm)
+	env.vars = map[string]any{"flag": true}
+	w := newTestWorld(env)
+
+	cond := NewMultiCondition("flag-set", func(m Machines) bool {
+		flag, ok := LookupSharedVar[bool](m, "flag")
+		return ok && flag
+	}, sm)
+	if !cond.Evaluate(w) {
+		t.Error("condition should see the shared variable")
+	}
+
+	missing := NewMultiCondition("missing", func(m Machines) bool {
+		_, ok := LookupSharedVar[bool](m, "missing")
+		return ok
+	}, sm)
+	if missing.Evaluate(w) {
+		t.Error("lookup of an undeclared variable should fail")
+	}
+}
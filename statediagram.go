@@ -0,0 +1,256 @@
+package goat
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// stateDiagram is the state diagram of the main states or of one region of
+// a state machine type, built from the explored worlds.
+type stateDiagram struct {
+	// name is the state machine type name, followed by "#region" for a
+	// region.
+	name string
+	// states holds the details of the defined states in definition order,
+	// followed by any reached state that was not defined.
+	states  []string
+	initial string
+	reached map[string]bool
+	edges   map[stateEdge]bool
+}
+
+type stateEdge struct {
+	from  string
+	to    string
+	label string
+}
+
+func (d *stateDiagram) addState(details string) {
+	for _, s := range d.states {
+		if s == details {
+			return
+		}
+	}
+	d.states = append(d.states, details)
+}
+
+// recordTransitions records, for each state machine type, the states the
+// machines of env are in and the transitions of the steps gss. A transition
+// is attributed to the event whose handler called Goto.
+func (x *exploration) recordTransitions(env environment, gss []globalStep) {
+	for _, smID := range sortedMachineIDs(env) {
+		sm := env.machines[smID]
+		innerSM := getInnerStateMachine(sm)
+		for _, region := range regionNames(sm) {
+			d := x.diagram(sm, region)
+			state := getStateDetails(innerSM.stateIn(region))
+			d.addState(state)
+			d.reached[state] = true
+		}
+	}
+
+	for _, gs := range gss {
+		sm := env.machines[gs.smID]
+		innerSM := getInnerStateMachine(sm)
+		if innerSM.halted {
+			continue
+		}
+		to, ok := gotoTarget(env, gs.env, gs.smID, gs.region)
+		if !ok {
+			continue
+		}
+		d := x.diagram(sm, gs.region)
+		d.addState(to)
+		from := getStateDetails(innerSM.stateIn(gs.region))
+		d.edges[stateEdge{from: from, to: to, label: transitionLabel(env, gs.env, gs.smID, gs.region)}] = true
+	}
+}
+
+func (x *exploration) diagram(sm AbstractStateMachine, region string) *stateDiagram {
+	name := queueID(getStateMachineName(sm), region)
+	d, ok := x.diagrams[name]
+	if !ok {
+		d = newStateDiagram(name, getInnerStateMachine(sm).definition, region)
+		x.diagrams[name] = d
+	}
+	return d
+}
+
+// stateDiagrams returns the diagrams recorded by Solve, sorted by name.
+func (m *model) stateDiagrams() []*stateDiagram {
+	names := make([]string, 0, len(m.explored.diagrams))
+	for name := range m.explored.diagrams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]*stateDiagram, 0, len(names))
+	for _, name := range names {
+		result = append(result, m.explored.diagrams[name])
+	}
+	return result
+}
+
+func newStateDiagram(name string, def *specDefinition, region string) *stateDiagram {
+	d := &stateDiagram{
+		name:    name,
+		reached: make(map[string]bool),
+		edges:   make(map[stateEdge]bool),
+	}
+	if def == nil {
+		return d
+	}
+	states, initial := def.regionDefinition(region)
+	for _, s := range states {
+		d.addState(getStateDetails(s))
+	}
+	if initial != nil {
+		d.initial = getStateDetails(initial)
+	}
+	return d
+}
+
+// gotoTarget reports the state the step from one environment to the next
+// moved the region towards, if its handler called Goto.
+func gotoTarget(from, to environment, smID, region string) (string, bool) {
+	qid := queueID(smID, region)
+	before := len(from.queue[qid]) - 1
+	after := to.queue[qid]
+	if before < 0 || len(after) <= before {
+		return "", false
+	}
+	for _, e := range after[before:] {
+		if te, ok := e.(*transitionEvent); ok {
+			return getStateDetails(te.To), true
+		}
+	}
+	return "", false
+}
+
+// transitionLabel renders the event that triggered a transition followed by
+// the events sent while handling it, as in "eRequest / eResponse".
+func transitionLabel(from, to environment, smID, region string) string {
+	step := describeStep(from, to, smID, region)
+	if len(step.Sent) == 0 {
+		return step.EventName
+	}
+	sent := make([]string, 0, len(step.Sent))
+	for _, e := range step.Sent {
+		sent = append(sent, e.EventName)
+	}
+	return step.EventName + " / " + strings.Join(sent, ", ")
+}
+
+func (d *stateDiagram) sortedEdges() []stateEdge {
+	edges := make([]stateEdge, 0, len(d.edges))
+	for e := range d.edges {
+		edges = append(edges, e)
+	}
+	index := make(map[string]int, len(d.states))
+	for i, s := range d.states {
+		index[s] = i
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if index[a.from] != index[b.from] {
+			return index[a.from] < index[b.from]
+		}
+		if index[a.to] != index[b.to] {
+			return index[a.to] < index[b.to]
+		}
+		return a.label < b.label
+	})
+	return edges
+}
+
+// nodeID returns the identifier of a state of the diagram.
+func (d *stateDiagram) nodeID(details string) string {
+	for i, s := range d.states {
+		if s == details {
+			return fmt.Sprintf("%s_s%d", diagramIdentifier(d.name), i)
+		}
+	}
+	return ""
+}
+
+func writeStateDiagrams(w io.Writer, format DiagramFormat, diagrams []*stateDiagram) error {
+	var sb strings.Builder
+	switch format {
+	case FormatDOT:
+		writeDotStateDiagrams(&sb, diagrams)
+	case FormatMermaid:
+		writeMermaidStateDiagrams(&sb, diagrams)
+	default:
+		return fmt.Errorf("unsupported diagram format: %s", format)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeDotStateDiagrams(sb *strings.Builder, diagrams []*stateDiagram) {
+	sb.WriteString("digraph {\n")
+	for _, d := range diagrams {
+		id := diagramIdentifier(d.name)
+		fmt.Fprintf(sb, "  subgraph cluster_%s {\n", id)
+		fmt.Fprintf(sb, "    label=\"%s\";\n", escapeDot(d.name))
+		for _, s := range d.states {
+			fmt.Fprintf(sb, "    %s [ label=\"%s\"", d.nodeID(s), escapeDot(fieldsLabel(s)))
+			if !d.reached[s] {
+				sb.WriteString(", style=dashed, color=gray, fontcolor=gray")
+			}
+			sb.WriteString(" ];\n")
+		}
+		if d.initial != "" {
+			fmt.Fprintf(sb, "    %s_start [ shape=point ];\n", id)
+			fmt.Fprintf(sb, "    %s_start -> %s;\n", id, d.nodeID(d.initial))
+		}
+		for _, e := range d.sortedEdges() {
+			fmt.Fprintf(sb, "    %s -> %s [ label=\"%s\" ];\n", d.nodeID(e.from), d.nodeID(e.to), escapeDot(e.label))
+		}
+		sb.WriteString("  }\n")
+	}
+	sb.WriteString("}\n")
+}
+
+func writeMermaidStateDiagrams(sb *strings.Builder, diagrams []*stateDiagram) {
+	sb.WriteString("stateDiagram-v2\n")
+	sb.WriteString("    classDef unreached stroke-dasharray: 5 5, color: gray\n")
+	for _, d := range diagrams {
+		fmt.Fprintf(sb, "    state \"%s\" as %s {\n", escapeMermaidState(d.name), diagramIdentifier(d.name))
+		for _, s := range d.states {
+			fmt.Fprintf(sb, "        state \"%s\" as %s\n", escapeMermaidState(fieldsLabel(s)), d.nodeID(s))
+		}
+		if d.initial != "" {
+			fmt.Fprintf(sb, "        [*] --> %s\n", d.nodeID(d.initial))
+		}
+		for _, e := range d.sortedEdges() {
+			fmt.Fprintf(sb, "        %s --> %s: %s\n", d.nodeID(e.from), d.nodeID(e.to), escapeMermaid(e.label))
+		}
+		sb.WriteString("    }\n")
+		for _, s := range d.states {
+			if !d.reached[s] {
+				fmt.Fprintf(sb, "    class %s unreached\n", d.nodeID(s))
+			}
+		}
+	}
+}
+
+// diagramIdentifier turns name into an identifier accepted by every diagram
+// format by replacing anything but letters, digits and underscores.
+func diagramIdentifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func escapeDot(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+func escapeMermaidState(s string) string {
+	return strings.ReplaceAll(escapeMermaid(s), `"`, "#quot;")
+}
@@ -0,0 +1,108 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newStateDiagramTestOptions(t *testing.T) []Option {
+	t.Helper()
+	idle := newTestState("idle")
+	done := newTestState("done")
+	unused := newTestState("unused")
+
+	receiverSpec := NewStateMachineSpec(&testStateMachine{})
+	receiverSpec.DefineStates(idle, done, unused).SetInitialState(idle)
+	OnEvent(receiverSpec, idle, func(ctx context.Context, _ *testEvent, sm *testStateMachine) {
+		SendTo(ctx, sm, &testEvent{Value: 2})
+		Goto(ctx, done)
+	})
+	receiver, err := receiverSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	senderSpec := NewStateMachineSpec(&hierarchyTestStateMachine{})
+	senderSpec.DefineStates(idle).SetInitialState(idle)
+	OnEntry(senderSpec, idle, func(ctx context.Context, _ *hierarchyTestStateMachine) {
+		SendTo(ctx, receiver, &testEvent{Value: 1})
+	})
+	sender, err := senderSpec.NewInstance()
+	if err != nil {
+		t.Fatalf("NewInstance() returned error: %v", err)
+	}
+
+	return []Option{WithStateMachines(receiver, sender)}
+}
+
+func TestWriteStateDiagram(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  DiagramFormat
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "dot",
+			format: FormatDOT,
+			want: `digraph {
+  subgraph cluster_hierarchyTestStateMachine {
+    label="hierarchyTestStateMachine";
+    hierarchyTestStateMachine_s0 [ label="Name=idle" ];
+    hierarchyTestStateMachine_start [ shape=point ];
+    hierarchyTestStateMachine_start -> hierarchyTestStateMachine_s0;
+  }
+  subgraph cluster_testStateMachine {
+    label="testStateMachine";
+    testStateMachine_s0 [ label="Name=idle" ];
+    testStateMachine_s1 [ label="Name=done" ];
+    testStateMachine_s2 [ label="Name=unused", style=dashed, color=gray, fontcolor=gray ];
+    testStateMachine_start [ shape=point ];
+    testStateMachine_start -> testStateMachine_s0;
+    testStateMachine_s0 -> testStateMachine_s1 [ label="testEvent / testEvent" ];
+  }
+}
+`,
+		},
+		{
+			name:   "mermaid",
+			format: FormatMermaid,
+			want: `stateDiagram-v2
+    classDef unreached stroke-dasharray: 5 5, color: gray
+    state "hierarchyTestStateMachine" as hierarchyTestStateMachine {
+        state "Name=idle" as hierarchyTestStateMachine_s0
+        [*] --> hierarchyTestStateMachine_s0
+    }
+    state "testStateMachine" as testStateMachine {
+        state "Name=idle" as testStateMachine_s0
+        state "Name=done" as testStateMachine_s1
+        state "Name=unused" as testStateMachine_s2
+        [*] --> testStateMachine_s0
+        testStateMachine_s0 --> testStateMachine_s1: testEvent / testEvent
+    }
+    class testStateMachine_s2 unreached
+`,
+		},
+		{
+			name:    "unsupported format",
+			format:  FormatPlantUML,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteStateDiagram(&buf, tt.format, newStateDiagramTestOptions(t)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteStateDiagram() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("diagram mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -26,6 +26,36 @@ type StateMachineSpec[T AbstractStateMachine] struct {
 	states          []AbstractState
 	initialState    AbstractState
 	handlerBuilders map[AbstractState][]handlerBuilderInfo
+	parents         map[AbstractState]AbstractState
+	regions         []regionSpec
+}
+
+type regionSpec struct {
+	name         string
+	states       []AbstractState
+	initialState AbstractState
+}
+
+// specDefinition records the states a StateMachineSpec defined when an
+// instance was created, so that reports can list states no world reached.
+type specDefinition struct {
+	states       []AbstractState
+	initialState AbstractState
+	regions      []regionSpec
+}
+
+// regionDefinition returns the states and initial state of the named region,
+// or of the main states for the empty name.
+func (d *specDefinition) regionDefinition(name string) ([]AbstractState, AbstractState) {
+	if name == "" {
+		return d.states, d.initialState
+	}
+	for _, r := range d.regions {
+		if r.name == name {
+			return r.states, r.initialState
+		}
+	}
+	return nil, nil
 }
 
 // NewStateMachineSpec creates a new state machine specification with
@@ -84,6 +114,85 @@ func (spec *StateMachineSpec[T]) SetInitialState(state AbstractState) *StateMach
 	return spec
 }
 
+// DefineSubstates declares states nested inside a parent state.
+// The substates become valid states of the specification, and while a
+// state machine is in one of them, OnEvent handlers registered for the
+// parent (or any further ancestor) apply unless the substate registers
+// its own handler for the same event. Goto exits and enters every state
+// between the source and target and their closest common ancestor, so
+// OnExit and OnEntry handlers of parent states run in hierarchy order.
+//
+// Parameters:
+//   - parent: A state already passed to DefineStates or DefineSubstates
+//   - substates: States nested directly inside parent
+//
+// Returns the spec for method chaining.
+//
+// Example:
+//
+//	spec.DefineStates(StoppedState{}, RunningState{}).
+//	     DefineSubstates(RunningState{}, HealthyState{}, DegradedState{}).
+//	     SetInitialState(HealthyState{})
+func (spec *StateMachineSpec[T]) DefineSubstates(parent AbstractState, substates ...AbstractState) *StateMachineSpec[T] {
+	if spec.parents == nil {
+		spec.parents = make(map[AbstractState]AbstractState)
+	}
+	for _, state := range substates {
+		spec.states = append(spec.states, state)
+		spec.parents[state] = parent
+		spec.setDefaultHandlerBuilders(state)
+	}
+	return spec
+}
+
+// DefineRegion adds an orthogonal region to the specification. A region
+// has its own current state and its own event queue, independent of the
+// states passed to DefineStates, so the model checker interleaves the
+// processing of each region with the rest of the system. Every event sent
+// to an instance is delivered to the main states and to each region;
+// handlers registered for a region's states react to it there.
+//
+// Parameters:
+//   - name: A name identifying the region within the specification
+//   - states: All valid states of the region
+//
+// Returns the spec for method chaining.
+//
+// Example:
+//
+//	spec.DefineStates(DisconnectedState{}, ConnectedState{}).
+//	     SetInitialState(DisconnectedState{}).
+//	     DefineRegion("leadership", FollowerState{}, LeaderState{}).
+//	     SetRegionInitialState("leadership", FollowerState{})
+func (spec *StateMachineSpec[T]) DefineRegion(name string, states ...AbstractState) *StateMachineSpec[T] {
+	spec.regions = append(spec.regions, regionSpec{name: name, states: states})
+	for _, state := range states {
+		spec.setDefaultHandlerBuilders(state)
+	}
+	return spec
+}
+
+// SetRegionInitialState defines which state the named region starts in.
+// The provided state must be one of the states passed to DefineRegion.
+//
+// Parameters:
+//   - name: The name of a region defined with DefineRegion
+//   - state: The state the region should start in
+//
+// Returns the spec for method chaining.
+//
+// Example:
+//
+//	spec.SetRegionInitialState("leadership", FollowerState{})
+func (spec *StateMachineSpec[T]) SetRegionInitialState(name string, state AbstractState) *StateMachineSpec[T] {
+	for i := range spec.regions {
+		if spec.regions[i].name == name {
+			spec.regions[i].initialState = state
+		}
+	}
+	return spec
+}
+
 func (spec *StateMachineSpec[T]) setDefaultHandlerBuilders(state AbstractState) {
 	transitionBuilder := func(smID string) handler {
 		return &defaultOnTransitionHandler{}
@@ -107,13 +216,61 @@ func (spec *StateMachineSpec[T]) validate() error {
 		return fmt.Errorf("state machine spec has no initial state")
 	}
 
-	for _, definedState := range spec.states {
-		if sameState(definedState, spec.initialState) {
-			return nil
+	if !spec.isDefined(spec.initialState) {
+		return fmt.Errorf("initial state is not in defined states")
+	}
+
+	names := make(map[string]bool)
+	for _, r := range spec.regions {
+		if r.name == "" {
+			return fmt.Errorf("region name must not be empty")
+		}
+		if names[r.name] {
+			return fmt.Errorf("region %s is defined more than once", r.name)
+		}
+		names[r.name] = true
+		if r.initialState == nil {
+			return fmt.Errorf("region %s has no initial state", r.name)
+		}
+		if !containsState(r.states, r.initialState) {
+			return fmt.Errorf("initial state of region %s is not in its states", r.name)
+		}
+	}
+
+	for state, parent := range spec.parents {
+		if !spec.isDefined(state) {
+			return fmt.Errorf("substate %s is not in defined states", getStateDetails(state))
+		}
+		if !spec.isDefined(parent) {
+			return fmt.Errorf("parent state %s is not in defined states", getStateDetails(parent))
+		}
+		if len(stateLineage(spec.parents, state)) > len(spec.parents)+1 {
+			return fmt.Errorf("state hierarchy of %s contains a cycle", getStateDetails(state))
+		}
+	}
+
+	return nil
+}
+
+func (spec *StateMachineSpec[T]) isDefined(state AbstractState) bool {
+	if containsState(spec.states, state) {
+		return true
+	}
+	for _, r := range spec.regions {
+		if containsState(r.states, state) {
+			return true
 		}
 	}
+	return false
+}
 
-	return fmt.Errorf("initial state is not in defined states")
+func containsState(states []AbstractState, state AbstractState) bool {
+	for _, s := range states {
+		if sameState(s, state) {
+			return true
+		}
+	}
+	return false
 }
 
 // NewInstance creates a new state machine instance based on this specification.
@@ -143,6 +300,16 @@ func (spec *StateMachineSpec[T]) NewInstance() (T, error) {
 	innerSM.HandlerBuilders = make(map[AbstractState][]handlerBuilderInfo)
 	innerSM.State = spec.initialState
 	innerSM.halted = false
+	innerSM.parents = spec.parents
+	innerSM.definition = &specDefinition{
+		states:       append([]AbstractState{}, spec.states...),
+		initialState: spec.initialState,
+		regions:      append([]regionSpec{}, spec.regions...),
+	}
+	innerSM.regions = nil
+	for _, r := range spec.regions {
+		innerSM.regions = append(innerSM.regions, region{name: r.name, state: r.initialState})
+	}
 
 	for state, builders := range spec.handlerBuilders {
 		innerSM.HandlerBuilders[state] = append([]handlerBuilderInfo{}, builders...)
@@ -233,9 +400,18 @@ type StateMachine struct {
 	EventHandlers   map[AbstractState][]handlerInfo
 	HandlerBuilders map[AbstractState][]handlerBuilderInfo
 	halted          bool
+	parents         map[AbstractState]AbstractState
+	regions         []region
+	definition      *specDefinition
 	State           AbstractState
 }
 
+// region holds the current state of an orthogonal region of an instance.
+type region struct {
+	name  string
+	state AbstractState
+}
+
 func (*StateMachine) isStateMachine() bool {
 	return true
 }
@@ -273,6 +449,163 @@ func (sm *StateMachine) SetInitialState(state AbstractState) {
 	sm.State = state
 }
 
+// RegionState returns the current state of the named orthogonal region,
+// or nil if the state machine has no such region.
+//
+// Parameters:
+//   - name: The name of a region defined with DefineRegion
+//
+// Example:
+//
+//	cond := goat.NewCondition("leader", sm, func(sm *Node) bool {
+//	    return sm.RegionState("leadership") != nil
+//	})
+func (sm *StateMachine) RegionState(name string) AbstractState {
+	if name == "" {
+		return nil
+	}
+	return sm.stateIn(name)
+}
+
+// stateIn returns the current state of the named region. The empty name
+// refers to the main states defined with DefineStates.
+func (sm *StateMachine) stateIn(name string) AbstractState {
+	if name == "" {
+		return sm.State
+	}
+	for _, r := range sm.regions {
+		if r.name == name {
+			return r.state
+		}
+	}
+	return nil
+}
+
+func (sm *StateMachine) setStateIn(name string, state AbstractState) {
+	if name == "" {
+		sm.State = state
+		return
+	}
+	for i := range sm.regions {
+		if sm.regions[i].name == name {
+			sm.regions[i].state = state
+		}
+	}
+}
+
+// lineage returns state followed by its ancestors, innermost first.
+func (sm *StateMachine) lineage(state AbstractState) []AbstractState {
+	return stateLineage(sm.parents, state)
+}
+
+// handlerScopes returns the states whose handlers are consulted, in order,
+// when event is dequeued from the named region, together with the event
+// those handlers are registered for. Entry and exit events only run the
+// handlers of a single state. Transition and halt events run the handlers of
+// the innermost state of the lineage registering some with OnTransition or
+// OnHalt, as every state has default ones. Other events fall back to the
+// handlers of the ancestors of the current state when the current state does
+// not handle them.
+func (sm *StateMachine) handlerScopes(region string, event AbstractEvent) ([]AbstractState, AbstractEvent) {
+	current := sm.stateIn(region)
+	switch e := event.(type) {
+	case *ancestorEntryEvent:
+		return []AbstractState{e.State}, &entryEvent{}
+	case *ancestorExitEvent:
+		return []AbstractState{e.State}, &exitEvent{}
+	case *entryEvent, *exitEvent:
+		return []AbstractState{current}, event
+	case *transitionEvent, *haltEvent:
+		for _, state := range sm.lineage(current) {
+			if sm.hasRegisteredHandler(state, event) {
+				return []AbstractState{state}, event
+			}
+		}
+		return []AbstractState{current}, event
+	}
+	return sm.lineage(current), event
+}
+
+// hasRegisteredHandler reports whether state has a handler for event other
+// than the default transition and halt handlers.
+func (sm *StateMachine) hasRegisteredHandler(state AbstractState, event AbstractEvent) bool {
+	for s, his := range sm.EventHandlers {
+		if !sameState(s, state) {
+			continue
+		}
+		for _, hi := range his {
+			if !sameEvent(hi.event, event) {
+				continue
+			}
+			switch hi.handler.(type) {
+			case *defaultOnTransitionHandler, *defaultOnHaltHandler:
+			default:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// transitionPath returns the ancestor states that must be exited and entered,
+// in order, when moving between the given states. The source and target
+// themselves are exited and entered by the regular exit and entry events and
+// are not included.
+func (sm *StateMachine) transitionPath(source, to AbstractState) (exits, entries []AbstractState) {
+	from := sm.lineage(source)
+	target := sm.lineage(to)
+
+	fromIdx, targetIdx := len(from), len(target)
+	for i := 1; i < len(from) && fromIdx == len(from); i++ {
+		for j := 1; j < len(target); j++ {
+			if sameState(from[i], target[j]) {
+				fromIdx, targetIdx = i, j
+				break
+			}
+		}
+	}
+
+	exits = from[1:fromIdx]
+	for j := targetIdx - 1; j >= 1; j-- {
+		entries = append(entries, target[j])
+	}
+	return exits, entries
+}
+
+// stateLineage walks the parent relation upwards from state. The walk stops
+// after len(parents)+2 steps so that a cyclic hierarchy cannot loop forever;
+// validate reports such cycles.
+func stateLineage(parents map[AbstractState]AbstractState, state AbstractState) []AbstractState {
+	lineage := []AbstractState{state}
+	for len(lineage) <= len(parents)+1 {
+		parent := parentState(parents, lineage[len(lineage)-1])
+		if parent == nil {
+			break
+		}
+		lineage = append(lineage, parent)
+	}
+	return lineage
+}
+
+func parentState(parents map[AbstractState]AbstractState, state AbstractState) AbstractState {
+	for child, parent := range parents {
+		if sameState(child, state) {
+			return parent
+		}
+	}
+	return nil
+}
+
+// regionNames returns the empty name of the main states followed by the
+// names of the orthogonal regions of sm, in definition order.
+func regionNames(sm AbstractStateMachine) []string {
+	names := []string{""}
+	for _, r := range getInnerStateMachine(sm).regions {
+		names = append(names, r.name)
+	}
+	return names
+}
+
 func getInnerStateMachine(sm AbstractStateMachine) *StateMachine {
 	v := reflect.ValueOf(sm)
 	if !v.IsValid() {
@@ -303,6 +636,21 @@ func getStateMachineName(sm AbstractStateMachine) string {
 	return v.Type().Name()
 }
 
+// fieldDetail formats a field of a state machine, state or event for its
+// details.
+func fieldDetail(name string, field reflect.Value) string {
+	return fmt.Sprintf("{Name:%s,Type:%s,Value:%s}", name, field.Type(), valueDetails(field))
+}
+
+// valueDetails formats a value of details, which cannot show unexported
+// fields.
+func valueDetails(v reflect.Value) string {
+	if !v.CanInterface() {
+		return "[UNACCESSIBLE]"
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
 func getStateMachineDetails(sm AbstractStateMachine) string {
 	v := reflect.ValueOf(sm)
 	if !v.IsValid() {
@@ -324,13 +672,7 @@ func getStateMachineDetails(sm AbstractStateMachine) string {
 		}
 
 		if fieldName != "StateMachine" {
-			if field.CanInterface() {
-				fieldType := field.Type().String()
-				fieldValue := field.Interface()
-				fieldDetails = append(fieldDetails, fmt.Sprintf("{Name:%s,Type:%s,Value:%v}", fieldName, fieldType, fieldValue))
-			} else {
-				fieldDetails = append(fieldDetails, fmt.Sprintf("{Name:%s,Type:%s,Value:[UNACCESSIBLE]}", fieldName, field.Type().String()))
-			}
+			fieldDetails = append(fieldDetails, fieldDetail(fieldName, field))
 		}
 	}
 
@@ -359,13 +701,7 @@ func getStateDetails(s AbstractState) string {
 		fieldName := t.Field(i).Name
 
 		if fieldName != "State" {
-			if field.CanInterface() {
-				fieldType := field.Type().String()
-				fieldValue := field.Interface()
-				fieldDetails = append(fieldDetails, fmt.Sprintf("{Name:%s,Type:%s,Value:%v}", fieldName, fieldType, fieldValue))
-			} else {
-				fieldDetails = append(fieldDetails, fmt.Sprintf("{Name:%s,Type:%s,Value:[UNACCESSIBLE]}", fieldName, field.Type().String()))
-			}
+			fieldDetails = append(fieldDetails, fieldDetail(fieldName, field))
 		}
 	}
 
@@ -1,9 +1,11 @@
 package goat
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestNewStateMachineSpec(t *testing.T) {
@@ -182,3 +184,182 @@ func TestGetStateDetails(t *testing.T) {
 		}
 	})
 }
+
+func TestStateMachineSpec_DefineSubstates(t *testing.T) {
+	t.Run("adds substates with default handlers and records parents", func(t *testing.T) {
+		spec := NewStateMachineSpec(&testStateMachine{})
+		running := newTestState("running")
+		healthy := newTestState("healthy")
+		degraded := newTestState("degraded")
+
+		result := spec.DefineStates(running).DefineSubstates(running, healthy, degraded)
+
+		if result != spec {
+			t.Error("DefineSubstates should return self for method chaining")
+		}
+		if !cmp.Equal(spec.states, []AbstractState{running, healthy, degraded}) {
+			t.Errorf("States mismatch:\n%s", cmp.Diff([]AbstractState{running, healthy, degraded}, spec.states))
+		}
+		for _, state := range []AbstractState{healthy, degraded} {
+			if len(spec.handlerBuilders[state]) != 2 {
+				t.Errorf("Expected exactly 2 default handlers for substate, got %d", len(spec.handlerBuilders[state]))
+			}
+			if spec.parents[state] != running {
+				t.Errorf("Expected parent of %s to be running", getStateDetails(state))
+			}
+		}
+	})
+}
+
+func TestStateMachineSpec_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(spec *StateMachineSpec[*testStateMachine])
+		wantErr string
+	}{
+		{
+			name: "valid nested states",
+			setup: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineStates(newTestState("running")).
+					DefineSubstates(newTestState("running"), newTestState("healthy")).
+					SetInitialState(newTestState("healthy"))
+			},
+		},
+		{
+			name: "missing initial state",
+			setup: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineStates(newTestState("running"))
+			},
+			wantErr: "state machine spec has no initial state",
+		},
+		{
+			name: "undefined parent state",
+			setup: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineSubstates(newTestState("running"), newTestState("healthy")).
+					SetInitialState(newTestState("healthy"))
+			},
+			wantErr: "parent state {Name:Name,Type:string,Value:running} is not in defined states",
+		},
+		{
+			name: "substates dropped by a later DefineStates",
+			setup: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineStates(newTestState("running")).
+					DefineSubstates(newTestState("running"), newTestState("healthy"))
+				spec.DefineStates(newTestState("running")).
+					SetInitialState(newTestState("running"))
+			},
+			wantErr: "substate {Name:Name,Type:string,Value:healthy} is not in defined states",
+		},
+		{
+			name: "region without initial state",
+			setup: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineStates(newTestState("a")).
+					SetInitialState(newTestState("a")).
+					DefineRegion("r", newTestState("x"))
+			},
+			wantErr: "region r has no initial state",
+		},
+		{
+			name: "region initial state outside the region",
+			setup: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineStates(newTestState("a")).
+					SetInitialState(newTestState("a")).
+					DefineRegion("r", newTestState("x")).
+					SetRegionInitialState("r", newTestState("a"))
+			},
+			wantErr: "initial state of region r is not in its states",
+		},
+		{
+			name: "duplicate region name",
+			setup: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineStates(newTestState("a")).
+					SetInitialState(newTestState("a")).
+					DefineRegion("r", newTestState("x")).
+					DefineRegion("r", newTestState("y")).
+					SetRegionInitialState("r", newTestState("x"))
+			},
+			wantErr: "region r is defined more than once",
+		},
+		{
+			name: "cyclic hierarchy",
+			setup: func(spec *StateMachineSpec[*testStateMachine]) {
+				spec.DefineStates(newTestState("a")).
+					DefineSubstates(newTestState("a"), newTestState("b")).
+					DefineSubstates(newTestState("b"), newTestState("a")).
+					SetInitialState(newTestState("a"))
+			},
+			wantErr: "contains a cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewStateMachineSpec(&testStateMachine{})
+			tt.setup(spec)
+
+			err := spec.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStateMachine_transitionPath(t *testing.T) {
+	// root
+	// ├── running
+	// │   ├── healthy
+	// │   └── degraded
+	// └── stopped
+	root := newTestState("root")
+	running := newTestState("running")
+	healthy := newTestState("healthy")
+	degraded := newTestState("degraded")
+	stopped := newTestState("stopped")
+
+	spec := NewStateMachineSpec(&testStateMachine{})
+	spec.DefineStates(root).
+		DefineSubstates(root, running, stopped).
+		DefineSubstates(running, healthy, degraded).
+		SetInitialState(healthy)
+
+	tests := []struct {
+		name        string
+		from        AbstractState
+		to          AbstractState
+		wantExits   []AbstractState
+		wantEntries []AbstractState
+	}{
+		{name: "between siblings", from: healthy, to: degraded},
+		{name: "self transition", from: healthy, to: healthy},
+		{name: "out of a nested state", from: healthy, to: stopped, wantExits: []AbstractState{running}},
+		{name: "into a nested state", from: stopped, to: degraded, wantEntries: []AbstractState{running}},
+		{name: "to the parent state", from: healthy, to: running, wantExits: []AbstractState{running}},
+		{name: "from the parent state", from: running, to: healthy, wantEntries: []AbstractState{running}},
+		{name: "to the outermost state", from: degraded, to: root, wantExits: []AbstractState{running, root}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm, err := spec.NewInstance()
+			if err != nil {
+				t.Fatalf("NewInstance() returned error: %v", err)
+			}
+
+			exits, entries := getInnerStateMachine(sm).transitionPath(tt.from, tt.to)
+
+			if diff := cmp.Diff(tt.wantExits, exits, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("exits mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantEntries, entries, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 	"time"
 )
 
@@ -17,7 +16,8 @@ import (
 //
 // Returns:
 //   - *Result: verification results (violations, path information, summary)
-//   - error: if model creation or solving fails
+//   - error: if model creation or solving fails, or if WithFailOnDeadHandlers
+//     is used and a handler never fired, in which case the result is returned too
 //
 // Example:
 //
@@ -28,7 +28,7 @@ import (
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
-//	// result is automatically printed to stdout
+//	// result is printed to stdout unless WithReporter or WithOutput is used
 func Test(opts ...Option) (*Result, error) {
 	model, err := newModel(opts...)
 	if err != nil {
@@ -43,7 +43,16 @@ func Test(opts ...Option) (*Result, error) {
 	executionTime := time.Since(start).Milliseconds()
 
 	result := model.buildResult(trResults, executionTime)
-	_, _ = fmt.Fprint(os.Stdout, result)
+	if model.reportCoverage {
+		result.Coverage = model.coverage()
+	}
+	model.report().Result(result)
+
+	if model.failOnDeadHandlers {
+		if dead := result.Coverage.DeadHandlers(); len(dead) > 0 {
+			return result, fmt.Errorf("%d handler(s) never fired", len(dead))
+		}
+	}
 
 	return result, nil
 }
@@ -65,6 +74,55 @@ func WithStateMachines(sms ...AbstractStateMachine) Option {
 	})
 }
 
+// WithMaxInstances bounds the number of state machines a world may contain
+// once instances are created at runtime with Spawn. When the bound is
+// reached, Spawn creates no instance and reports false. Without this option
+// the number of instances is unbounded.
+//
+// Parameters:
+//   - n: The maximum number of state machines, including those passed to WithStateMachines
+//
+// Returns an Option that can be passed to Test() or Debug().
+//
+// Example:
+//
+//	goat.WithMaxInstances(5)
+func WithMaxInstances(n int) Option {
+	return optionFunc(func(o *options) {
+		o.maxInstances = n
+	})
+}
+
+// WithCoverage makes Test report in Result.Coverage which states each spec
+// reached, which handlers fired and which received events no handler
+// processed. Computing coverage replays every explored step once more.
+//
+// Returns an Option that can be passed to Test().
+//
+// Example:
+//
+//	result, err := goat.Test(goat.WithStateMachines(sm), goat.WithCoverage())
+func WithCoverage() Option {
+	return optionFunc(func(o *options) {
+		o.coverage = true
+	})
+}
+
+// WithFailOnDeadHandlers enables coverage like WithCoverage and makes Test
+// return an error when a registered handler, or one of the non-deterministic
+// alternatives registered for the same state and event, never fired.
+//
+// Returns an Option that can be passed to Test().
+//
+// Example:
+//
+//	_, err := goat.Test(goat.WithStateMachines(sm), goat.WithFailOnDeadHandlers())
+func WithFailOnDeadHandlers() Option {
+	return optionFunc(func(o *options) {
+		o.failOnDeadHandlers = true
+	})
+}
+
 // Debug performs model checking and outputs detailed JSON results.
 // Unlike Test(), this function provides comprehensive debugging information
 // including all explored worlds and their states in JSON format.
@@ -141,3 +199,38 @@ func WriteDot(w io.Writer, opts ...Option) error {
 	model.writeDot(w)
 	return nil
 }
+
+// WriteStateDiagram explores the model and writes a state diagram for each
+// state machine type in it, and for each of its orthogonal regions. Nodes
+// are the states defined by the spec, and edges the transitions observed
+// during exploration, labelled with the event whose handler called Goto and
+// the events it sent. The initial state is marked, and states that no
+// explored world reached are drawn dashed.
+//
+// Parameters:
+//   - w: Destination for the diagram
+//   - format: FormatDOT or FormatMermaid
+//   - opts: Configuration options, as for Test
+//
+// Returns an error if the format is unsupported, the model cannot be
+// built or explored, or writing to w fails.
+//
+// Example:
+//
+//	err := goat.WriteStateDiagram(os.Stdout, goat.FormatMermaid, goat.WithStateMachines(client, server))
+func WriteStateDiagram(w io.Writer, format DiagramFormat, opts ...Option) error {
+	if format != FormatDOT && format != FormatMermaid {
+		return fmt.Errorf("unsupported diagram format: %s", format)
+	}
+
+	model, err := newModel(opts...)
+	if err != nil {
+		return err
+	}
+
+	model.explored = newExploration()
+	if err := model.Solve(); err != nil {
+		return err
+	}
+	return writeStateDiagrams(w, format, model.stateDiagrams())
+}